@@ -0,0 +1,85 @@
+package crashreport
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Parameters of the adaptive timeout
+const (
+	latencySamples    = 100 // the recent submissions whose latency is tracked
+	latencyMultiplier = 3   // the timeout is this multiple of the 95th percentile of the latencies
+)
+
+// WithAdaptiveTimeout bounds each submission by a timeout following the recent latency of Raygun, instead of a fixed
+// one: three times the 95th percentile of the latencies of the last 100 successful submissions, clamped between min
+// and max. It's max until a submission succeeds. So a slow link isn't given up too early, and a hung connection
+// doesn't block for long when Raygun usually answers fast. The timeout of the http client still applies, 5s by
+// default, see WithClient. The current timeout is in Stats().SubmitTimeout.
+func WithAdaptiveTimeout(min, max time.Duration) Option {
+	return func(r *Reporter) {
+		r.adaptiveTimeout = &adaptiveTimeout{min: min, max: max}
+	}
+}
+
+// adaptiveTimeout tracks the latency of the submissions, see WithAdaptiveTimeout
+type adaptiveTimeout struct {
+	min, max time.Duration
+
+	mu        sync.Mutex
+	latencies []time.Duration // a ring of the last latencySamples
+	next      int             // the index of the next sample in latencies
+}
+
+// observe records the latency of a successful submission
+func (a *adaptiveTimeout) observe(latency time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.latencies) < latencySamples {
+		a.latencies = append(a.latencies, latency)
+		return
+	}
+	a.latencies[a.next] = latency
+	a.next = (a.next + 1) % latencySamples
+}
+
+// timeout returns the timeout of the next submission
+func (a *adaptiveTimeout) timeout() time.Duration {
+	if a == nil {
+		return 0
+	}
+	a.mu.Lock()
+	if len(a.latencies) == 0 {
+		a.mu.Unlock()
+		return a.max
+	}
+	sorted := append([]time.Duration(nil), a.latencies...)
+	a.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	timeout := latencyMultiplier * sorted[(len(sorted)*95-1)/100]
+	if timeout < a.min {
+		return a.min
+	}
+	if timeout > a.max {
+		return a.max
+	}
+	return timeout
+}
+
+// bound returns the context of a submission, bounded by the timeout, and the function to call with its result
+func (a *adaptiveTimeout) bound(ctx context.Context) (context.Context, func(error)) {
+	if a == nil {
+		return ctx, func(error) {}
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.timeout())
+	start := timeNow()
+	return ctx, func(err error) {
+		cancel()
+		if err == nil {
+			a.observe(timeNow().Sub(start))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package crashreport
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithAdaptiveTimeout(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	clock := time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return clock }
+
+	var latency time.Duration
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		clock = clock.Add(latency)
+		return &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody, Request: req}, nil
+	})}
+	reporter := NewReporter("key", WithClient(client), WithAdaptiveTimeout(50*time.Millisecond, 2*time.Second))
+	submit := func(n int, d time.Duration) {
+		latency = d
+		for i := 0; i < n; i++ {
+			if err := reporter.Report(errors.New("boom")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if timeout := reporter.Stats().SubmitTimeout; timeout != 2*time.Second {
+		t.Errorf("expected the max before any submission, got %s", timeout)
+	}
+	tests := []struct {
+		n       int
+		latency time.Duration
+		timeout time.Duration
+	}{
+		{20, 100 * time.Millisecond, 300 * time.Millisecond},
+		{100, 10 * time.Millisecond, 50 * time.Millisecond}, // clamped to the min
+		{100, time.Second, 2 * time.Second},                 // clamped to the max
+		{94, 100 * time.Millisecond, 2 * time.Second},       // 6 slow submissions are left
+		{1, 100 * time.Millisecond, 300 * time.Millisecond}, // the slow ones are out of the 95th percentile
+	}
+	for _, test := range tests {
+		submit(test.n, test.latency)
+		if timeout := reporter.Stats().SubmitTimeout; timeout != test.timeout {
+			t.Errorf("after %d submissions of %s, expected a timeout of %s, got %s", test.n, test.latency, test.timeout,
+				timeout)
+		}
+	}
+}
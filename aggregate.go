@@ -0,0 +1,112 @@
+package crashreport
+
+import (
+	"sync"
+	"time"
+)
+
+// WithAffectedUsers aggregates the occurrences of each error by user: the first occurrence of an error starts a
+// window, during which the other occurrences only add their user. When the window ends, the first occurrence is
+// submitted with the distinct User.Identifier under the "affectedUsers" key of the custom data, and their number under
+// "affectedUserCount". Errors are identified by their Fingerprint, or by their StackFingerprint with
+// WithStackOnlyFingerprint. The groups still open are submitted by Close. An occurrence that occurred a window after
+// the first one of its group, such as a report replayed WithOccurredOn, submits the group right away and opens a new
+// one.
+func WithAffectedUsers(window time.Duration) Option {
+	return func(r *Reporter) {
+		r.aggregate.window = window
+		r.aggregate.groups = map[string]*userGroup{}
+	}
+}
+
+// aggregate holds the occurrences of each error until the end of their window
+type aggregate struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	groups map[string]*userGroup // by fingerprint
+}
+
+type userGroup struct {
+	pendingPost
+	timer *time.Timer
+	users []string
+	seen  map[string]bool
+	start time.Time // the occurrence time of the first occurrence
+}
+
+// add adds the user of the post to the group, if it's new
+func (g *userGroup) add(post Post) {
+	user := post.Details.User.Identifier
+	if user == "" || g.seen[user] {
+		return
+	}
+	g.seen[user] = true
+	g.users = append(g.users, user)
+}
+
+// report returns the first occurrence of the error, with the affected users
+func (g *userGroup) report() Post {
+	post := g.post
+	post.SetCustomData("affectedUsers", g.users)
+	post.SetCustomData("affectedUserCount", len(g.users))
+	return post
+}
+
+// aggregated adds the post to the group of its error, or opens one if there is none
+func (r *Reporter) aggregated(post Post, key string) {
+	fingerprint := r.fingerprint(post)
+	at := occurredAt(post)
+
+	r.aggregate.mu.Lock()
+	var expired []pendingPost
+	if g, ok := r.aggregate.groups[fingerprint]; ok {
+		if at.Sub(g.start) < r.aggregate.window {
+			g.add(post)
+			r.aggregate.mu.Unlock()
+			r.dropped(post, DropDeduped)
+			return
+		}
+		// Replayed after the window of the group, which is submitted now
+		if g.timer.Stop() {
+			expired = append(expired, pendingPost{g.report(), g.key})
+		}
+		delete(r.aggregate.groups, fingerprint)
+	}
+	g := &userGroup{pendingPost: pendingPost{post, key}, seen: map[string]bool{}, start: at}
+	post.outcome.record(OutcomeQueued, nil)
+	g.add(post)
+	g.timer = time.AfterFunc(r.aggregate.window, func() {
+		r.aggregate.mu.Lock()
+		if r.aggregate.groups[fingerprint] == g {
+			delete(r.aggregate.groups, fingerprint)
+		}
+		post := g.report()
+		r.aggregate.mu.Unlock()
+
+		r.dispatch(post, g.key)
+	})
+	r.aggregate.groups[fingerprint] = g
+	r.aggregate.mu.Unlock()
+
+	for _, p := range expired {
+		r.dispatch(p.post, p.key)
+	}
+}
+
+// flushAggregated submits the open groups without waiting for the end of their window
+func (r *Reporter) flushAggregated() {
+	r.aggregate.mu.Lock()
+	var pending []pendingPost
+	for fingerprint, g := range r.aggregate.groups {
+		if g.timer.Stop() {
+			pending = append(pending, pendingPost{g.report(), g.key})
+			delete(r.aggregate.groups, fingerprint)
+		}
+	}
+	r.aggregate.mu.Unlock()
+
+	for _, p := range pending {
+		r.dispatch(p.post, p.key)
+	}
+}
@@ -0,0 +1,53 @@
+package crashreport
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithAffectedUsers(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithAffectedUsers(time.Minute))
+	for _, user := range []string{"ann", "bob", "ann", "carl", "dave", "", "eve", "bob"} {
+		reporter.Report(errors.New("checkout failed"), WithUser(user))
+	}
+	if n := len(server.Posts()); n != 0 {
+		t.Fatalf("nothing should be sent before the end of the window, got %d posts", n)
+	}
+	reporter.Close(time.Second)
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	details := sent[0].Details
+	if details.User.Identifier != "ann" {
+		t.Errorf("the first occurrence should be sent, got user %q", details.User.Identifier)
+	}
+	data := details.UserCustomData.(map[string]interface{})
+	users := []interface{}{"ann", "bob", "carl", "dave", "eve"}
+	if !reflect.DeepEqual(data["affectedUsers"], users) {
+		t.Errorf("expected the distinct users %v, got %v", users, data["affectedUsers"])
+	}
+	if data["affectedUserCount"] != float64(5) {
+		t.Errorf("expected 5 affected users, got %v", data["affectedUserCount"])
+	}
+}
+
+func TestWithAffectedUsersWindow(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithAffectedUsers(20*time.Millisecond))
+	reporter.Report(errors.New("checkout failed"), WithUser("ann"))
+	reporter.Report(errors.New("login failed"), WithUser("bob"))
+	time.Sleep(100 * time.Millisecond)
+
+	if n := len(server.Posts()); n != 2 {
+		t.Errorf("expected each error to be sent at the end of its window, got %d posts", n)
+	}
+}
@@ -0,0 +1,31 @@
+package crashreport
+
+import "strings"
+
+// WithAppModule sets the module of the application: the frames of its packages are marked InApp, so that they stand
+// out from the frames of the standard library and the dependencies. By default it's the main module of the binary,
+// read from its build info.
+func WithAppModule(prefix string) Option {
+	return func(r *Reporter) {
+		r.appModule = strings.TrimSuffix(prefix, "/")
+	}
+}
+
+// mainModule returns the path of the main module of the binary, or an empty string if it's unknown
+func mainModule() string {
+	info, ok := readBuildInfo()
+	if !ok {
+		return ""
+	}
+	return info.Main.Path
+}
+
+// markInApp returns a copy of the stacktrace whose frames are marked when they belong to the module
+func markInApp(stack StackTrace, module string) StackTrace {
+	marked := make(StackTrace, len(stack))
+	for i, frame := range stack {
+		frame.InApp = frame.PackageName == module || strings.HasPrefix(frame.PackageName, module+"/")
+		marked[i] = frame
+	}
+	return marked
+}
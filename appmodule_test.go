@@ -0,0 +1,36 @@
+package crashreport
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithAppModule(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	stack := StackTrace{}
+	stack.AddEntry(10, "github.com/acme/shop/billing", "charge.go", "Charge")
+	stack.AddEntry(20, "github.com/acme/shop", "main.go", "main")
+	stack.AddEntry(30, "github.com/acme/shopping", "cart.go", "Add")
+	stack.AddEntry(40, "net/http", "server.go", "ServeHTTP")
+	stack.AddEntry(50, "", "juju.go", "")
+	err := Error{Message: "declined", StackTrace: stack}
+
+	NewReporter("key", WithAppModule("github.com/acme/shop/")).Report(err)
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	var inApp []bool
+	for _, frame := range sent[0].Details.Error.StackTrace {
+		inApp = append(inApp, frame.InApp)
+	}
+	if expected := []bool{true, true, false, false, false}; !reflect.DeepEqual(inApp, expected) {
+		t.Errorf("expected frames marked %v, got %v", expected, inApp)
+	}
+	if stack[0].InApp {
+		t.Error("the stacktrace of the error should not be modified")
+	}
+}
@@ -0,0 +1,205 @@
+package crashreport
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrQueueFull is returned by the reports of an asynchronous reporter when its queue is full
+var ErrQueueFull = errors.New("crashreport: queue full")
+
+// ErrClosed is returned by the reports of a closed reporter
+var ErrClosed = errors.New("crashreport: reporter closed")
+
+// WithAsync makes the reporter submit in the background: reports are queued and return immediately, and the given
+// number of workers submit them. When the queue is full, the reports fail with ErrQueueFull.
+// Use Flush to wait for the queued reports and Close before exiting.
+func WithAsync(workers, queueSize int) Option {
+	return func(r *Reporter) {
+		if workers < 1 {
+			workers = 1
+		}
+		r.async.workers = workers
+		r.async.queue = make(chan queuedPost, queueSize)
+	}
+}
+
+// WithQueueByteLimit limits the size of the queue of an asynchronous reporter to n bytes of json, in addition to its
+// number of reports: when a report would take the queued and in-flight reports over n bytes, it fails with
+// ErrQueueFull. A report bigger than n is only accepted by an empty queue.
+func WithQueueByteLimit(n int64) Option {
+	return func(r *Reporter) {
+		r.async.byteLimit = n
+	}
+}
+
+// flusher is implemented by the sinks that keep posts, such as BatchSink
+type flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// async holds the state of the background workers
+type async struct {
+	workers   int
+	queue     chan queuedPost
+	running   sync.WaitGroup
+	byteLimit int64
+
+	mu      sync.Mutex
+	closed  bool
+	pending int           // queued and in-flight reports
+	bytes   int64         // size of the pending reports, when byteLimit is set
+	idle    chan struct{} // closed when pending drops to 0
+
+	unsent int64 // reports dropped because the reporter was closed
+}
+
+// queuedPost is a report in the queue, with its size if the queue is limited in bytes
+type queuedPost struct {
+	pendingPost
+	size int64
+}
+
+// start starts the workers, if the reporter is asynchronous
+func (r *Reporter) start() {
+	for i := 0; i < r.async.workers; i++ {
+		r.async.running.Add(1)
+		go r.work()
+	}
+}
+
+// work submits the queued reports until the queue is closed. Once the reporter context is cancelled, the remaining
+// reports are counted as unsent.
+func (r *Reporter) work() {
+	defer r.async.running.Done()
+	for p := range r.async.queue {
+		if err := r.ctx.Err(); err != nil {
+			atomic.AddInt64(&r.async.unsent, 1)
+			p.post.outcome.record(OutcomeFailed, err)
+			r.writeLastResort(p.post)
+		} else if err := r.submit(p.post, p.key); err != nil && r.ctx.Err() != nil {
+			atomic.AddInt64(&r.async.unsent, 1)
+		}
+		r.done(p.size)
+	}
+}
+
+// enqueue queues a report for the workers
+func (r *Reporter) enqueue(post Post, key string) error {
+	p := queuedPost{pendingPost: pendingPost{post, key}}
+	if r.async.byteLimit > 0 {
+		body, err := marshalPost(post)
+		if err != nil {
+			err = errors.Wrap(err, "convert to json")
+			post.outcome.record(OutcomeFailed, err)
+			return err
+		}
+		p.size = int64(len(body))
+	}
+
+	err := r.push(p)
+	switch {
+	case err == ErrQueueFull:
+		r.dropped(post, DropQueueFull)
+		r.writeLastResort(post)
+	case err != nil:
+		post.outcome.record(OutcomeFailed, err)
+		r.writeLastResort(post)
+	default:
+		post.outcome.record(OutcomeQueued, nil)
+	}
+	return err
+}
+
+// push adds a report to the queue, if there is room
+func (r *Reporter) push(p queuedPost) error {
+	r.async.mu.Lock()
+	defer r.async.mu.Unlock()
+
+	if r.async.closed {
+		return ErrClosed
+	}
+	if r.async.bytes > 0 && r.async.bytes+p.size > r.async.byteLimit {
+		return ErrQueueFull
+	}
+	select {
+	case r.async.queue <- p:
+	default:
+		return ErrQueueFull
+	}
+	if r.async.pending == 0 {
+		r.async.idle = make(chan struct{})
+	}
+	r.async.pending++
+	r.async.bytes += p.size
+	return nil
+}
+
+// done marks a queued report of the given size as processed
+func (r *Reporter) done(size int64) {
+	r.async.mu.Lock()
+	defer r.async.mu.Unlock()
+
+	r.async.pending--
+	r.async.bytes -= size
+	if r.async.pending == 0 {
+		close(r.async.idle)
+	}
+}
+
+// Flush waits until the queued reports are submitted, or the timeout expires. It returns false on timeout.
+// It returns immediately for a synchronous reporter.
+func (r *Reporter) Flush(timeout time.Duration) bool {
+	r.async.mu.Lock()
+	if r.async.pending == 0 {
+		r.async.mu.Unlock()
+		return true
+	}
+	idle := r.async.idle
+	r.async.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Close stops accepting reports and waits up to timeout for the queued ones to be submitted. Then the submissions
+// still in flight are cancelled, so that a hung connection doesn't block the shutdown.
+// A sink with a `Flush(context.Context) error` method, such as BatchSink, is flushed within the same timeout.
+// It returns the number of reports that couldn't be sent in time.
+func (r *Reporter) Close(timeout time.Duration) int {
+	deadline := time.Now().Add(timeout)
+	r.flushAggregated()
+	r.flushDebounced()
+	r.flushRollups()
+
+	r.async.mu.Lock()
+	if r.async.closed {
+		r.async.mu.Unlock()
+		return 0
+	}
+	r.async.closed = true
+	r.async.mu.Unlock()
+
+	r.Flush(timeout)
+	if sink, ok := r.sink.(flusher); ok {
+		ctx, cancel := context.WithDeadline(r.ctx, deadline)
+		sink.Flush(ctx)
+		cancel()
+	}
+	r.cancel()
+	if r.async.queue != nil {
+		close(r.async.queue)
+		r.async.running.Wait()
+	}
+	return int(atomic.LoadInt64(&r.async.unsent))
+}
@@ -0,0 +1,121 @@
+package crashreport
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestAsync(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithAsync(2, 10))
+	for i := 0; i < 5; i++ {
+		if err := reporter.Report(errors.New("failure")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !reporter.Flush(5 * time.Second) {
+		t.Fatal("flush should not time out")
+	}
+	if sent := len(server.Posts()); sent != 5 {
+		t.Errorf("expected 5 posts after flush, got %d", sent)
+	}
+
+	if unsent := reporter.Close(time.Second); unsent != 0 {
+		t.Errorf("expected no unsent reports, got %d", unsent)
+	}
+	if err := reporter.Report(errors.New("failure")); err != ErrClosed {
+		t.Errorf("reports after close should fail with ErrClosed, got %v", err)
+	}
+}
+
+func TestAsyncCloseCancelsInFlight(t *testing.T) {
+	started := make(chan struct{})
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		close(started)
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})}
+
+	reporter := NewReporter("key", WithClient(client), WithAsync(1, 10))
+	if err := reporter.Report(errors.New("failure")); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	begin := time.Now()
+	unsent := reporter.Close(100 * time.Millisecond)
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("close should return shortly after the timeout, took %s", elapsed)
+	}
+	if unsent != 1 {
+		t.Errorf("the in-flight report should be unsent, got %d", unsent)
+	}
+}
+
+func TestAsyncQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		<-block
+		return nil, errors.New("unreachable")
+	})}
+
+	reporter := NewReporter("key", WithClient(client), WithAsync(1, 1))
+	defer reporter.Close(0)
+	defer close(block)
+
+	var full bool
+	for i := 0; i < 3; i++ {
+		if err := reporter.Report(errors.New("failure")); err == ErrQueueFull {
+			full = true
+		}
+	}
+	if !full {
+		t.Error("reports should fail with ErrQueueFull when the queue is full")
+	}
+}
+
+func TestQueueByteLimit(t *testing.T) {
+	block := make(blockingSink)
+	drops := &dropRecorder{reasons: map[DropReason]int{}}
+	reporter := NewReporter("key", WithSink(block), WithAsync(1, 100), WithQueueByteLimit(10<<10),
+		WithDropObserver(drops.observe))
+	defer reporter.Close(0)
+
+	padding := WithCustomData("padding", strings.Repeat("x", 4<<10))
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		err := reporter.Report(errors.New("failure"), padding)
+		if err == ErrQueueFull {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		accepted++
+	}
+	if accepted != 2 {
+		t.Errorf("the byte limit should stop the queue after 2 reports of 4kB, got %d", accepted)
+	}
+	if n := drops.Count(DropQueueFull); n != 1 {
+		t.Errorf("the rejected report should be dropped as queue full, got %d", n)
+	}
+
+	close(block)
+	reporter.Flush(time.Second)
+	if err := reporter.Report(errors.New("failure"), padding); err != nil {
+		t.Errorf("the submitted reports should free the queue, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package crashreport
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// AutoTagKind is a tag derived from the content of the reports, see WithAutoTags
+type AutoTagKind int
+
+// Kinds of derived tags
+const (
+	AutoTagMethod     AutoTagKind = iota + 1 // the method of the request, as "method:GET"
+	AutoTagStatus                            // the class of the response status, as "status:5xx"
+	AutoTagErrorClass                        // the class of the error, as "error:*net.OpError"
+)
+
+// wrapperTypes are the types of the errors that only wrap other errors, skipped by AutoTagErrorClass
+var wrapperTypes = map[string]bool{
+	"*fmt.wrapError":      true,
+	"*fmt.wrapErrors":     true,
+	"*errors.withStack":   true,
+	"*errors.withMessage": true,
+}
+
+// WithAutoTags tags the reports with values derived from their content, to filter the dashboards without tagging
+// every report. The class of the error is Error.ClassName, or else the go type of the error, skipping the wrappers of
+// fmt.Errorf and pkg/errors. The tags already present on a report are not added twice, and the reports without a
+// request, a response or an error class don't get the corresponding tag.
+func WithAutoTags(kinds ...AutoTagKind) Option {
+	return func(r *Reporter) {
+		r.enrichers = append(r.enrichers, func(post *Post) {
+			for _, kind := range kinds {
+				if tag := autoTag(post, kind); tag != "" && !hasTag(post.Details.Tags, tag) {
+					post.Details.Tags = append(post.Details.Tags, tag)
+				}
+			}
+		})
+	}
+}
+
+// autoTag derives a tag from the post, or returns an empty string
+func autoTag(post *Post, kind AutoTagKind) string {
+	switch kind {
+	case AutoTagMethod:
+		if method := post.Details.Request.HTTPMethod; method != "" {
+			return "method:" + method
+		}
+	case AutoTagStatus:
+		if status := post.Details.Response.StatusCode; status >= 100 && status < 600 {
+			return "status:" + strconv.Itoa(status/100) + "xx"
+		}
+	case AutoTagErrorClass:
+		if class := errorClass(post); class != "" {
+			return "error:" + class
+		}
+	}
+	return ""
+}
+
+// errorClass returns the class of the error of the post, see WithAutoTags
+func errorClass(post *Post) string {
+	if post.Details.Error.ClassName != "" {
+		return post.Details.Error.ClassName
+	}
+	err := post.err
+	for err != nil && wrapperTypes[fmt.Sprintf("%T", err)] {
+		next, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = next.Unwrap()
+	}
+	switch err.(type) {
+	case nil, Error, *Error:
+		// The class of an Error is its ClassName
+		return ""
+	default:
+		return fmt.Sprintf("%T", err)
+	}
+}
+
+// hasTag tells if the tag is in the list
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package crashreport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	pkerr "github.com/pkg/errors"
+)
+
+func TestWithAutoTags(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithAutoTags(AutoTagMethod, AutoTagStatus, AutoTagErrorClass))
+	opErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	wrapped := pkerr.Wrap(fmt.Errorf("call inventory: %w", opErr), "reserve")
+	reporter.ReportRequest(wrapped, httptest.NewRequest("POST", "/orders", nil),
+		WithTags("checkout", "status:5xx"), func(post *Post) {
+			post.Details.Response.StatusCode = 503
+		})
+	NewReporter("key", WithAutoTags(AutoTagStatus, AutoTagErrorClass)).Report(Error{Message: "declined"})
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	expected := []string{"checkout", "status:5xx", "method:POST", "error:*net.OpError"}
+	if tags := sent[0].Details.Tags; !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected the tags %v, got %v", expected, tags)
+	}
+	if tags := sent[1].Details.Tags; len(tags) != 0 {
+		t.Errorf("a report without request nor error class should not be tagged, got %v", tags)
+	}
+}
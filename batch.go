@@ -0,0 +1,246 @@
+package crashreport
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Default limits of a BatchSink
+const (
+	defaultBatchSize     = 100
+	defaultBatchBytes    = 1 << 20
+	defaultBatchInterval = 5 * time.Second
+)
+
+// BatchOption configures a BatchSink
+type BatchOption func(*BatchSink)
+
+// WithBatchSize sets the maximum number of posts of a batch, 100 by default
+func WithBatchSize(n int) BatchOption {
+	return func(s *BatchSink) {
+		s.maxCount = n
+	}
+}
+
+// WithBatchBytes sets the maximum size in bytes of the json body of a batch, 1MB by default
+func WithBatchBytes(n int) BatchOption {
+	return func(s *BatchSink) {
+		s.maxBytes = n
+	}
+}
+
+// WithBatchInterval sets how long a post can wait for its batch to fill up, 5s by default
+func WithBatchInterval(d time.Duration) BatchOption {
+	return func(s *BatchSink) {
+		s.interval = d
+	}
+}
+
+// WithFlushJitter randomizes the interval of each batch by up to fraction of the interval, more or less, so that the
+// instances of a service started together don't submit their batches at the same time. With an interval of 5s and
+// a fraction of 0.2, each batch is submitted after 4 to 6s.
+func WithFlushJitter(fraction float64) BatchOption {
+	return func(s *BatchSink) {
+		s.jitter = fraction
+	}
+}
+
+// WithBatchCompression gzips the bodies of the batches, sent with the header Content-Encoding: gzip
+func WithBatchCompression(enabled bool) BatchOption {
+	return func(s *BatchSink) {
+		s.compress = enabled
+	}
+}
+
+// WithBatchEndpoint sets the endpoint of the raygun api, by default the value of Endpoint when the sink is created
+func WithBatchEndpoint(endpoint string) BatchOption {
+	return func(s *BatchSink) {
+		s.endpoint = endpoint
+	}
+}
+
+// WithBatchClient sets the http client used to submit the batches. By default it has a 5s timeout.
+func WithBatchClient(client *http.Client) BatchOption {
+	return func(s *BatchSink) {
+		s.client = client
+	}
+}
+
+// BatchSink submits the posts in batches to the bulk endpoint of Raygun, one batch per api key. A batch is submitted
+// when it's full, either by number of posts or by size of its body, or when its oldest post waited for the interval.
+// A post that would take the body over the size limit is kept for the next batch, and a post bigger than the limit
+// is submitted alone.
+//
+// Posts submitted by Send return the error of the submission if they filled the batch, nil otherwise. The reporter
+// flushes the sink when it's closed.
+type BatchSink struct {
+	endpoint string
+	client   *http.Client
+	maxCount int
+	maxBytes int
+	interval time.Duration
+	jitter   float64
+	compress bool
+
+	afterFunc func(time.Duration, func()) *time.Timer // time.AfterFunc, a variable for the tests
+
+	mu      sync.Mutex
+	rand    *rand.Rand        // for the jitter, guarded by mu
+	batches map[string]*batch // by api key
+}
+
+// batch holds the posts, marshaled, waiting to be submitted with an api key
+type batch struct {
+	key   string
+	posts [][]byte
+	size  int // of the json array of the posts
+	timer *time.Timer
+}
+
+// NewBatchSink creates a sink submitting posts in batches, see WithSink
+func NewBatchSink(opts ...BatchOption) *BatchSink {
+	s := &BatchSink{
+		endpoint: Endpoint,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		maxCount: defaultBatchSize,
+		maxBytes: defaultBatchBytes,
+		interval: defaultBatchInterval,
+		batches:  map[string]*batch{},
+
+		afterFunc: time.AfterFunc,
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Send adds the post to the batch of its key, and submits the batch if it's full
+func (s *BatchSink) Send(ctx context.Context, post Post, key string) error {
+	data, err := marshalPost(post)
+	if err != nil {
+		return errors.Wrap(err, "marshal post")
+	}
+
+	s.mu.Lock()
+	var full []*batch
+	b := s.batches[key]
+	if b != nil && b.size+1+len(data) > s.maxBytes {
+		full = append(full, s.take(key))
+		b = nil
+	}
+	if b == nil {
+		b = &batch{key: key, size: len("[]")}
+		if s.interval > 0 {
+			b.timer = s.afterFunc(s.nextInterval(), func() { s.expire(b) })
+		}
+		s.batches[key] = b
+	}
+	if len(b.posts) > 0 {
+		b.size++ // comma
+	}
+	b.posts = append(b.posts, data)
+	b.size += len(data)
+	if len(b.posts) >= s.maxCount || b.size >= s.maxBytes {
+		full = append(full, s.take(key))
+	}
+	s.mu.Unlock()
+
+	for _, b := range full {
+		if e := s.submit(ctx, b); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Flush submits the pending batches, and returns the first error
+func (s *BatchSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	var pending []*batch
+	for key := range s.batches {
+		pending = append(pending, s.take(key))
+	}
+	s.mu.Unlock()
+
+	var err error
+	for _, b := range pending {
+		if e := s.submit(ctx, b); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// nextInterval returns the interval of a new batch, with a new jitter
+func (s *BatchSink) nextInterval() time.Duration {
+	if s.jitter <= 0 {
+		return s.interval
+	}
+	return time.Duration(float64(s.interval) * (1 + s.jitter*(2*s.rand.Float64()-1)))
+}
+
+// take removes the batch of the key, to be submitted
+func (s *BatchSink) take(key string) *batch {
+	b := s.batches[key]
+	delete(s.batches, key)
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	return b
+}
+
+// expire submits the batch once its oldest post waited for the interval. Errors are dropped, as nobody is waiting.
+func (s *BatchSink) expire(b *batch) {
+	s.mu.Lock()
+	if s.batches[b.key] != b {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.batches, b.key)
+	s.mu.Unlock()
+
+	s.submit(context.Background(), b)
+}
+
+// gzipJSONHeader is the header of the gzipped json bodies
+var gzipJSONHeader = http.Header{"Content-Type": {"application/json"}, "Content-Encoding": {"gzip"}}
+
+// submit posts the batch to the bulk endpoint
+func (s *BatchSink) submit(ctx context.Context, b *batch) error {
+	if s.compress {
+		body := getBuffer()
+		gz := getGzipWriter(body)
+		defer gzipPool.Put(gz)
+		b.writeTo(gz)
+		if err := gz.Close(); err != nil {
+			putBuffer(body)
+			return errors.Wrap(err, "gzip batch")
+		}
+		return postBody(ctx, body, gzipJSONHeader, s.endpoint+"/entries/bulk", b.key, s.client)
+	}
+
+	body := getBuffer()
+	body.Grow(b.size)
+	b.writeTo(body)
+	return postJSON(ctx, body, s.endpoint+"/entries/bulk", b.key, s.client)
+}
+
+// writeTo writes the json array of the posts of the batch
+func (b *batch) writeTo(w io.Writer) {
+	w.Write([]byte{'['})
+	for i, post := range b.posts {
+		if i > 0 {
+			w.Write([]byte{','})
+		}
+		w.Write(post)
+	}
+	w.Write([]byte{']'})
+}
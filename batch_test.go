@@ -0,0 +1,187 @@
+package crashreport
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bulkServer records the batches posted to the bulk endpoint
+type bulkServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	batches   [][]Post
+	sizes     []int
+	gzipped   []bool
+	rejectNth int // the index of a batch answered by a 503, if positive, see rejectBatch
+}
+
+func newBulkServer(t *testing.T) *bulkServer {
+	s := &bulkServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/entries/bulk" || req.Header.Get("X-ApiKey") != "key" {
+			t.Errorf("unexpected request %s with key %q", req.URL.Path, req.Header.Get("X-ApiKey"))
+		}
+		var body io.Reader = req.Body
+		gzipped := req.Header.Get("Content-Encoding") == "gzip"
+		if gzipped {
+			gz, err := gzip.NewReader(req.Body)
+			if err != nil {
+				t.Errorf("gunzip batch: %s", err)
+				return
+			}
+			body = gz
+		}
+		var posts []Post
+		if err := json.NewDecoder(body).Decode(&posts); err != nil {
+			t.Errorf("decode batch: %s", err)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.rejectNth--; s.rejectNth == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		s.batches = append(s.batches, posts)
+		s.sizes = append(s.sizes, int(req.ContentLength))
+		s.gzipped = append(s.gzipped, gzipped)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	return s
+}
+
+// rejectBatch answers the nth batch received from now on, starting at 1, with a 503
+func (s *bulkServer) rejectBatch(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejectNth = n
+}
+
+// Counts returns the number of posts of each batch received so far
+func (s *bulkServer) Counts() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make([]int, len(s.batches))
+	for i, batch := range s.batches {
+		counts[i] = len(batch)
+	}
+	return counts
+}
+
+// postOfSize returns a post whose json is size bytes long
+func postOfSize(t *testing.T, size int) Post {
+	post := Post{}
+	post.SetCustomData("pad", "")
+	base, _ := json.Marshal(post)
+	if size < len(base) {
+		t.Fatalf("a post can't be smaller than %d bytes", len(base))
+	}
+	post.SetCustomData("pad", strings.Repeat("x", size-len(base)))
+	return post
+}
+
+func TestBatchSinkBytes(t *testing.T) {
+	server := newBulkServer(t)
+	defer server.Close()
+
+	sink := NewBatchSink(WithBatchEndpoint(server.URL), WithBatchBytes(1000), WithBatchInterval(0))
+	ctx := context.Background()
+	for _, size := range []int{300, 300, 300, 300, 2000, 200, 200} {
+		if err := sink.Send(ctx, postOfSize(t, size), "key"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// [300 300 300] is 904 bytes, a fourth post would take it over 1000
+	// [300] is flushed early by the oversized post, which is sent alone
+	expected := []int{3, 1, 1, 2}
+	counts := server.Counts()
+	if len(counts) != len(expected) {
+		t.Fatalf("expected batches of %v posts, got %v", expected, counts)
+	}
+	for i := range expected {
+		if counts[i] != expected[i] {
+			t.Errorf("expected batches of %v posts, got %v", expected, counts)
+		}
+	}
+	for i, size := range server.sizes {
+		if size > 1000 && counts[i] != 1 {
+			t.Errorf("batch %d is %d bytes, over the limit", i, size)
+		}
+	}
+	if server.sizes[0] != 904 {
+		t.Errorf("expected the first batch to be 904 bytes, got %d", server.sizes[0])
+	}
+}
+
+func TestBatchSinkReporter(t *testing.T) {
+	server := newBulkServer(t)
+	defer server.Close()
+
+	sink := NewBatchSink(WithBatchEndpoint(server.URL), WithBatchSize(2), WithBatchInterval(50*time.Millisecond))
+	reporter := NewReporter("key", WithSink(sink))
+	for i := 0; i < 3; i++ {
+		reporter.CaptureMessage("batched")
+	}
+	if counts := server.Counts(); len(counts) != 1 || counts[0] != 2 {
+		t.Errorf("expected a full batch of 2 posts, got %v", counts)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if counts := server.Counts(); len(counts) != 2 || counts[1] != 1 {
+		t.Errorf("expected the last post to be sent after the interval, got %v", counts)
+	}
+
+	reporter.CaptureMessage("closing")
+	reporter.Close(time.Second)
+	if counts := server.Counts(); len(counts) != 3 {
+		t.Errorf("expected the pending batch to be flushed on close, got %v", counts)
+	}
+}
+
+func TestFlushJitter(t *testing.T) {
+	interval := time.Second
+	delays := func(seed int64) []time.Duration {
+		sink := NewBatchSink(WithBatchEndpoint("http://127.0.0.1:0"), WithFlushJitter(0.2), WithBatchInterval(interval))
+		sink.rand = rand.New(rand.NewSource(seed))
+		var delays []time.Duration
+		sink.afterFunc = func(d time.Duration, f func()) *time.Timer {
+			delays = append(delays, d)
+			return time.AfterFunc(time.Hour, f)
+		}
+		for i := 0; i < 20; i++ {
+			sink.Send(context.Background(), Post{}, "key")
+			sink.take("key")
+		}
+		return delays
+	}
+
+	first := delays(1)
+	distinct := map[time.Duration]bool{}
+	for _, d := range first {
+		if d < 800*time.Millisecond || d > 1200*time.Millisecond {
+			t.Errorf("expected the delays within 20%% of the interval, got %s", d)
+		}
+		distinct[d] = true
+	}
+	if len(first) != 20 || len(distinct) < 15 {
+		t.Errorf("expected a new jitter for each batch, got %v", first)
+	}
+	for i, d := range delays(1) {
+		if d != first[i] {
+			t.Fatalf("expected the same delays with the same seed, got %s and %s", d, first[i])
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package crashreport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// MaxBodySize is the size of the request bodies above which FromReq only keeps the first MaxBodySize bytes, with the
+// total length and the SHA-256 of the body.
+var MaxBodySize int64 = 64 << 10
+
+// bodyCapture records a request body while it's read: the beginning of the body, its length and its hash. Only the
+// beginning is kept in memory.
+type bodyCapture struct {
+	io.Reader // the body, teed to the capture
+	io.Closer
+
+	limit  int64
+	head   bytes.Buffer
+	length int64
+	hash   hash.Hash
+}
+
+// captureBody wraps the body of the request so that it's recorded as it's read
+func captureBody(req *http.Request) *bodyCapture {
+	if c, ok := req.Body.(*bodyCapture); ok {
+		return c
+	}
+	c := &bodyCapture{Closer: req.Body, limit: MaxBodySize, hash: sha256.New()}
+	c.Reader = io.TeeReader(req.Body, c)
+	req.Body = c
+	return c
+}
+
+func (c *bodyCapture) Write(p []byte) (int, error) {
+	if room := c.limit - int64(c.head.Len()); room > 0 {
+		if int64(len(p)) < room {
+			room = int64(len(p))
+		}
+		c.head.Write(p[:room])
+	}
+	c.length += int64(len(p))
+	c.hash.Write(p)
+	return len(p), nil
+}
+
+// truncated tells if the body is longer than the part kept in memory
+func (c *bodyCapture) truncated() bool {
+	return c.length > int64(c.head.Len())
+}
+
+// rawData returns the body for Request.RawData: the whole body if it's short enough, or its beginning with its length
+// and hash
+func (c *bodyCapture) rawData() interface{} {
+	if !c.truncated() {
+		return c.head.Bytes()
+	}
+	return map[string]interface{}{
+		"body":   c.head.String(),
+		"length": c.length,
+		"sha256": hex.EncodeToString(c.hash.Sum(nil)),
+	}
+}
+
+// readBody reads the rest of the request body and returns it for Request.RawData. A body that fits in MaxBodySize is
+// given back to the request, to be read again. A longer one is consumed, unless it was already read through the
+// middleware.
+func readBody(req *http.Request) interface{} {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	c := captureBody(req)
+	io.Copy(ioutil.Discard, c)
+	if !c.truncated() {
+		req.Body = ioutil.NopCloser(bytes.NewReader(c.head.Bytes()))
+	}
+	return c.rawData()
+}
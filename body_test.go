@@ -0,0 +1,64 @@
+package crashreport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFromReqBody(t *testing.T) {
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(`{"id":42}`))
+	request := FromReq(req)
+	if body, _ := request.RawData.([]byte); string(body) != `{"id":42}` {
+		t.Errorf("expected the body in RawData, got %v", request.RawData)
+	}
+	if body, _ := ioutil.ReadAll(req.Body); string(body) != `{"id":42}` {
+		t.Errorf("the body should be readable again, got %q", body)
+	}
+
+	if request := FromReq(httptest.NewRequest("GET", "/", nil)); request.RawData != nil {
+		t.Errorf("expected no RawData without body, got %v", request.RawData)
+	}
+}
+
+func TestMiddlewareLargeBody(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	body := bytes.Repeat([]byte("0123456789abcdef"), int(MaxBodySize)/8) // twice MaxBodySize
+	var read int
+	handler := NewReporter("key").Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := ioutil.ReadAll(req.Body)
+		read = len(b)
+		panic("too large")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/upload", bytes.NewReader(body)))
+
+	if read != len(body) {
+		t.Errorf("the handler should read the full body, got %d bytes out of %d", read, len(body))
+	}
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	raw, ok := sent[0].Details.Request.RawData.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a truncated body, got %T", sent[0].Details.Request.RawData)
+	}
+	if head := raw["body"].(string); int64(len(head)) != MaxBodySize || !bytes.HasPrefix(body, []byte(head)) {
+		t.Errorf("expected the first %d bytes of the body, got %d", MaxBodySize, len(head))
+	}
+	if raw["length"] != float64(len(body)) {
+		t.Errorf("expected the length %d, got %v", len(body), raw["length"])
+	}
+	sum := sha256.Sum256(body)
+	if raw["sha256"] != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected hash %v", raw["sha256"])
+	}
+}
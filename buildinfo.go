@@ -0,0 +1,52 @@
+package crashreport
+
+import (
+	"runtime/debug"
+)
+
+// readBuildInfo returns the build info of the binary, a variable for the tests
+var readBuildInfo = debug.ReadBuildInfo
+
+// buildKeys are the build settings attached to the reports, by their name in the custom data
+var buildKeys = map[string]string{
+	"CGO_ENABLED": "cgo",
+	"-race":       "race",
+	"-tags":       "tags",
+	"-compiler":   "compiler",
+	"GOOS":        "goos",
+	"GOARCH":      "goarch",
+}
+
+// buildSettings returns the settings of the build of the binary that help reproducing its crashes, with the version of
+// go, or nil if the binary has no build info
+func buildSettings() map[string]string {
+	info, ok := readBuildInfo()
+	if !ok || info == nil {
+		return nil
+	}
+	settings := map[string]string{}
+	if info.GoVersion != "" {
+		settings["goVersion"] = info.GoVersion
+	}
+	for _, setting := range info.Settings {
+		if key, ok := buildKeys[setting.Key]; ok {
+			settings[key] = setting.Value
+		}
+	}
+	return settings
+}
+
+// applyBuildSettings attaches the build settings to the post under the "build" key of the custom data, and tags it
+// "race" and "cgo" if the binary was built with the race detector or with cgo
+func applyBuildSettings(post *Post, settings map[string]string) {
+	if len(settings) == 0 {
+		return
+	}
+	post.SetCustomData("build", settings)
+	if settings["race"] == "true" {
+		post.Details.Tags = append(post.Details.Tags, "race")
+	}
+	if settings["cgo"] == "1" {
+		post.Details.Tags = append(post.Details.Tags, "cgo")
+	}
+}
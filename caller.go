@@ -0,0 +1,49 @@
+package crashreport
+
+import (
+	"runtime"
+	"strings"
+)
+
+// WithIdentifier sets the identifier of the report's context. By default it's the name of the function that called
+// the reporter, such as "github.com/acme/shop/billing.Charge".
+func WithIdentifier(identifier string) ReportOption {
+	return func(post *Post) {
+		post.Details.Context.Identifier = identifier
+	}
+}
+
+// caller returns the name of the function that called the function calling caller, skip frames higher
+func caller(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip + 2)
+	if !ok {
+		return ""
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
+
+// callerOutside is like caller, but it skips the functions whose name starts with prefix, such as "log." for the
+// methods of log.Logger
+func callerOutside(prefix string) string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, prefix) {
+			return frame.Function
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// identify sets the identifier of the post's context to the function that reported it, unless the template set one
+func identify(post *Post, caller string) {
+	if post.Details.Context.Identifier == "" {
+		post.Details.Context.Identifier = caller
+	}
+}
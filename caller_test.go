@@ -0,0 +1,33 @@
+package crashreport
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestContextIdentifier(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	reporter.Report(errors.New("reported"))
+	reporter.ReportRequest(errors.New("with request"), nil)
+	reporter.CaptureMessage("message")
+	reporter.Report(errors.New("overridden"), WithIdentifier("checkout"))
+	NewStdLogger(reporter, WithStdLoggerOutput(ioutil.Discard)).Printf("logged")
+
+	sent := server.Posts()
+	if len(sent) != 5 {
+		t.Fatalf("expected 5 posts, got %d", len(sent))
+	}
+	for _, i := range []int{0, 1, 2, 4} {
+		if id := sent[i].Details.Context.Identifier; !strings.HasSuffix(id, ".TestContextIdentifier") {
+			t.Errorf("%q should be identified by the test function, got %q", sent[i].Details.Error.Message, id)
+		}
+	}
+	if id := sent[3].Details.Context.Identifier; id != "checkout" {
+		t.Errorf("WithIdentifier should override the caller, got %q", id)
+	}
+}
@@ -0,0 +1,56 @@
+package crashreport
+
+import (
+	"context"
+	"time"
+)
+
+// CancellationOption configures ReportContextCancellation
+type CancellationOption func(*cancellation)
+
+type cancellation struct {
+	plain bool
+	opts  []ReportOption
+}
+
+// WithPlainCancellation also reports the contexts cancelled without a cause, whose cause is context.Canceled. They
+// are skipped by default, as they usually come from a normal shutdown.
+func WithPlainCancellation() CancellationOption {
+	return func(c *cancellation) {
+		c.plain = true
+	}
+}
+
+// WithCancellationOptions applies the report options to the report of the cancellation
+func WithCancellationOptions(opts ...ReportOption) CancellationOption {
+	return func(c *cancellation) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+// ReportContextCancellation reports the cause of the cancellation of the context once it's done, to understand why
+// a long-running operation was aborted. The cause is the error given to the cancel function of
+// context.WithCancelCause, or context.DeadlineExceeded for a deadline, see context.Cause. The report is tagged with
+// "context-cancelled" and has the values stored in the context, like with ReportCtx, but its submission is not bounded
+// by the deadline of the context, which has passed.
+//
+// The report is sent from its own goroutine. The returned function stops watching the context, and returns false if
+// the context was already done, like context.AfterFunc.
+func ReportContextCancellation(ctx context.Context, reporter *Reporter, opts ...CancellationOption) (stop func() bool) {
+	var c cancellation
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return context.AfterFunc(ctx, func() {
+		cause := context.Cause(ctx)
+		if cause == nil || (cause == context.Canceled && !c.plain) {
+			return
+		}
+		post := reporter.newPost()
+		post.Details.Error = FromErr(cause)
+		post.err = cause
+		reporter.applyContext(ctx, &post)
+		post.deadline = time.Time{}
+		reporter.send(post, append([]ReportOption{WithTags("context-cancelled")}, c.opts...)...)
+	})
+}
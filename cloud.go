@@ -0,0 +1,148 @@
+package crashreport
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// cloudMetadataTimeout bounds the queries to the metadata endpoints, which don't answer outside of the clouds
+const cloudMetadataTimeout = time.Second
+
+// the metadata endpoints, variables for the tests
+var (
+	awsMetadataEndpoint = "http://169.254.169.254"
+	gcpMetadataEndpoint = "http://metadata.google.internal"
+)
+
+// cloudMetadata caches the tags of the instance for the process lifetime
+var cloudMetadata = &instanceMetadata{}
+
+// WithCloudMetadata tags the reports with the instance id, region and instance type of the AWS or GCP instance running
+// the process, as "instance:i-0abc", "region:eu-west-1" and "instanceType:t3.micro". The metadata endpoint is
+// queried once per process, in the background and for a second at most, so that it never delays the startup on other
+// hosts. The reports sent before the answer don't have the tags.
+func WithCloudMetadata() Option {
+	return func(r *Reporter) {
+		metadata := cloudMetadata
+		metadata.load()
+		r.enrichers = append(r.enrichers, func(post *Post) {
+			post.Details.Tags = append(post.Details.Tags, metadata.tags()...)
+		})
+	}
+}
+
+// instanceMetadata fetches the tags of the instance once
+type instanceMetadata struct {
+	once   sync.Once
+	done   chan struct{} // closed when the fetch is over
+	result []string
+}
+
+// load starts the fetch, the first time it's called
+func (m *instanceMetadata) load() {
+	m.once.Do(func() {
+		m.done = make(chan struct{})
+		go func() {
+			defer close(m.done)
+			ctx, cancel := context.WithTimeout(context.Background(), cloudMetadataTimeout)
+			defer cancel()
+			// Never go through a proxy: the endpoints are link-local
+			client := &http.Client{Transport: &http.Transport{}}
+			if tags, err := awsTags(ctx, client); err == nil {
+				m.result = tags
+			} else if tags, err := gcpTags(ctx, client); err == nil {
+				m.result = tags
+			}
+		}()
+	})
+}
+
+// tags returns the tags of the instance, or nil if they are not fetched yet
+func (m *instanceMetadata) tags() []string {
+	select {
+	case <-m.done:
+		return m.result
+	default:
+		return nil
+	}
+}
+
+// awsTags queries the instance metadata service of EC2, with an IMDSv2 session token
+func awsTags(ctx context.Context, client *http.Client) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "PUT", awsMetadataEndpoint+"/latest/api/token", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create req")
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, err := metadataValue(client, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "get token")
+	}
+
+	var tags []string
+	for _, field := range []struct{ tag, path string }{
+		{"instance", "instance-id"},
+		{"region", "placement/region"},
+		{"instanceType", "instance-type"},
+	} {
+		req, err := http.NewRequestWithContext(ctx, "GET", awsMetadataEndpoint+"/latest/meta-data/"+field.path, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "create req")
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+		value, err := metadataValue(client, req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get %s", field.path)
+		}
+		tags = append(tags, field.tag+":"+value)
+	}
+	return tags, nil
+}
+
+// gcpTags queries the metadata server of Compute Engine. The zone and the machine type are returned as paths, such
+// as projects/123/zones/europe-west1-b, and the region is the zone without its last part.
+func gcpTags(ctx context.Context, client *http.Client) ([]string, error) {
+	values := map[string]string{}
+	for _, path := range []string{"id", "zone", "machine-type"} {
+		req, err := http.NewRequestWithContext(ctx, "GET", gcpMetadataEndpoint+"/computeMetadata/v1/instance/"+path, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "create req")
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+		value, err := metadataValue(client, req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get %s", path)
+		}
+		values[path] = value[strings.LastIndex(value, "/")+1:]
+	}
+
+	region := values["zone"]
+	if i := strings.LastIndex(region, "-"); i > 0 {
+		region = region[:i]
+	}
+	return []string{"instance:" + values["id"], "region:" + region, "instanceType:" + values["machine-type"]}, nil
+}
+
+// metadataValue returns the body of a metadata query, which must succeed
+func metadataValue(client *http.Client, req *http.Request) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "execute req")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "read body")
+	}
+	return strings.TrimSpace(string(body)), nil
+}
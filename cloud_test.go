@@ -0,0 +1,99 @@
+package crashreport
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// mockMetadata starts a fake metadata server of the given cloud, and points the endpoints to it until closed
+func mockMetadata(t *testing.T, cloud string) *httptest.Server {
+	values := map[string]map[string]string{
+		"aws": {
+			"/latest/meta-data/instance-id":      "i-0abc",
+			"/latest/meta-data/placement/region": "eu-west-1",
+			"/latest/meta-data/instance-type":    "t3.micro",
+		},
+		"gcp": {
+			"/computeMetadata/v1/instance/id":           "4567",
+			"/computeMetadata/v1/instance/zone":         "projects/123/zones/europe-west1-b",
+			"/computeMetadata/v1/instance/machine-type": "projects/123/machineTypes/e2-medium",
+		},
+	}[cloud]
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case cloud == "aws" && req.URL.Path == "/latest/api/token":
+			if req.Method != "PUT" || req.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte("session-token"))
+		case cloud == "aws" && req.Header.Get("X-aws-ec2-metadata-token") != "session-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case cloud == "gcp" && req.Header.Get("Metadata-Flavor") != "Google":
+			w.WriteHeader(http.StatusForbidden)
+		case values[req.URL.Path] != "":
+			w.Write([]byte(values[req.URL.Path]))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	oldAWS, oldGCP, oldCache := awsMetadataEndpoint, gcpMetadataEndpoint, cloudMetadata
+	t.Cleanup(func() {
+		awsMetadataEndpoint, gcpMetadataEndpoint, cloudMetadata = oldAWS, oldGCP, oldCache
+		server.Close()
+	})
+	awsMetadataEndpoint, gcpMetadataEndpoint = server.URL, server.URL
+	cloudMetadata = &instanceMetadata{}
+	return server
+}
+
+func TestWithCloudMetadata(t *testing.T) {
+	tests := map[string][]string{
+		"aws": {"instance:i-0abc", "region:eu-west-1", "instanceType:t3.micro"},
+		"gcp": {"instance:4567", "region:europe-west1", "instanceType:e2-medium"},
+	}
+	for cloud, expected := range tests {
+		t.Run(cloud, func(t *testing.T) {
+			mockMetadata(t, cloud)
+			server := mockRaygun(t)
+			defer server.Close()
+
+			reporter := NewReporter("key", WithCloudMetadata())
+			<-cloudMetadata.done
+			reporter.Report(errors.New("in the cloud"))
+
+			sent := server.Posts()
+			if len(sent) != 1 {
+				t.Fatalf("expected 1 post, got %d", len(sent))
+			}
+			if tags := sent[0].Details.Tags; !reflect.DeepEqual(tags, expected) {
+				t.Errorf("expected the tags %v, got %v", expected, tags)
+			}
+		})
+	}
+}
+
+func TestWithCloudMetadataOutsideOfClouds(t *testing.T) {
+	metadata := mockMetadata(t, "none")
+	metadata.Close()
+	server := mockRaygun(t)
+	defer server.Close()
+
+	start := time.Now()
+	reporter := NewReporter("key", WithCloudMetadata())
+	<-cloudMetadata.done
+	if elapsed := time.Since(start); elapsed > cloudMetadataTimeout+time.Second {
+		t.Errorf("the fetch should be bounded, took %s", elapsed)
+	}
+	reporter.Report(errors.New("on premises"))
+
+	if tags := server.Posts()[0].Details.Tags; len(tags) != 0 {
+		t.Errorf("expected no tags, got %v", tags)
+	}
+}
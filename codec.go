@@ -0,0 +1,47 @@
+package crashreport
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Codec encodes the posts submitted by a reporter, see WithCodec
+type Codec interface {
+	ContentType() string // the Content-Type header of the submissions
+	Encode(post Post) ([]byte, error)
+}
+
+// JSONCodec is the default codec, encoding the posts in json as the api of Raygun expects
+var JSONCodec Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Encode(post Post) ([]byte, error) {
+	return marshalPost(post)
+}
+
+// WithCodec sets the encoding of the submissions, json by default. It's meant for the gateways in front of Raygun
+// accepting a more compact encoding, such as msgpack: the api of Raygun itself only accepts json. The Content-Type
+// header is the one of the codec. The sinks of WithSink do their own encoding, and the disk queue stays in json.
+func WithCodec(codec Codec) Option {
+	return func(r *Reporter) {
+		r.codec = codec
+	}
+}
+
+// submitEncoded posts the post encoded with the codec
+func submitEncoded(ctx context.Context, post Post, codec Codec, reportUrl, key string, client *http.Client) error {
+	data, err := encode(codec, post)
+	if err != nil {
+		return errors.Wrap(err, "encode post")
+	}
+	buf := getBuffer()
+	buf.Write(data)
+	return postBody(ctx, buf, http.Header{"Content-Type": {codec.ContentType()}}, reportUrl, key, client)
+}
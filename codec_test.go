@@ -0,0 +1,45 @@
+package crashreport
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// messageCodec encodes the posts as their message, standing for a binary encoding such as msgpack
+type messageCodec struct{}
+
+func (messageCodec) ContentType() string {
+	return "application/x-message"
+}
+
+func (messageCodec) Encode(post Post) ([]byte, error) {
+	return []byte("message=" + post.Details.Error.Message), nil
+}
+
+func TestWithCodec(t *testing.T) {
+	var contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		contentType, body = r.Header.Get("Content-Type"), string(data)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	reporter := NewReporter("key", WithEndpoint(server.URL), WithCodec(messageCodec{}))
+	if err := reporter.Report(errors.New("encoded")); err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "application/x-message" || body != "message=encoded" {
+		t.Errorf("expected the body and the content type of the codec, got '%s' in %s", body, contentType)
+	}
+
+	if err := NewReporter("key", WithEndpoint(server.URL)).Report(errors.New("default")); err != nil {
+		t.Fatal(err)
+	}
+	if contentType != JSONCodec.ContentType() || body[0] != '{' {
+		t.Errorf("expected json by default, got '%s' in %s", body, contentType)
+	}
+}
@@ -0,0 +1,254 @@
+package crashreport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// contextKey is the type of the keys stored by this package in a context
+type contextKey int
+
+const (
+	requestKey contextKey = iota
+	userKey
+	tagsKey
+	breadcrumbsKey
+	submissionKey
+	sessionKey
+	responseKey
+	traceKey
+)
+
+// traceTag is the prefix of the tag of the trace of a report, see ContextWithTraceID
+const traceTag = "trace:"
+
+// ContextWithRequest returns a context carrying the http request, for ReportFromContext. The middleware stores the
+// request of every handler this way.
+//
+// Go has no goroutine-local storage, so code deep in the stack can only find the request if the context is passed
+// down to it.
+func ContextWithRequest(ctx context.Context, req *http.Request) context.Context {
+	return context.WithValue(ctx, requestKey, req)
+}
+
+// RequestFromContext returns the http request stored by ContextWithRequest, or nil
+func RequestFromContext(ctx context.Context) *http.Request {
+	req, _ := ctx.Value(requestKey).(*http.Request)
+	return req
+}
+
+// ReportFromContext reports the error with the http request stored in the context, if any. See ContextWithRequest.
+// In a handler of the middleware, the report also has the status of the response so far, see Reporter.Middleware.
+func (r *Reporter) ReportFromContext(ctx context.Context, err error, opts ...ReportOption) error {
+	if rec, ok := ctx.Value(responseKey).(*responseRecorder); ok {
+		status := rec.status
+		opts = append([]ReportOption{func(post *Post) { setResponseStatus(post, status) }}, opts...)
+	}
+	return r.reportRequest(err, RequestFromContext(ctx), caller(0), opts)
+}
+
+// ContextWithUser returns a context carrying the user affected by the errors, for ReportCtx
+func ContextWithUser(ctx context.Context, identifier string) context.Context {
+	return context.WithValue(ctx, userKey, identifier)
+}
+
+// ContextWithTags returns a context carrying tags for ReportCtx, in addition to the tags of the parent context
+func ContextWithTags(ctx context.Context, tags ...string) context.Context {
+	parent, _ := ctx.Value(tagsKey).([]string)
+	all := make([]string, 0, len(parent)+len(tags))
+	all = append(append(all, parent...), tags...)
+	return context.WithValue(ctx, tagsKey, all)
+}
+
+// ContextWithTraceID returns a context carrying the id of the distributed trace of the request, for ReportCtx. The
+// reports of the context have a "trace:<id>" tag and the id under the "traceId" key of the custom data, to find the
+// trace of an error. With OpenTelemetry, the id is the one of the span context of the request:
+//
+//	ctx = crashreport.ContextWithTraceID(ctx, trace.SpanContextFromContext(ctx).TraceID().String())
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceKey, traceID)
+}
+
+// TraceIDFromContext returns the trace id stored by ContextWithTraceID, or ""
+func TraceIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(traceKey).(string)
+	return id
+}
+
+// breadcrumbStore collects the breadcrumbs added to a context
+type breadcrumbStore struct {
+	mu          sync.Mutex
+	breadcrumbs []Breadcrumb
+
+	maxCount     int // see WithContextBreadcrumbLimits
+	maxDataBytes int
+}
+
+// BreadcrumbOption configures the breadcrumbs collected by a context, see ContextWithBreadcrumbs
+type BreadcrumbOption func(*breadcrumbStore)
+
+// WithContextBreadcrumbLimits bounds the breadcrumbs collected by a context, for the long requests with many
+// operations: past count breadcrumbs the oldest are dropped, and the custom data of a breadcrumb larger than dataBytes
+// once converted to json is replaced by its size, under the "omittedBytes" key. The limits apply as the breadcrumbs
+// are added, so the memory held by the context is bounded too. Zero means no limit.
+func WithContextBreadcrumbLimits(count, dataBytes int) BreadcrumbOption {
+	return func(s *breadcrumbStore) {
+		s.maxCount = count
+		s.maxDataBytes = dataBytes
+	}
+}
+
+// ContextWithBreadcrumbs returns a context collecting the breadcrumbs added with AddBreadcrumb, for ReportCtx
+func ContextWithBreadcrumbs(ctx context.Context, opts ...BreadcrumbOption) context.Context {
+	store := &breadcrumbStore{}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return context.WithValue(ctx, breadcrumbsKey, store)
+}
+
+// AddBreadcrumb adds a breadcrumb to the context, if it was prepared with ContextWithBreadcrumbs. It returns false
+// otherwise.
+func AddBreadcrumb(ctx context.Context, breadcrumb Breadcrumb) bool {
+	store, ok := ctx.Value(breadcrumbsKey).(*breadcrumbStore)
+	if !ok {
+		return false
+	}
+	if store.maxDataBytes > 0 && breadcrumb.CustomData != nil {
+		if data, err := json.Marshal(breadcrumb.CustomData); err == nil && len(data) > store.maxDataBytes {
+			breadcrumb.CustomData = map[string]interface{}{"omittedBytes": len(data)}
+		}
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.maxCount > 0 && len(store.breadcrumbs) >= store.maxCount {
+		n := copy(store.breadcrumbs, store.breadcrumbs[len(store.breadcrumbs)-store.maxCount+1:])
+		store.breadcrumbs = store.breadcrumbs[:n]
+	}
+	store.breadcrumbs = append(store.breadcrumbs, breadcrumb)
+	return true
+}
+
+// BreadcrumbsFromContext returns the breadcrumbs added to the context
+func BreadcrumbsFromContext(ctx context.Context) []Breadcrumb {
+	store, ok := ctx.Value(breadcrumbsKey).(*breadcrumbStore)
+	if !ok {
+		return nil
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return append([]Breadcrumb(nil), store.breadcrumbs...)
+}
+
+// contextExtractors are the functions registered with RegisterContextExtractor
+var contextExtractors struct {
+	sync.RWMutex
+	list []func(context.Context) map[string]interface{}
+}
+
+// RegisterContextExtractor registers a function adding the values that the application stores in the contexts, under
+// its own keys, to the custom data of the reports of ReportCtx and FromErrContext:
+//
+//	crashreport.RegisterContextExtractor(func(ctx context.Context) map[string]interface{} {
+//		tenant, ok := ctx.Value(tenantKey{}).(string)
+//		if !ok {
+//			return nil
+//		}
+//		return map[string]interface{}{"tenant": tenant, "locale": localeFrom(ctx)}
+//	})
+//
+// The keys of the maps returned by the extractors are set in the custom data, in the order of the registration. The
+// options of a report take precedence. It must be safe for concurrent use.
+func RegisterContextExtractor(extractor func(ctx context.Context) map[string]interface{}) {
+	contextExtractors.Lock()
+	defer contextExtractors.Unlock()
+	contextExtractors.list = append(contextExtractors.list, extractor)
+}
+
+// extractContext sets the values of the registered extractors in the custom data of the post
+func extractContext(ctx context.Context, post *Post) {
+	contextExtractors.RLock()
+	defer contextExtractors.RUnlock()
+	for _, extractor := range contextExtractors.list {
+		var values map[string]interface{}
+		safely("context extractor", func() { values = extractor(ctx) })
+		for k, v := range values {
+			post.SetCustomData(k, v)
+		}
+	}
+}
+
+// applyContext fills the post with the values stored in the context, see FromErrContext
+func (r *Reporter) applyContext(ctx context.Context, post *Post) {
+	applyContext(ctx, post, r.headers)
+}
+
+// applyContext fills the post with the values stored in the context: request, user, tags, session, trace id,
+// breadcrumbs, values of the extractors and deadline. The headers of the request are filtered with headers.
+func applyContext(ctx context.Context, post *Post, headers headerFilter) {
+	if req := RequestFromContext(ctx); req != nil {
+		post.Details.Request = fromReq(req, headers)
+	}
+	if rec, ok := ctx.Value(responseKey).(*responseRecorder); ok {
+		setResponseStatus(post, rec.status)
+	}
+	if user, ok := ctx.Value(userKey).(string); ok {
+		post.Details.User = User{Identifier: user}
+	}
+	if tags, ok := ctx.Value(tagsKey).([]string); ok {
+		post.Details.Tags = append(post.Details.Tags, tags...)
+	}
+	if session := SessionFromContext(ctx); session != "" {
+		setSession(post, session)
+	}
+	if trace := TraceIDFromContext(ctx); trace != "" {
+		post.Details.Tags = append(post.Details.Tags, traceTag+trace)
+		post.SetCustomData("traceId", trace)
+	}
+	post.Details.Breadcrumbs = append(post.Details.Breadcrumbs, BreadcrumbsFromContext(ctx)...)
+	extractContext(ctx, post)
+	if deadline, ok := ctx.Deadline(); ok {
+		post.deadline = deadline
+	}
+}
+
+// ReportCtx reports the error with everything the context knows about it:
+//
+//   - the request, user, tags, session, trace id and breadcrumbs stored in the context are added to the report, and
+//     the values of RegisterContextExtractor
+//   - the deadline of the context bounds the submission, even if it happens later in the background
+//
+// The options are applied after the context values, so they take precedence: WithUser overrides the user of the
+// context, and tags are added to the ones of the context. The reporter options, such as WithMessageTransform or
+// WithBreadcrumbSource, are applied last.
+func (r *Reporter) ReportCtx(ctx context.Context, err error, opts ...ReportOption) error {
+	post := r.newPost()
+	post.Details.Error = FromErr(err)
+	post.err = err
+	r.applyContext(ctx, &post)
+	identify(&post, caller(0))
+	return r.send(post, opts...)
+}
+
+// FromErrContext creates a post for the error, as ReportCtx does, so that it can be inspected or modified before
+// being submitted. The error is converted with FromErr, and the post is filled with the values stored in the context:
+//
+//   - the request of ContextWithRequest, with the default allowlist of headers
+//   - the user of ContextWithUser
+//   - the tags of ContextWithTags
+//   - the session of ContextWithSession
+//   - the trace id of ContextWithTraceID
+//   - the values of the extractors of RegisterContextExtractor
+//   - the breadcrumbs added with AddBreadcrumb to a context of ContextWithBreadcrumbs
+//   - the deadline of the context, which bounds the submission of the post by a reporter
+//
+// Unlike ReportCtx, the template, the options and the enrichers of a reporter aren't applied.
+func FromErrContext(ctx context.Context, err error) Post {
+	post := NewPost()
+	post.Details.Error = FromErr(err)
+	post.err = err
+	applyContext(ctx, &post, headerFilter{})
+	return post
+}
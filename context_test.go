@@ -0,0 +1,249 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportFromContext(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	handler := reporter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		deepInTheStack(req.Context(), reporter)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/42", nil))
+
+	reporter.ReportFromContext(context.Background(), errors.New("no request"))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	if sent[0].Details.Request.URL != "/orders/42" || sent[0].Details.Request.HTTPMethod != "GET" {
+		t.Errorf("the request should be found in the context, got %+v", sent[0].Details.Request)
+	}
+	if sent[1].Details.Request.URL != "" {
+		t.Errorf("a context without request should report without request, got %+v", sent[1].Details.Request)
+	}
+}
+
+func deepInTheStack(ctx context.Context, reporter *Reporter) {
+	reporter.ReportFromContext(ctx, errors.New("order not found"))
+}
+
+func TestReportCtx(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithBreadcrumbSource(func() []Breadcrumb {
+		return []Breadcrumb{{Message: "from the reporter"}}
+	}))
+
+	ctx := ContextWithUser(context.Background(), "context-user")
+	ctx = ContextWithTags(ctx, "api")
+	ctx = ContextWithTags(ctx, "v2")
+	ctx = ContextWithBreadcrumbs(ctx)
+	AddBreadcrumb(ctx, Breadcrumb{Message: "from the context"})
+
+	reporter.ReportCtx(ctx, errors.New("with context"), WithTags("option"))
+	reporter.ReportCtx(ctx, errors.New("overridden"), WithUser("option-user"))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+
+	details := sent[0].Details
+	if details.User.Identifier != "context-user" {
+		t.Errorf("the user should come from the context, got '%s'", details.User.Identifier)
+	}
+	if strings.Join(details.Tags, ",") != "api,v2,option" {
+		t.Errorf("tags should be merged, context first, got %v", details.Tags)
+	}
+	if len(details.Breadcrumbs) != 2 || details.Breadcrumbs[0].Message != "from the context" || details.Breadcrumbs[1].Message != "from the reporter" {
+		t.Errorf("breadcrumbs should come from the context then the reporter, got %+v", details.Breadcrumbs)
+	}
+	if sent[1].Details.User.Identifier != "option-user" {
+		t.Errorf("options should override the context, got '%s'", sent[1].Details.User.Identifier)
+	}
+}
+
+func TestFromErrContext(t *testing.T) {
+	req := httptest.NewRequest("GET", "/orders/7", nil)
+	req.Header.Set("Authorization", "secret")
+	req.Header.Set("User-Agent", "test")
+
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	ctx = ContextWithRequest(ctx, req)
+	ctx = ContextWithUser(ctx, "context-user")
+	ctx = ContextWithTags(ctx, "api", "v2")
+	ctx = ContextWithTraceID(ctx, "4bf92f3577b34da6a3ce929d0e0e4736")
+	ctx = ContextWithBreadcrumbs(ctx)
+	AddBreadcrumb(ctx, Breadcrumb{Message: "from the context"})
+
+	err := errors.New("built from the context")
+	post := FromErrContext(ctx, err)
+
+	details := post.Details
+	if details.Error.Message != "built from the context" || post.err != err {
+		t.Errorf("expected the error, got %+v", details.Error)
+	}
+	if details.Request.URL != "/orders/7" || details.Request.Headers["User-Agent"] != "test" {
+		t.Errorf("expected the request of the context, got %+v", details.Request)
+	}
+	if _, ok := details.Request.Headers["Authorization"]; ok {
+		t.Errorf("expected the default allowlist of headers, got %v", details.Request.Headers)
+	}
+	if details.User.Identifier != "context-user" {
+		t.Errorf("expected the user of the context, got '%s'", details.User.Identifier)
+	}
+	if strings.Join(details.Tags, ",") != "api,v2,trace:4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the tags of the context and of the trace, got %v", details.Tags)
+	}
+	if data, _ := details.UserCustomData.(map[string]interface{}); data["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the trace id of the context, got %v", details.UserCustomData)
+	}
+	if len(details.Breadcrumbs) != 1 || details.Breadcrumbs[0].Message != "from the context" {
+		t.Errorf("expected the breadcrumbs of the context, got %+v", details.Breadcrumbs)
+	}
+	if !post.deadline.Equal(deadline) {
+		t.Errorf("expected the deadline of the context, got %v", post.deadline)
+	}
+	if details.MachineName == "" || post.OccuredOn == "" {
+		t.Errorf("expected a post filled like NewPost, got %+v", post)
+	}
+}
+
+func TestContextBreadcrumbLimits(t *testing.T) {
+	ctx := ContextWithBreadcrumbs(context.Background(), WithContextBreadcrumbLimits(3, 64))
+	for i := 0; i < 5; i++ {
+		AddBreadcrumb(ctx, Breadcrumb{Message: fmt.Sprintf("query %d", i), CustomData: map[string]interface{}{"rows": i}})
+	}
+	AddBreadcrumb(ctx, Breadcrumb{Message: "large", CustomData: map[string]interface{}{"sql": strings.Repeat("x", 100)}})
+
+	breadcrumbs := BreadcrumbsFromContext(ctx)
+	if len(breadcrumbs) != 3 || breadcrumbs[0].Message != "query 3" || breadcrumbs[1].Message != "query 4" {
+		t.Fatalf("expected the newest 3 breadcrumbs, got %+v", breadcrumbs)
+	}
+	if data := breadcrumbs[1].CustomData.(map[string]interface{}); data["rows"] != 4 {
+		t.Errorf("expected the small custom data to be kept, got %v", data)
+	}
+	if data := breadcrumbs[2].CustomData.(map[string]interface{}); data["omittedBytes"] != 110 || data["sql"] != nil {
+		t.Errorf("expected the large custom data to be replaced by its size, got %v", data)
+	}
+}
+
+func TestReportCtxDeadline(t *testing.T) {
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})}
+	reporter := NewReporter("key", WithClient(client))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	begin := time.Now()
+	err := reporter.ReportCtx(ctx, errors.New("slow"))
+	if err == nil || time.Since(begin) > time.Second {
+		t.Errorf("the submission should stop at the deadline of the context, got %v after %s", err, time.Since(begin))
+	}
+}
+
+func TestReportContextCancellation(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	reporter := NewReporter("key")
+
+	plain, cancelPlain := context.WithCancelCause(context.Background())
+	ReportContextCancellation(plain, reporter)
+	cancelPlain(nil)
+
+	ctx, cancel := context.WithCancelCause(ContextWithUser(context.Background(), "alice"))
+	ctx, cancelTimeout := context.WithTimeout(ctx, time.Hour)
+	defer cancelTimeout()
+	ReportContextCancellation(ctx, reporter, WithCancellationOptions(WithTags("worker")))
+	cancel(errors.New("upstream closed"))
+
+	stopped, cancelStopped := context.WithCancelCause(context.Background())
+	if !ReportContextCancellation(stopped, reporter)() {
+		t.Error("expected to stop watching the context")
+	}
+	cancelStopped(errors.New("stopped"))
+
+	deadline := time.Now().Add(time.Second)
+	for len(server.Posts()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected only the cancellation with a cause to be reported, got %d posts", len(sent))
+	}
+	if sent[0].Details.Error.Message != "upstream closed" || sent[0].Details.User.Identifier != "alice" {
+		t.Errorf("expected the cause with the user of the context, got %q for %q",
+			sent[0].Details.Error.Message, sent[0].Details.User.Identifier)
+	}
+	if !hasTag(sent[0].Details.Tags, "context-cancelled") || !hasTag(sent[0].Details.Tags, "worker") {
+		t.Errorf("expected the context-cancelled tag and the options, got %v", sent[0].Details.Tags)
+	}
+
+	canceled, cancelCanceled := context.WithCancel(context.Background())
+	ReportContextCancellation(canceled, reporter, WithPlainCancellation())
+	cancelCanceled()
+	deadline = time.Now().Add(time.Second)
+	for len(server.Posts()) == 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sent := server.Posts(); len(sent) != 2 || sent[1].Details.Error.Message != "context canceled" {
+		t.Errorf("expected the plain cancellation to be reported with WithPlainCancellation, got %d posts", len(sent))
+	}
+}
+
+func TestRegisterContextExtractor(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func(list []func(context.Context) map[string]interface{}) { contextExtractors.list = list }(
+		contextExtractors.list)
+
+	type tenantKey struct{}
+	RegisterContextExtractor(func(ctx context.Context) map[string]interface{} {
+		tenant, ok := ctx.Value(tenantKey{}).(string)
+		if !ok {
+			return nil
+		}
+		return map[string]interface{}{"tenant": tenant, "locale": "fr"}
+	})
+	RegisterContextExtractor(func(ctx context.Context) map[string]interface{} {
+		return map[string]interface{}{"locale": "fr-CA", "scope": "admin"}
+	})
+	RegisterContextExtractor(func(ctx context.Context) map[string]interface{} { panic("boom") })
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	NewReporter("key").ReportCtx(ctx, errors.New("failure"), WithCustomData("scope", "read"))
+	post := FromErrContext(ctx, errors.New("failure"))
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	expected := map[string]interface{}{"tenant": "acme", "locale": "fr-CA", "scope": "read"}
+	if data := sent[0].Details.UserCustomData; !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected the merged values %v, got %v", expected, data)
+	}
+	if data := post.Details.UserCustomData.(map[string]interface{}); data["tenant"] != "acme" || data["scope"] != "admin" {
+		t.Errorf("expected the values in the post of FromErrContext, got %v", data)
+	}
+}
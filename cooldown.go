@@ -0,0 +1,98 @@
+package crashreport
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// defaultCooldown is how long the reporter stops submitting after a 429 without a Retry-After header
+const defaultCooldown = time.Minute
+
+// quotaCooldown is how long the reporter stops submitting when the quota is exceeded, before trying again
+const quotaCooldown = time.Hour
+
+// maxPending is the maximum number of reports kept during a cool-down. Further reports are dropped.
+const maxPending = 100
+
+// cooldown keeps the reports received while Raygun is rate limiting us
+type cooldown struct {
+	mu      sync.Mutex
+	until   time.Time
+	timer   *time.Timer
+	pending []pendingPost
+
+	quotaLogged sync.Once
+}
+
+type pendingPost struct {
+	post Post
+	key  string
+}
+
+// hold keeps the post for later if the reporter is cooling down
+func (r *Reporter) hold(post Post, key string) bool {
+	r.cooldown.mu.Lock()
+	if !time.Now().Before(r.cooldown.until) {
+		r.cooldown.mu.Unlock()
+		return false
+	}
+	kept := len(r.cooldown.pending) < maxPending
+	if kept {
+		r.cooldown.pending = append(r.cooldown.pending, pendingPost{post, key})
+	}
+	r.cooldown.mu.Unlock()
+
+	if kept {
+		post.outcome.record(OutcomeQueued, nil)
+	} else {
+		r.dropped(post, DropRateLimited)
+	}
+	return true
+}
+
+// startCooldown stops the submissions for d
+func (r *Reporter) startCooldown(d time.Duration) {
+	if d <= 0 {
+		d = defaultCooldown
+	}
+
+	r.cooldown.mu.Lock()
+	defer r.cooldown.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(r.cooldown.until) {
+		r.cooldown.until = until
+	}
+	if r.cooldown.timer == nil {
+		r.cooldown.timer = time.AfterFunc(d, r.resume)
+	}
+}
+
+// stopOnQuota stops the submissions for d, or an hour, because the quota is exceeded. It's logged once per reporter.
+func (r *Reporter) stopOnQuota(d time.Duration) {
+	if d <= 0 {
+		d = quotaCooldown
+	}
+	r.cooldown.quotaLogged.Do(func() {
+		log.Printf("crashreport: Raygun quota exceeded, reports are held for %s", d)
+	})
+	r.startCooldown(d)
+}
+
+// resume submits the reports kept during the cool-down, once it's over
+func (r *Reporter) resume() {
+	r.cooldown.mu.Lock()
+	if wait := time.Until(r.cooldown.until); wait > 0 {
+		r.cooldown.timer = time.AfterFunc(wait, r.resume)
+		r.cooldown.mu.Unlock()
+		return
+	}
+	pending := r.cooldown.pending
+	r.cooldown.pending = nil
+	r.cooldown.timer = nil
+	r.cooldown.mu.Unlock()
+
+	for _, p := range pending {
+		r.submit(p.post, p.key)
+	}
+}
@@ -0,0 +1,102 @@
+package crashreport
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCooldownOn429(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	rejected := false
+	server.Reject(func(w http.ResponseWriter) bool {
+		if rejected {
+			return false
+		}
+		rejected = true
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return true
+	})
+
+	reporter := NewReporter("key")
+
+	err := reporter.Report(errors.New("first"))
+	if e, ok := err.(*ResponseError); !ok || e.StatusCode != http.StatusTooManyRequests || e.RetryAfter != 2*time.Second {
+		t.Fatalf("expected a 429 with a 2s Retry-After, got %v", err)
+	}
+
+	for _, msg := range []string{"second", "third"} {
+		if err := reporter.Report(errors.New(msg)); err != nil {
+			t.Errorf("reports during the cool-down should be queued, got %v", err)
+		}
+	}
+
+	time.Sleep(time.Second)
+	if attempts := server.Attempts(); attempts != 1 {
+		t.Errorf("no submissions should occur during the cool-down, got %d", attempts)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(server.Posts()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	sent := server.Posts()
+	if len(sent) != 2 || sent[0].Details.Error.Message != "second" || sent[1].Details.Error.Message != "third" {
+		t.Errorf("queued reports should be submitted after the cool-down, got %d posts", len(sent))
+	}
+}
+
+func TestForbidden(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	var logs bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&logs)
+
+	body := "Invalid API Key"
+	server.Reject(func(w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(body))
+		return true
+	})
+
+	reporter := NewReporter("key")
+	for i := 0; i < 2; i++ {
+		err := reporter.Report(errors.New("unauthorized"))
+		if !errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("expected ErrUnauthorized, got %v", err)
+		}
+	}
+	if attempts := server.Attempts(); attempts != 2 {
+		t.Errorf("an invalid key should not stop the submissions, got %d attempts", attempts)
+	}
+
+	body = "Monthly quota exceeded for this application"
+	reporter = NewReporter("key")
+	err := reporter.Report(errors.New("over quota"))
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if e, ok := err.(*ResponseError); !ok || e.StatusCode != http.StatusForbidden {
+		t.Errorf("the response error should be kept, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := reporter.Report(errors.New("held")); err != nil {
+			t.Errorf("reports should be held while the quota is exceeded, got %v", err)
+		}
+	}
+	if attempts := server.Attempts(); attempts != 3 {
+		t.Errorf("no submissions should occur once the quota is exceeded, got %d attempts", attempts-2)
+	}
+	if n := strings.Count(logs.String(), "quota exceeded"); n != 1 {
+		t.Errorf("the quota should be logged once, got %q", logs.String())
+	}
+}
@@ -0,0 +1,37 @@
+package crashreport
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// maxCrashLog is the size of the content read by ReportReader, the rest is ignored
+const maxCrashLog = 1 << 20
+
+// ReportReader reports a crash written by another component, such as the stderr of a child process that panicked,
+// with the given message. The content is read up to 1MB and parsed as a stack dump with ParseStack, the stacktrace
+// starting at the function that panicked:
+//
+//	var stderr bytes.Buffer
+//	cmd.Stderr = &stderr
+//	if err := cmd.Run(); err != nil {
+//		reporter.ReportReader(&stderr, "worker crashed: "+err.Error())
+//	}
+//
+// Content that isn't a go stack dump, such as the crash log of a program in another language, is sent as it is under
+// the "crashLog" key of the custom data, without a stacktrace.
+func (r *Reporter) ReportReader(rd io.Reader, message string, opts ...ReportOption) error {
+	raw, err := ioutil.ReadAll(io.LimitReader(rd, maxCrashLog))
+	if err != nil {
+		return errors.Wrap(err, "read crash log")
+	}
+
+	post := r.newPost()
+	post.Details.Error = Error{Message: message, StackTrace: panicStack(ParseStack(raw))}
+	if len(post.Details.Error.StackTrace) == 0 {
+		post.SetCustomData("crashLog", string(raw))
+	}
+	return r.send(post, opts...)
+}
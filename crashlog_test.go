@@ -0,0 +1,55 @@
+package crashreport
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReportReader(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	dump := `panic: runtime error: invalid memory address or nil pointer dereference
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x47f0a6]
+
+goroutine 1 [running]:
+panic({0x4a2b60?, 0x5731d0?})
+	/usr/local/go/src/runtime/panic.go:770 +0x132
+example.com/worker/job.(*Runner).Run(0x0)
+	/src/job/runner.go:27 +0x26
+main.main()
+	/src/main.go:14 +0x3b
+exit status 2
+`
+	reporter := NewReporter("key")
+	if err := reporter.ReportReader(strings.NewReader(dump), "worker crashed"); err != nil {
+		t.Fatal(err)
+	}
+	segfault := "Segmentation fault (core dumped)\n"
+	if err := reporter.ReportReader(strings.NewReader(segfault), "converter crashed"); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	if sent[0].Details.Error.Message != "worker crashed" {
+		t.Errorf("expected the message of the report, got %q", sent[0].Details.Error.Message)
+	}
+	assertFrames(t, "go dump", sent[0].Details.Error.StackTrace, []frame{
+		{"example.com/worker/job", "(*Runner).Run", "/src/job/runner.go", 27},
+		{"main", "main", "/src/main.go", 14},
+	})
+	if data, _ := sent[0].Details.UserCustomData.(map[string]interface{}); data["crashLog"] != nil {
+		t.Errorf("expected the parsed dump not to be attached, got %v", data["crashLog"])
+	}
+
+	if len(sent[1].Details.Error.StackTrace) != 0 {
+		t.Errorf("expected no stacktrace for the unparseable content, got %v", sent[1].Details.Error.StackTrace)
+	}
+	data, _ := sent[1].Details.UserCustomData.(map[string]interface{})
+	if data["crashLog"] != segfault {
+		t.Errorf("expected the raw content in the custom data, got %v", data["crashLog"])
+	}
+}
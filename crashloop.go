@@ -0,0 +1,117 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// crashLoopFile is the file of the disk queue directory holding the recent panics, see WithCrashLoopThrottle. Its
+// extension isn't one of the queue, so that DrainQueue leaves it alone.
+const crashLoopFile = "recent-panics.state"
+
+// WithCrashLoopThrottle reports an identical panic at most once per window, even across restarts, for the crash loops
+// where the same panic fires at every start. The fingerprints of the reported panics and their time are stored in the
+// directory of WithDiskQueue, which is required: without it the panics aren't throttled. A panic is recorded once its
+// report is submitted successfully, so that a panic sampled out or failing to submit is reported again. The throttled
+// panics are dropped as DropSuppressed and counted in Stats.Suppressed. Panics are identified as with
+// WithReportOncePerFingerprint.
+func WithCrashLoopThrottle(window time.Duration) Option {
+	return func(r *Reporter) {
+		r.crashLoop.window = window
+	}
+}
+
+// crashLoop throttles the panics, see WithCrashLoopThrottle
+type crashLoop struct {
+	mu     sync.Mutex // serializes the reads and writes of the file
+	window time.Duration
+}
+
+// crashLooping tells if the panic of the post was reported less than the window ago, by this process or a previous
+// one
+func (r *Reporter) crashLooping(post Post) bool {
+	if r.diskQueue.dir == "" {
+		return false
+	}
+	fingerprint := r.fingerprint(post)
+	r.crashLoop.mu.Lock()
+	last, ok := r.recentPanics()[fingerprint]
+	r.crashLoop.mu.Unlock()
+
+	if ok && timeNow().Sub(last) < r.crashLoop.window {
+		atomic.AddInt64(&r.stats.suppressed, 1)
+		return true
+	}
+	return false
+}
+
+// reportedPanic records that the panic of the post was submitted now, forgetting the panics out of the window
+func (r *Reporter) reportedPanic(post Post) {
+	if r.diskQueue.dir == "" {
+		return
+	}
+	fingerprint := r.fingerprint(post)
+	now := timeNow()
+
+	r.crashLoop.mu.Lock()
+	defer r.crashLoop.mu.Unlock()
+	recent := r.recentPanics()
+	for fp, last := range recent {
+		if now.Sub(last) >= r.crashLoop.window {
+			delete(recent, fp)
+		}
+	}
+	recent[fingerprint] = now
+	path := filepath.Join(r.diskQueue.dir, crashLoopFile)
+	if err := writeRecentPanics(path, recent); err != nil {
+		log.Printf("crashreport: can't record the panic in %s: %v", path, err)
+	}
+}
+
+// recentPanics reads the times of the recent panics by fingerprint. The lock of crashLoop must be held.
+func (r *Reporter) recentPanics() map[string]time.Time {
+	path := filepath.Join(r.diskQueue.dir, crashLoopFile)
+	recent := map[string]time.Time{}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &recent); err != nil {
+			log.Printf("crashreport: ignoring the corrupted recent panics %s: %v", path, err)
+			return map[string]time.Time{}
+		}
+	}
+	return recent
+}
+
+// writeRecentPanics replaces the file of the recent panics, through a temporary file so that a crash while writing
+// leaves the previous one
+func writeRecentPanics(path string, recent map[string]time.Time) error {
+	data, err := json.Marshal(recent)
+	if err != nil {
+		return errors.Wrap(err, "marshal recent panics")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "create dir")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return errors.Wrap(err, "create recent panics file")
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "write recent panics file")
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,64 @@
+package crashreport
+
+import (
+	"testing"
+	"time"
+)
+
+// startup panics as a program failing at every start would
+func startup(reporter *Reporter, v interface{}) {
+	defer func() {
+		reporter.CapturePanic(recover())
+	}()
+	panic(v)
+}
+
+func TestWithCrashLoopThrottle(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func() { timeNow = time.Now }()
+	clock := time.Now()
+	timeNow = func() time.Time { return clock }
+
+	dir := t.TempDir()
+	restart := func() *Reporter {
+		return NewReporter("key", WithDiskQueue(dir), WithCrashLoopThrottle(time.Hour))
+	}
+
+	startup(restart(), "missing configuration")
+	restarted := restart()
+	startup(restarted, "missing configuration")
+	if sent := server.Posts(); len(sent) != 1 {
+		t.Fatalf("expected the panic to be submitted once across the restarts, got %d posts", len(sent))
+	}
+	if suppressed := restarted.Stats().Suppressed; suppressed != 1 {
+		t.Errorf("expected 1 suppressed panic, got %d", suppressed)
+	}
+	if files, err := restarted.queuedFiles(); err != nil || len(files) != 0 {
+		t.Errorf("expected the recent panics not to be queued, got %v, %v", files, err)
+	}
+
+	startup(restart(), "another panic")
+	clock = clock.Add(2 * time.Hour)
+	startup(restart(), "missing configuration")
+	if sent := server.Posts(); len(sent) != 3 {
+		t.Errorf("expected another panic and the one after the window to be submitted, got %d posts", len(sent))
+	}
+}
+
+func TestWithCrashLoopThrottleUnsubmitted(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	dir := t.TempDir()
+	startup(NewReporter("key", WithDiskQueue(dir), WithCrashLoopThrottle(time.Hour), WithSampleRate(0)), "sampled")
+	startup(NewReporter("key", WithDiskQueue(dir), WithCrashLoopThrottle(time.Hour),
+		WithEndpoint("http://127.0.0.1:1")), "unreachable")
+	restarted := NewReporter("key", WithDiskQueue(dir), WithCrashLoopThrottle(time.Hour))
+	startup(restarted, "sampled")
+	startup(restarted, "unreachable")
+
+	if sent := server.Posts(); len(sent) != 2 {
+		t.Errorf("expected the panics that weren't submitted to be reported after the restart, got %d posts", len(sent))
+	}
+}
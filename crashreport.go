@@ -7,24 +7,44 @@ package crashreport
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
 // Endpoint contains the endpoint of the raygun api. You can change it for testing purposes.
+// Reporters read it once, when they are created: use WithEndpoint to configure a reporter instead.
 var Endpoint = "https://api.raygun.io"
 
+// TimeFormat is the format of Post.OccuredOn: RFC3339 in UTC, with milliseconds to keep close errors ordered
+const TimeFormat = "2006-01-02T15:04:05.000Z"
+
 // Post is the full body of a raygun message. See https://raygun.com/raygun-providers/rest-json-api
 type Post struct {
-	OccuredOn string  `json:"occurredOn,omitempty"` // the time the error occured on, format 2006-01-02T15:04:05Z
+	OccuredOn string  `json:"occurredOn,omitempty"` // the time the error occured on, in UTC, format 2006-01-02T15:04:05.000Z
 	Details   Details `json:"details,omitempty"`    // all the details needed by the API
+
+	level    Level     // the severity, see Level()
+	deadline time.Time // the deadline of the submission, if any
+	err      error     // the reported error, if any
+
+	outcome *outcomeRecorder // see ReportResult and WithDeliveryCallback, nil if not asked
+
+	client       *http.Client // of the submission, see WithHTTPClient
+	endpoint     string       // of the submission, see WithEndpointResolver
+	endpointHost string
+	panicked     bool // recovered from a panic, see WithCrashLoopThrottle
+	synchronous  bool // submitted without being held, rate limited or queued, see Fatal and ReportShutdown
 }
 
 // Details contains the info about the circumstances of the error
@@ -41,18 +61,19 @@ type Details struct {
 	Response       Response     `json:"response,omitempty"`       // the response from the context
 	User           User         `json:"user,omitempty"`           // the user from the context
 	Context        Context      `json:"context,omitempty"`        // the identifier from the context
+	GroupingKey    string       `json:"groupingKey,omitempty"`    // groups the errors in Raygun instead of its own grouping
 }
 
 // Client contains the info about the app generating the error
 type Client struct {
-	Name      string `json:"identifier,omitempty"`
+	Name      string `json:"name,omitempty"`
 	Version   string `json:"version,omitempty"`
 	ClientURL string `json:"clientUrl,omitempty"`
 }
 
 // Error contains the info about the actual error
 type Error struct {
-	InnerError string      `json:"innerError,omitempty"` // not really useful in go, but whatever
+	InnerError string      `json:"innerError,omitempty"` // the message of the wrapped error, see WithInnerErrorMode
 	Data       interface{} `json:"data,omitempty"`       // could be anything
 	ClassName  string      `json:"className,omitempty"`  // not really useful in go, but whatever
 	Message    string      `json:"message,omitempty"`    // This is basically err.Error()
@@ -63,28 +84,124 @@ func (e Error) Error() string {
 	return e.Message
 }
 
+// AsError returns a go error carrying the error, for the tools replaying reports. Its Error method returns the message,
+// its Class method the class, and its StackTrace method the frames as "file:line" lines, like the errors of
+// juju/errors: FromErr gives back the message, the class, the data and the files and lines of the stacktrace. The
+// packages and the methods of the frames are lost.
+func (e Error) AsError() error {
+	return replayedError{e}
+}
+
+// replayedError is the go error of an Error, see AsError. It's not an Error, which FromErr would return as it is.
+type replayedError struct {
+	e Error
+}
+
+func (r replayedError) Error() string     { return r.e.Message }
+func (r replayedError) Class() string     { return r.e.ClassName }
+func (r replayedError) data() interface{} { return r.e.Data }
+
+// StackTrace returns the frames in the format of juju/errors
+func (r replayedError) StackTrace() []string {
+	lines := make([]string, len(r.e.StackTrace))
+	for i, frame := range r.e.StackTrace {
+		lines[i] = frame.FileName + ":" + strconv.Itoa(frame.LineNumber)
+	}
+	return lines
+}
+
+// ParseError decodes an error marshaled to json, for example embedded in another payload. Marshaling an Error gives
+// the same json as in the posts sent to Raygun.
+func ParseError(data []byte) (Error, error) {
+	var e Error
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Error{}, errors.Wrap(err, "parse error")
+	}
+	return e, nil
+}
+
+// SetData sets a key of Data. If Data already holds something other than a map, it's moved under the "data" key.
+func (e *Error) SetData(key string, value interface{}) {
+	data, ok := e.Data.(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{}
+		if e.Data != nil {
+			data["data"] = e.Data
+		}
+		e.Data = data
+	}
+	data[key] = value
+}
+
 // StackTrace implements the interface to add a new stack element
 type StackTrace []StackTraceElement
 
 // AddEntry adds a new line to the stacktrace
 func (s *StackTrace) AddEntry(lineNumber int, packageName, fileName, methodName string) {
-	*s = append(*s, StackTraceElement{lineNumber, packageName, fileName, methodName})
+	*s = append(*s, StackTraceElement{LineNumber: lineNumber, PackageName: packageName, FileName: fileName, MethodName: methodName})
 }
 
+// Prepend adds a frame at the top of the stacktrace, as the innermost call
+func (s *StackTrace) Prepend(frame StackTraceElement) {
+	*s = append(StackTrace{frame}, *s...)
+}
+
+// String returns the stacktrace in the format of a go panic, see MarshalText
 func (s *StackTrace) String() string {
-	out := ""
-	for _, line := range *s {
-		out = out + fmt.Sprintf("%s/%s:%d\n", line.PackageName, line.FileName, line.LineNumber)
+	text, _ := s.MarshalText()
+	return string(text)
+}
+
+// MarshalText writes the stacktrace in the format of a go panic, two lines per frame:
+//
+//	github.com/acme/shop/billing.Charge
+//		/src/billing/charge.go:42
+func (s StackTrace) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+	for _, frame := range s {
+		switch {
+		case frame.PackageName == "" && frame.MethodName == "":
+			buf.WriteString("???") // as in go panics, juju/errors stacktraces only have the location
+		case frame.PackageName == "":
+			buf.WriteString(frame.MethodName)
+		case frame.MethodName == "":
+			buf.WriteString(frame.PackageName)
+		default:
+			buf.WriteString(frame.PackageName + "." + frame.MethodName)
+		}
+		fmt.Fprintf(&buf, "\n\t%s:%d\n", frame.FileName, frame.LineNumber)
 	}
-	return out
+	return buf.Bytes(), nil
 }
 
-// StackTraceElement is one element of the error's stack trace.
+// MarshalJSON writes the stacktrace as an array of frames. It's needed because MarshalText would be used otherwise.
+func (s StackTrace) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]StackTraceElement(s))
+}
+
+// StackTraceElement is one element of the error's stack trace. The fields are the ones of a Raygun frame: go has no
+// classes, so the package is sent as the className, which Raygun displays before the methodName.
 type StackTraceElement struct {
 	LineNumber  int    `json:"lineNumber,omitempty"`
-	PackageName string `json:"className,omitempty"`
+	PackageName string `json:"className,omitempty"` // the import path of the package, such as net/http
 	FileName    string `json:"fileName,omitempty"`
 	MethodName  string `json:"methodName,omitempty"`
+	InApp       bool   `json:"inApp,omitempty"`     // the frame belongs to the application, see WithAppModule
+	SourceURL   string `json:"sourceUrl,omitempty"` // the line in the repository, see WithSourceURL
+}
+
+// NewStackTraceElement creates a frame of a stacktrace, for the stacks built from other sources than the runtime, such
+// as logs. The names are trimmed of spaces, and a negative line number, for an unknown line, is replaced by 0.
+func NewStackTraceElement(pkg, file, method string, line int) StackTraceElement {
+	if line < 0 {
+		line = 0
+	}
+	return StackTraceElement{
+		LineNumber:  line,
+		PackageName: strings.TrimSpace(pkg),
+		FileName:    strings.TrimSpace(file),
+		MethodName:  strings.TrimSpace(method),
+	}
 }
 
 // Breadcrumb is a step that the user did in the application. See https://raygun.com/thinktank/suggestion/4228
@@ -92,29 +209,29 @@ type Breadcrumb struct {
 	Message    string      `json:"message,omitempty"`
 	Category   string      `json:"category,omitempty"`
 	CustomData interface{} `json:"customData,omitempty"`
-	Timestamp  int         `json:"timestamp,omitempty"`
+	Timestamp  int64       `json:"timestamp,omitempty"`
 	Level      int         `json:"level,omitempty"`
 	Type       string      `json:"type,omitempty"`
 }
 
 // Environment contains the info about the machine
 type Environment struct {
-	ProcessorCount          int    `json:"processorCount,omitempty"`
-	OsVersion               string `json:"osVersion,omitempty"`
-	WindowBoundsWidth       int    `json:"windowBoundsWidth,omitempty"`
-	WindowBoundsHeight      int    `json:"windowBoundsHeight,omitempty"`
-	ResolutionScale         string `json:"resolutionScale,omitempty"`
-	CurrentOrientation      string `json:"currentOrientation,omitempty"`
-	CPU                     string `json:"cpu,omitempty"`
-	PackageVersion          string `json:"packageVersion,omitempty"`
-	Architecture            string `json:"architecture,omitempty"`
-	TotalPhysicalMemory     int    `json:"totalPhysicalMemory,omitempty"`
-	AvailablePhysicalMemory int    `json:"availablePhysicalMemory,omitempty"`
-	TotalVirtualMemory      int    `json:"totalVirtualMemory,omitempty"`
-	AvailableVirtualMemory  int    `json:"availableVirtualMemory,omitempty"`
-	DiskSpaceFree           []int  `json:"diskSpaceFree,omitempty"`
-	DeviceName              string `json:"deviceName,omitempty"`
-	Locale                  string `json:"locale,omitempty"`
+	ProcessorCount          int     `json:"processorCount,omitempty"`
+	OsVersion               string  `json:"osVersion,omitempty"`
+	WindowBoundsWidth       int     `json:"windowBoundsWidth,omitempty"`
+	WindowBoundsHeight      int     `json:"windowBoundsHeight,omitempty"`
+	ResolutionScale         string  `json:"resolutionScale,omitempty"`
+	CurrentOrientation      string  `json:"currentOrientation,omitempty"`
+	CPU                     string  `json:"cpu,omitempty"`
+	PackageVersion          string  `json:"packageVersion,omitempty"`
+	Architecture            string  `json:"architecture,omitempty"`
+	TotalPhysicalMemory     int64   `json:"totalPhysicalMemory,omitempty"`
+	AvailablePhysicalMemory int64   `json:"availablePhysicalMemory,omitempty"`
+	TotalVirtualMemory      int64   `json:"totalVirtualMemory,omitempty"`
+	AvailableVirtualMemory  int64   `json:"availableVirtualMemory,omitempty"`
+	DiskSpaceFree           []int64 `json:"diskSpaceFree,omitempty"` // one entry per disk
+	DeviceName              string  `json:"deviceName,omitempty"`
+	Locale                  string  `json:"locale,omitempty"`
 }
 
 // Request holds all information on the request from the context
@@ -127,6 +244,9 @@ type Request struct {
 	Form        map[string]string `json:"form,omitempty"`        // key-value-pairs from a given form (POST)
 	Headers     map[string]string `json:"headers,omitempty"`     // key-value-pairs from the header
 	RawData     interface{}       `json:"rawData,omitempty"`
+
+	rawPath     string // the path of the request when URL is a route template, see SetRoute
+	contentType string // of the body, see WithJSONRequestBody
 }
 
 // Response contains the status code
@@ -144,6 +264,26 @@ type Context struct {
 	Identifier string `json:"identifier,omitempty"`
 }
 
+// OccurredTime parses OccuredOn. The time is set once by NewPost, so it's the instant of the error even if the post
+// is submitted later.
+func (p Post) OccurredTime() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, p.OccuredOn)
+}
+
+// SetCustomData sets a key of UserCustomData. If UserCustomData already holds something other than a map, it's moved
+// under the "data" key.
+func (p *Post) SetCustomData(key string, value interface{}) {
+	data, ok := p.Details.UserCustomData.(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{}
+		if p.Details.UserCustomData != nil {
+			data["data"] = p.Details.UserCustomData
+		}
+		p.Details.UserCustomData = data
+	}
+	data[key] = value
+}
+
 // NewPost creates a new post by collecting data about the system, such as the current timestamp, os version and architecture, and number of cpus
 func NewPost() Post {
 	hostname, err := os.Hostname()
@@ -152,7 +292,7 @@ func NewPost() Post {
 	}
 
 	post := Post{
-		OccuredOn: time.Now().Format("2006-01-02T15:04:05Z"),
+		OccuredOn: time.Now().UTC().Format(TimeFormat),
 		Details: Details{
 			MachineName: hostname,
 			Environment: Environment{
@@ -167,8 +307,9 @@ func NewPost() Post {
 }
 
 // FromErr creates an error struct from an error
-// If the error satisfies the interfaces `Class() string` and/or `Data() interface{}` it will use them to construct the
-// Error struct.
+// If the error satisfies the interfaces `Class() string`, `Data() interface{}` and/or
+// `FieldErrors() map[string]string` it will use them to construct the Error struct.
+// The details of the functions registered with RegisterErrorDetailer are added to Data.
 // FromErr also constructs a stacktrace. It the error satisfies the interface `Stacktrace() []string` it will use that.
 // Otherwise it will use the runtime package to retrieve the goroutine stacktrace
 func FromErr(err error) Error {
@@ -176,21 +317,36 @@ func FromErr(err error) Error {
 	if e, ok := err.(Error); ok {
 		return e
 	}
+	if e, ok := err.(*Error); ok && e != nil {
+		return *e
+	}
 
 	rayerr := Error{
-		// InnerError: cause(err).Error(),  // Disabled because it causes strange things
+		InnerError: innerError(err, InnerErrorImmediate),
 		Message:    err.Error(),
 		ClassName:  class(err),
 		Data:       data(err),
 		StackTrace: stacktrace(err),
 	}
+	if fields := fieldErrors(err); fields != nil {
+		rayerr.SetData("fields", fields)
+	}
+	detail(&rayerr, err)
 
 	return rayerr
 }
 
-// FromReq returns a Request struct from a http request. Rawdata is set to the content of Body
+// FromReq returns a Request struct from a http request. Rawdata is set to the content of Body, which can be read
+// again afterwards. Bodies longer than MaxBodySize are not kept in memory: Rawdata only has their beginning, their
+// length and their SHA-256, and they can't be read again unless the middleware captured them.
+// Only the Content-Type, Accept, User-Agent and Referer headers are kept, see WithHeaderAllowlist to report others.
 func FromReq(req *http.Request) Request {
-	body, _ := ioutil.ReadAll(req.Body)
+	return fromReq(req, headerFilter{})
+}
+
+// fromReq is FromReq, keeping the headers chosen by the filter
+func fromReq(req *http.Request, headers headerFilter) Request {
+	body := readBody(req)
 
 	request := Request{
 		HostName:    req.Host,
@@ -199,65 +355,85 @@ func FromReq(req *http.Request) Request {
 		IPAddress:   req.RemoteAddr,
 		QueryString: arrayMapToStringMap(req.URL.Query()),
 		Form:        arrayMapToStringMap(req.PostForm),
-		Headers:     arrayMapToStringMap(req.Header),
+		Headers:     headers.apply(req.Header),
 		RawData:     body,
+		contentType: req.Header.Get("Content-Type"),
 	}
 
 	return request
 }
 
-// Submit sends the error to raygun. If the client is nil it will use a default one with a 5s timeout
+// Submit sends the error to raygun. If the client is nil it will use a default one with a 5s timeout.
+// Custom data that can't be converted to json, such as channels or functions, is replaced by a description of the
+// error rather than failing the submission.
 func Submit(post Post, key string, client *http.Client) error {
-	json, err := json.Marshal(post)
-	if err != nil {
-		return errors.Wrapf(err, "convert to json")
-	}
-
-	r, err := http.NewRequest("POST", Endpoint+"/entries", bytes.NewBuffer(json))
-	if err != nil {
-		return errors.Wrapf(err, "create req")
-	}
-	r.Header.Add("X-ApiKey", key)
-	r.Header.Add("Content-Type", "application/json")
-
-	// Default client has 5s timeout
-	if client == nil {
-		client = &http.Client{
-			Timeout: 5 * time.Second,
-		}
-	}
-
-	resp, err := client.Do(r)
-	if err != nil {
-		return errors.Wrapf(err, "execute req")
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 202 {
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			body = []byte("no body")
-		}
+	return SubmitToUrl(post, Endpoint+"/entries", key, client)
+}
 
-		return errors.New("unexpected answer '" + resp.Status + "' from Raygun: " + string(body))
-	}
+// QuickReport submits a message with the given tags, synchronously and without a reporter, for the scripts and the
+// cron jobs:
+//
+//	crashreport.QuickReport(os.Getenv("RAYGUN_API_KEY"), "backup failed", "cron", "backup")
+//
+// The report only holds the message, the tags and the machine. It's submitted with the default client.
+func QuickReport(key, message string, tags ...string) error {
+	post := NewPost()
+	post.Details.Error = Error{ClassName: "Message", Message: message}
+	post.Details.Tags = tags
+	return Submit(post, key, nil)
+}
 
-	return nil
+// SubmitContext is like Submit, but the request is cancelled when the context is done
+func SubmitContext(ctx context.Context, post Post, key string, client *http.Client) error {
+	return submitContext(ctx, post, Endpoint+"/entries", key, client)
 }
 
 // Submit sends the error to host(with scheam). If the client is nil it will use a default one with a 5s timeout
 func SubmitToUrl(post Post, reportUrl, key string, client *http.Client) error {
-	json, err := json.Marshal(post)
-	if err != nil {
+	return submitContext(context.Background(), post, reportUrl, key, client)
+}
+
+// SubmitContextToUrl is like SubmitToUrl, but the request is cancelled when the context is done
+func SubmitContextToUrl(ctx context.Context, post Post, reportUrl, key string, client *http.Client) error {
+	return submitContext(ctx, post, reportUrl, key, client)
+}
+
+func submitContext(ctx context.Context, post Post, reportUrl, key string, client *http.Client) error {
+	buf := getBuffer()
+	if err := encodePost(buf, post); err != nil {
+		putBuffer(buf)
 		return errors.Wrapf(err, "convert to json")
 	}
+	return postJSON(ctx, buf, reportUrl, key, client)
+}
+
+// jsonHeader is the header of the json bodies
+var jsonHeader = http.Header{"Content-Type": {"application/json"}}
 
-	r, err := http.NewRequest("POST", reportUrl, bytes.NewBuffer(json))
+// postJSON posts the json body to raygun, see postBody
+func postJSON(ctx context.Context, buf *bytes.Buffer, reportUrl, key string, client *http.Client) error {
+	return postBody(ctx, buf, jsonHeader, reportUrl, key, client)
+}
+
+// postBody posts the body to raygun with the header, such as its Content-Type, and expects a 202. The buffer comes
+// from the pool, and goes back to it once the request is done.
+func postBody(ctx context.Context, buf *bytes.Buffer, header http.Header, reportUrl, key string, client *http.Client) error {
+	shared := newPooledBuffer(buf)
+	defer shared.release()
+	body := shared.body()
+	r, err := http.NewRequestWithContext(submission(ctx), "POST", reportUrl, body)
 	if err != nil {
+		body.Close()
 		return errors.Wrapf(err, "create req")
 	}
+	r.ContentLength = int64(buf.Len())
+	r.GetBody = func() (io.ReadCloser, error) {
+		return shared.body(), nil
+	}
+	for name, values := range header {
+		r.Header[name] = values
+	}
 	r.Header.Add("X-ApiKey", key)
-	r.Header.Set("Content-Type", "application/json")
 
 	// Default client has 5s timeout
 	if client == nil {
@@ -278,7 +454,12 @@ func SubmitToUrl(post Post, reportUrl, key string, client *http.Client) error {
 			body = []byte("no body")
 		}
 
-		return errors.New("unexpected answer '" + resp.Status + "' from Raygun: " + string(body))
+		return &ResponseError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       string(body),
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
 
 	return nil
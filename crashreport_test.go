@@ -1,8 +1,12 @@
 package crashreport
 
 import (
+	"encoding/json"
 	"errors"
+	"math"
+	"reflect"
 	"testing"
+	"time"
 
 	jujuerr "github.com/juju/errors"
 	pkerr "github.com/pkg/errors"
@@ -71,3 +75,174 @@ func wrapErr(err error) error {
 func annotateErr(err error) error {
 	return jujuerr.Annotate(err, "wrapped err")
 }
+
+func TestEnvironmentLargeValues(t *testing.T) {
+	large := int64(math.MaxInt32) * 4
+
+	env := Environment{
+		TotalPhysicalMemory:     large,
+		AvailablePhysicalMemory: large + 1,
+		TotalVirtualMemory:      large + 2,
+		AvailableVirtualMemory:  large + 3,
+		DiskSpaceFree:           []int64{large + 4, 1},
+	}
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Environment
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(env, decoded) {
+		t.Errorf("environment should survive a json round trip, got %+v", decoded)
+	}
+}
+
+func TestOccurredTime(t *testing.T) {
+	before := time.Now().Truncate(time.Millisecond)
+	post := NewPost()
+
+	occurred, err := post.OccurredTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if occurred.Before(before) || occurred.After(time.Now()) {
+		t.Errorf("occurred time %s should be now", occurred)
+	}
+	if occurred.Location() != time.UTC {
+		t.Errorf("occurred time should be in UTC, got %s", occurred.Location())
+	}
+
+	instant := time.Date(2020, 5, 17, 10, 30, 15, 123000000, time.UTC)
+	post.OccuredOn = instant.Format(TimeFormat)
+	if post.OccuredOn != "2020-05-17T10:30:15.123Z" {
+		t.Errorf("unexpected format %s", post.OccuredOn)
+	}
+
+	data, _ := json.Marshal(post)
+	var decoded Post
+	json.Unmarshal(data, &decoded)
+	occurred, err = decoded.OccurredTime()
+	if err != nil || !occurred.Equal(instant) {
+		t.Errorf("the millisecond time should survive a round trip, got %s (%v)", occurred, err)
+	}
+
+	post.OccuredOn = "2020-05-17T10:30:15Z"
+	if occurred, err := post.OccurredTime(); err != nil || !occurred.Equal(instant.Truncate(time.Second)) {
+		t.Errorf("times without milliseconds should parse, got %s (%v)", occurred, err)
+	}
+}
+
+func TestQuickReport(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	before := time.Now().Truncate(time.Millisecond)
+	if err := QuickReport("key", "backup failed", "cron", "backup"); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	post := sent[0]
+	if post.Details.Error.Message != "backup failed" || !reflect.DeepEqual(post.Details.Tags, []string{"cron", "backup"}) {
+		t.Errorf("expected the message and the tags, got %+v", post.Details)
+	}
+	if occurred, err := post.OccurredTime(); err != nil || occurred.Before(before) || occurred.After(time.Now()) {
+		t.Errorf("expected the time of the report, got %s (%v)", post.OccuredOn, err)
+	}
+	if post.Details.MachineName == "" || server.Keys()[0] != "key" {
+		t.Errorf("expected the machine and the key, got %q and %q", post.Details.MachineName, server.Keys()[0])
+	}
+}
+
+func TestErrorAsError(t *testing.T) {
+	original := Error{
+		ClassName: "ValidationError",
+		Message:   "invalid configuration",
+		Data:      map[string]interface{}{"field": "port"},
+		StackTrace: StackTrace{
+			NewStackTraceElement("github.com/acme/shop/config", "/src/config/load.go", "Load", 42),
+			NewStackTraceElement("main", "/src/main.go", "main", 12),
+		},
+	}
+
+	err := original.AsError()
+	if _, ok := err.(Error); ok || err.Error() != "invalid configuration" {
+		t.Fatalf("expected a go error with the message, got %T %q", err, err)
+	}
+	replayed := FromErr(err)
+	if replayed.Message != original.Message || replayed.ClassName != original.ClassName ||
+		!reflect.DeepEqual(replayed.Data, original.Data) {
+		t.Errorf("expected the message, the class and the data to survive, got %+v", replayed)
+	}
+	if len(replayed.StackTrace) != 2 {
+		t.Fatalf("expected the 2 frames, got %+v", replayed.StackTrace)
+	}
+	for i, frame := range replayed.StackTrace {
+		if frame.FileName != original.StackTrace[i].FileName || frame.LineNumber != original.StackTrace[i].LineNumber {
+			t.Errorf("expected the frame %+v, got %+v", original.StackTrace[i], frame)
+		}
+	}
+}
+
+func TestErrorRoundTrip(t *testing.T) {
+	e := Error{
+		ClassName: "PaymentError",
+		Message:   "card declined",
+		Data:      map[string]interface{}{"amount": 12.5, "currency": "EUR"},
+	}
+	e.StackTrace.AddEntry(42, "github.com/acme/shop/billing", "/src/billing/charge.go", "Charge")
+	e.StackTrace.AddEntry(7, "", "juju.go", "")
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	json.Unmarshal(data, &raw)
+	if frames, ok := raw["stackTrace"].([]interface{}); !ok || len(frames) != 2 {
+		t.Errorf("the stacktrace should be marshaled as an array of frames, got %v", raw["stackTrace"])
+	}
+
+	parsed, err := ParseError(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, e) {
+		t.Errorf("the error should survive a round trip, got %+v", parsed)
+	}
+
+	if _, err := ParseError([]byte("{not json")); err == nil {
+		t.Error("expected an error for invalid json")
+	}
+
+	expected := "github.com/acme/shop/billing.Charge\n\t/src/billing/charge.go:42\n???\n\tjuju.go:7\n"
+	if text, _ := e.StackTrace.MarshalText(); string(text) != expected {
+		t.Errorf("unexpected text %q", text)
+	}
+	if s := e.StackTrace.String(); s != expected {
+		t.Errorf("String should match MarshalText, got %q", s)
+	}
+}
+
+func TestNewStackTraceElement(t *testing.T) {
+	frame := NewStackTraceElement(" github.com/acme/shop/billing ", "\t/src/billing/charge.go\n", " Charge ", -1)
+	expected := StackTraceElement{PackageName: "github.com/acme/shop/billing", FileName: "/src/billing/charge.go", MethodName: "Charge"}
+	if frame != expected {
+		t.Errorf("expected %+v, got %+v", expected, frame)
+	}
+
+	var stack StackTrace
+	stack.AddEntry(10, "main", "main.go", "main")
+	stack.Prepend(NewStackTraceElement("main", "main.go", "handle", 20))
+	stack.Prepend(NewStackTraceElement("main", "main.go", "parse", 30))
+	if len(stack) != 3 || stack[0].MethodName != "parse" || stack[1].MethodName != "handle" || stack[2].MethodName != "main" {
+		t.Errorf("expected the prepended frames on top, got %+v", stack)
+	}
+}
@@ -0,0 +1,81 @@
+// Package crashreporttest provides utilities to test the code reporting to Raygun with the crashreport package, and to
+// report the failures of the tests themselves, see ReportTestFailure and RunTests. Keeping them here leaves the testing
+// package out of the binaries using crashreport.
+package crashreporttest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// CaptureSubmit runs submit against a fake Raygun api, and returns the first request it received. submit gets the
+// endpoint of the fake api, to be passed to the submission:
+//
+//	req, err := crashreporttest.CaptureSubmit(func(endpoint string) error {
+//		return crashreport.SubmitToUrl(post, endpoint+"/entries", "key", nil)
+//	})
+//
+// The body of the returned request can be read as many times as needed, and is already decompressed if it was
+// gzipped: the Content-Encoding header tells how it was sent. The fake api accepts every request with a 202.
+// It returns an error if submit fails or sends nothing.
+func CaptureSubmit(submit func(endpoint string) error) (*http.Request, error) {
+	var mu sync.Mutex
+	var captured *http.Request
+	var captureErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := readBody(req)
+		mu.Lock()
+		if captured == nil && captureErr == nil {
+			captured, captureErr = req.Clone(req.Context()), err
+			captured.Body = ioutil.NopCloser(bytes.NewReader(body))
+			captured.GetBody = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	if err := submit(server.URL); err != nil {
+		return nil, errors.Wrap(err, "submit")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if captureErr != nil {
+		return nil, captureErr
+	}
+	if captured == nil {
+		return nil, errors.New("no request was submitted")
+	}
+	return captured, nil
+}
+
+// readBody reads the body of the request, decompressing it if needed
+func readBody(req *http.Request) ([]byte, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+	if req.Header.Get("Content-Encoding") != "gzip" {
+		return body, nil
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "decompress body")
+	}
+	body, err = ioutil.ReadAll(gz)
+	if err != nil {
+		return nil, errors.Wrap(err, "decompress body")
+	}
+	return body, nil
+}
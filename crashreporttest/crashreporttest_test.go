@@ -0,0 +1,51 @@
+package crashreporttest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestCaptureSubmitGzip(t *testing.T) {
+	req, err := CaptureSubmit(func(endpoint string) error {
+		var body bytes.Buffer
+		gz := gzip.NewWriter(&body)
+		gz.Write([]byte(`{"details":{}}`))
+		gz.Close()
+
+		req, _ := http.NewRequest("POST", endpoint+"/entries", &body)
+		req.Header.Set("Content-Encoding", "gzip")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Header.Get("Content-Encoding") != "gzip" {
+		t.Error("the encoding of the request should be kept")
+	}
+	for i := 0; i < 2; i++ {
+		body, _ := ioutil.ReadAll(req.Body)
+		if string(body) != `{"details":{}}` {
+			t.Errorf("expected the decompressed body, got %q", body)
+		}
+		req.Body, _ = req.GetBody()
+	}
+}
+
+func TestCaptureSubmitErrors(t *testing.T) {
+	if _, err := CaptureSubmit(func(string) error { return nil }); err == nil {
+		t.Error("expected an error when nothing is submitted")
+	}
+	if _, err := CaptureSubmit(func(string) error { return errors.New("refused") }); err == nil {
+		t.Error("expected the error of the submission")
+	}
+}
@@ -0,0 +1,77 @@
+package crashreporttest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/chennqqi/crashreport"
+	"github.com/chennqqi/crashreport/crashreporttest"
+)
+
+func ExampleCaptureSubmit() {
+	post := crashreport.NewPost()
+	post.Details.Error = crashreport.FromErr(errors.New("payment declined"))
+
+	req, err := crashreporttest.CaptureSubmit(func(endpoint string) error {
+		return crashreport.SubmitToUrl(post, endpoint+"/entries", "my-api-key", nil)
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	var sent crashreport.Post
+	json.NewDecoder(req.Body).Decode(&sent)
+
+	fmt.Println(req.Method, req.URL.Path)
+	fmt.Println(req.Header.Get("X-ApiKey"))
+	fmt.Println(req.Header.Get("Content-Type"))
+	fmt.Println(sent.Details.Error.Message)
+	// Output:
+	// POST /entries
+	// my-api-key
+	// application/json
+	// payment declined
+}
+
+func ExampleCaptureSubmit_reporter() {
+	req, err := crashreporttest.CaptureSubmit(func(endpoint string) error {
+		reporter := crashreport.NewReporter("my-api-key", crashreport.WithEndpoint(endpoint))
+		return reporter.Report(errors.New("cache miss"), crashreport.WithTags("cache"))
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	var sent crashreport.Post
+	json.NewDecoder(req.Body).Decode(&sent)
+
+	fmt.Println(sent.Details.Error.Message, sent.Details.Tags)
+	// Output:
+	// cache miss [cache]
+}
+
+func ExampleMemorySink() {
+	sink := crashreporttest.NewMemorySink()
+	reporter := crashreport.NewReporter("my-api-key", crashreport.WithSink(sink))
+
+	reporter.Report(errors.New("inventory out of sync"), crashreport.WithUser("alice"))
+
+	for _, post := range sink.Posts() {
+		fmt.Println(post.Details.Error.Message, post.Details.User.Identifier)
+	}
+	// Output:
+	// inventory out of sync alice
+}
+
+func ExampleRunTests() {
+	reporter := crashreport.NewReporter(os.Getenv("RAYGUN_API_KEY"))
+
+	// The TestMain of the package under test
+	testMain := func(m *testing.M) {
+		os.Exit(crashreporttest.RunTests(m, reporter))
+	}
+	_ = testMain
+}
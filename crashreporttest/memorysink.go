@@ -0,0 +1,50 @@
+package crashreporttest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chennqqi/crashreport"
+)
+
+// MemorySink keeps the posts sent to it in memory, to test the reports of a library without any http:
+//
+//	sink := crashreporttest.NewMemorySink()
+//	reporter := crashreport.NewReporter("key", crashreport.WithSink(sink))
+//
+// It's safe for concurrent use.
+type MemorySink struct {
+	mu    sync.Mutex
+	posts []crashreport.Post
+}
+
+// NewMemorySink creates an empty sink
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+// Send keeps a copy of the post. The key is ignored.
+func (s *MemorySink) Send(ctx context.Context, post crashreport.Post, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.posts = append(s.posts, post.Clone())
+	return nil
+}
+
+// Posts returns copies of the posts sent so far, in order
+func (s *MemorySink) Posts() []crashreport.Post {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := make([]crashreport.Post, len(s.posts))
+	for i, post := range s.posts {
+		posts[i] = post.Clone()
+	}
+	return posts
+}
+
+// Reset forgets the posts sent so far
+func (s *MemorySink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.posts = nil
+}
@@ -0,0 +1,38 @@
+package crashreporttest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/chennqqi/crashreport"
+)
+
+func TestMemorySink(t *testing.T) {
+	sink := NewMemorySink()
+	reporter := crashreport.NewReporter("key", crashreport.WithSink(sink))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reporter.Report(errors.New("concurrent"), crashreport.WithTags("worker"))
+		}()
+	}
+	wg.Wait()
+
+	posts := sink.Posts()
+	if len(posts) != 10 {
+		t.Fatalf("expected 10 posts, got %d", len(posts))
+	}
+	posts[0].Details.Tags[0] = "changed"
+	if tags := sink.Posts()[0].Details.Tags; tags[0] != "worker" {
+		t.Errorf("the posts should be copies, got the tags %v", tags)
+	}
+
+	sink.Reset()
+	if posts := sink.Posts(); len(posts) != 0 {
+		t.Errorf("expected no post after Reset, got %d", len(posts))
+	}
+}
@@ -0,0 +1,76 @@
+package crashreporttest
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chennqqi/crashreport"
+)
+
+// testReportTimeout is how long a failed test waits for its report to be submitted
+const testReportTimeout = 5 * time.Second
+
+// failedTests counts the failed tests of ReportTestFailure, for the summary of RunTests
+var failedTests int64
+
+// ReportTestFailure reports the test with CaptureMessage if it fails or panics, to follow the flaky tests of the CI in
+// Raygun. The report has the "ci" tag, the name of the test in its custom data, under the "test" key, and the
+// breadcrumb sources of the reporter: the testing package doesn't expose the messages of a failure, so a log capture
+// is the way to attach them. Call it at the start of the test, it registers a cleanup:
+//
+//	func TestCheckout(t *testing.T) {
+//		crashreporttest.ReportTestFailure(t, reporter)
+//		...
+//	}
+//
+// It does nothing when the CRASHREPORT_DISABLE_TESTS environment variable is set, to keep local runs quiet.
+func ReportTestFailure(t testing.TB, reporter *crashreport.Reporter) {
+	if os.Getenv("CRASHREPORT_DISABLE_TESTS") != "" {
+		return
+	}
+	t.Cleanup(func() {
+		if !t.Failed() {
+			return
+		}
+		atomic.AddInt64(&failedTests, 1)
+		reporter.CaptureMessage("test failed: "+t.Name(), crashreport.WithLevel(crashreport.LevelError),
+			crashreport.WithTags("ci"), crashreport.WithCustomData("test", t.Name()))
+		reporter.Flush(testReportTimeout)
+	})
+}
+
+// RunTests runs the tests of the package and returns the exit code for os.Exit. If the suite fails, a summary is
+// reported with CaptureMessage, for a view of the health of the CI without a report per test. It has the "ci" and
+// "ci-suite-failure" tags, and in its custom data the name of the test binary, under the "suite" key, the exit code,
+// under "exitCode", and the number of failed tests under "failedTests": the testing package doesn't expose it, so
+// only the tests calling ReportTestFailure are counted. Call it from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(crashreporttest.RunTests(m, reporter))
+//	}
+//
+// Like ReportTestFailure, it reports nothing when the CRASHREPORT_DISABLE_TESTS environment variable is set.
+func RunTests(m *testing.M, reporter *crashreport.Reporter) int {
+	return runTests(m.Run, reporter)
+}
+
+// runTests is RunTests with the function running the tests
+func runTests(run func() int, reporter *crashreport.Reporter) int {
+	atomic.StoreInt64(&failedTests, 0)
+	code := run()
+	if code == 0 || os.Getenv("CRASHREPORT_DISABLE_TESTS") != "" {
+		return code
+	}
+	opts := []crashreport.ReportOption{crashreport.WithLevel(crashreport.LevelError),
+		crashreport.WithTags("ci", "ci-suite-failure"), crashreport.WithCustomData("suite", filepath.Base(os.Args[0])),
+		crashreport.WithCustomData("exitCode", code)}
+	if failed := atomic.LoadInt64(&failedTests); failed > 0 {
+		opts = append(opts, crashreport.WithCustomData("failedTests", failed))
+	}
+	reporter.CaptureMessage("test suite failed", opts...)
+	reporter.Flush(testReportTimeout)
+	return code
+}
@@ -0,0 +1,108 @@
+package crashreporttest
+
+import (
+	"testing"
+
+	"github.com/chennqqi/crashreport"
+)
+
+// fakeT is a test whose result is controlled, to check the reports of failed tests without failing
+type fakeT struct {
+	testing.TB
+	name     string
+	failed   bool
+	cleanups []func()
+}
+
+func (f *fakeT) Name() string           { return f.name }
+func (f *fakeT) Failed() bool           { return f.failed }
+func (f *fakeT) Cleanup(cleanup func()) { f.cleanups = append(f.cleanups, cleanup) }
+
+// finish runs the cleanups, as the testing package does at the end of a test
+func (f *fakeT) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestReportTestFailure(t *testing.T) {
+	sink := NewMemorySink()
+	reporter := crashreport.NewReporter("key", crashreport.WithSink(sink), crashreport.WithAsync(1, 10))
+	defer reporter.Close(0)
+
+	passed := &fakeT{name: "TestPassed"}
+	ReportTestFailure(passed, reporter)
+	passed.finish()
+
+	failed := &fakeT{name: "TestFlaky"}
+	ReportTestFailure(failed, reporter)
+	failed.failed = true
+	failed.finish()
+
+	sent := sink.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("only the failed test should be reported, got %d posts", len(sent))
+	}
+	post := sent[0]
+	if post.Details.Error.Message != "test failed: TestFlaky" {
+		t.Errorf("unexpected message %q", post.Details.Error.Message)
+	}
+	if !hasTag(post.Details.Tags, "ci") {
+		t.Errorf("the report should have the ci tag, got %v", post.Details.Tags)
+	}
+	if test := post.Details.UserCustomData.(map[string]interface{})["test"]; test != "TestFlaky" {
+		t.Errorf("the custom data should have the test name, got %v", test)
+	}
+
+	t.Setenv("CRASHREPORT_DISABLE_TESTS", "1")
+	disabled := &fakeT{name: "TestLocal", failed: true}
+	ReportTestFailure(disabled, reporter)
+	disabled.finish()
+	if len(disabled.cleanups) != 0 || len(sink.Posts()) != 1 {
+		t.Error("nothing should be reported when CRASHREPORT_DISABLE_TESTS is set")
+	}
+}
+
+func TestRunTests(t *testing.T) {
+	sink := NewMemorySink()
+	reporter := crashreport.NewReporter("key", crashreport.WithSink(sink))
+
+	passing := func() int { return 0 }
+	if code := runTests(passing, reporter); code != 0 || len(sink.Posts()) != 0 {
+		t.Fatalf("a passing suite should not be reported, got code %d and %d posts", code, len(sink.Posts()))
+	}
+
+	failing := func() int {
+		for _, name := range []string{"TestCheckout", "TestRefund", "TestInvoice"} {
+			test := &fakeT{name: name, failed: name != "TestInvoice"}
+			ReportTestFailure(test, reporter)
+			test.finish()
+		}
+		return 1
+	}
+	if code := runTests(failing, reporter); code != 1 {
+		t.Errorf("expected the exit code of the suite, got %d", code)
+	}
+
+	sent := sink.Posts()
+	if len(sent) != 3 {
+		t.Fatalf("expected the 2 failed tests and the summary, got %d posts", len(sent))
+	}
+	summary := sent[2]
+	if summary.Details.Error.Message != "test suite failed" || !hasTag(summary.Details.Tags, "ci-suite-failure") {
+		t.Errorf("unexpected summary %q with tags %v", summary.Details.Error.Message, summary.Details.Tags)
+	}
+	data := summary.Details.UserCustomData.(map[string]interface{})
+	if data["failedTests"] != int64(2) || data["exitCode"] != 1 || data["suite"] == "" {
+		t.Errorf("expected the number of failed tests and the exit code, got %v", data)
+	}
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package crashreport
+
+import (
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// crashSignals are the signals watched by WithCrashSignals
+var crashSignals = []os.Signal{syscall.SIGSEGV, syscall.SIGABRT}
+
+// WithCrashSignals writes a report to the disk queue set with WithDiskQueue when the process receives SIGSEGV or
+// SIGABRT, then raises the signal again to crash as it would have. The report has the fatal level, the name of the
+// signal as message and under the "signal" key of the custom data, and the stacks of all the goroutines under the
+// "goroutines" key. It's submitted by DrainQueue, at the next start.
+//
+// This is best effort, for the crashes that bypass the panics, and it has strong limits:
+//
+//   - The faults of the Go code, such as a nil dereference, are panics and not signals: see CapturePanicFull.
+//   - Only the signals delivered to the Go runtime are seen, such as the ones sent with kill or raised by abort()
+//     in C code. A fault in C code called with cgo usually crashes the process before any Go code runs.
+//   - The process may be corrupted when the signal arrives, so the report is kept minimal: it doesn't go through the
+//     options, the enrichers or the redactions of the reporter, and it's not sent over the network but written
+//     synchronously to the disk queue.
+//   - The goroutine dump uses a buffer allocated when the reporter is created, of the size set with
+//     WithGoroutineDumpLimit, and it's cut when the buffer is full.
+//
+// Without a disk queue, the option does nothing. Close stops watching the signals.
+func WithCrashSignals() Option {
+	return func(r *Reporter) {
+		r.crashSignals = true
+	}
+}
+
+// watchCrashSignals starts the goroutine writing the report of a crash signal, see WithCrashSignals
+func (r *Reporter) watchCrashSignals() {
+	if r.diskQueue.dir == "" {
+		return
+	}
+	limit := r.goroutineDumpLimit
+	if limit <= 0 {
+		limit = defaultGoroutineDumpLimit
+	}
+	buf := make([]byte, limit)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, crashSignals...)
+	go func() {
+		select {
+		case <-r.ctx.Done():
+			signal.Stop(signals)
+		case sig := <-signals:
+			r.persist(r.signalPost(sig, buf))
+			raise(sig)
+		}
+	}()
+}
+
+// signalPost builds the report of a crash signal, with the goroutine dump written to buf
+func (r *Reporter) signalPost(sig os.Signal, buf []byte) Post {
+	post := r.newPost()
+	post.Details.Error = Error{Message: "signal: " + sig.String(), ClassName: "signal"}
+	post.level = LevelFatal
+	applyLevel(&post)
+
+	n := runtime.Stack(buf, true)
+	post.SetCustomData("signal", sig.String())
+	post.SetCustomData("goroutines", string(buf[:n]))
+	if n == len(buf) {
+		post.SetCustomData("goroutinesTruncated", true)
+	}
+	return post
+}
+
+// raise restores the default handling of the signal and sends it again to the process. If the process survives
+// it, it exits with the status of a Go crash.
+func raise(sig os.Signal) {
+	signal.Reset(sig)
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		p.Signal(sig)
+	}
+	time.Sleep(time.Second)
+	exit(2)
+}
@@ -0,0 +1,45 @@
+//go:build linux || darwin || freebsd
+
+package crashreport
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWithCrashSignals(t *testing.T) {
+	if dir := os.Getenv("CRASHREPORT_TEST_SIGNAL_DIR"); dir != "" {
+		NewReporter("key", WithDiskQueue(dir), WithCrashSignals())
+		syscall.Kill(os.Getpid(), syscall.SIGABRT)
+		time.Sleep(5 * time.Second)
+		return
+	}
+
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestWithCrashSignals$")
+	cmd.Env = append(os.Environ(), "CRASHREPORT_TEST_SIGNAL_DIR="+dir)
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected the process to crash")
+	}
+
+	reporter := NewReporter("key", WithDiskQueue(dir))
+	files, err := reporter.queuedFiles()
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected a queued report, got %v, %v", files, err)
+	}
+	post, err := readPost(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Details.Error.Message != "signal: aborted" || !hasTag(post.Details.Tags, "severity:fatal") {
+		t.Errorf("expected a fatal report of the signal, got %q and %v", post.Details.Error.Message, post.Details.Tags)
+	}
+	data := post.Details.UserCustomData.(map[string]interface{})
+	if dump, _ := data["goroutines"].(string); !strings.Contains(dump, "TestWithCrashSignals") {
+		t.Errorf("expected the goroutine dump, got %q", dump)
+	}
+}
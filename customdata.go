@@ -0,0 +1,85 @@
+package crashreport
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// WithCustomDataMarshaler sets the function converting the custom data of the reports to json, json.Marshal by
+// default, for custom data relying on another encoding such as a jsoniter config. When it fails, the custom data is
+// replaced by a description of the error and the report is still submitted.
+func WithCustomDataMarshaler(marshal func(v interface{}) ([]byte, error)) Option {
+	return func(r *Reporter) {
+		r.customDataMarshaler = marshal
+	}
+}
+
+// marshalCustomData converts the custom data of the post with the marshaler of the reporter, if any
+func (r *Reporter) marshalCustomData(post *Post) {
+	if r.customDataMarshaler == nil || post.Details.UserCustomData == nil {
+		return
+	}
+	if _, ok := post.Details.UserCustomData.(json.RawMessage); ok {
+		return // already converted, for a post submitted again
+	}
+	var data []byte
+	err := errors.New("custom data marshaler panicked")
+	safely("custom data marshaler", func() { data, err = r.customDataMarshaler(post.Details.UserCustomData) })
+	if err != nil {
+		post.Details.UserCustomData = unmarshalable(err)
+		return
+	}
+	post.Details.UserCustomData = json.RawMessage(data)
+}
+
+// marshalPost converts the post to json. Custom data that can't be converted, such as channels or functions, is
+// replaced by a description of the error rather than failing the whole report: in a map, only the values that can't
+// be converted are replaced.
+func marshalPost(post Post) ([]byte, error) {
+	data, err := json.Marshal(post)
+	if err == nil || post.Details.UserCustomData == nil {
+		return data, err
+	}
+	post.Details.UserCustomData = safeCustomData(post.Details.UserCustomData)
+	return json.Marshal(post)
+}
+
+// encodePost is marshalPost encoding into the buffer, such as a pooled one, instead of a new slice
+func encodePost(buf *bytes.Buffer, post Post) error {
+	err := json.NewEncoder(buf).Encode(post)
+	if err == nil || post.Details.UserCustomData == nil {
+		return err
+	}
+	buf.Reset()
+	post.Details.UserCustomData = safeCustomData(post.Details.UserCustomData)
+	return json.NewEncoder(buf).Encode(post)
+}
+
+// safeCustomData returns the custom data with the values that can't be converted to json replaced, see marshalPost.
+// The custom data itself is not modified.
+func safeCustomData(v interface{}) interface{} {
+	_, err := json.Marshal(v)
+	if err == nil {
+		return v
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return unmarshalable(err)
+	}
+	safe := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if _, err := json.Marshal(value); err != nil {
+			safe[key] = unmarshalable(err)
+		} else {
+			safe[key] = value
+		}
+	}
+	return safe
+}
+
+// unmarshalable is the placeholder of custom data that can't be converted to json
+func unmarshalable(err error) string {
+	return "unmarshalable custom data: " + err.Error()
+}
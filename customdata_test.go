@@ -0,0 +1,71 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalableCustomData(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	post := NewPost()
+	post.SetCustomData("done", make(chan struct{}))
+	post.SetCustomData("user", "alice")
+	if err := Submit(post, "key", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	reporter := NewReporter("key")
+	if err := reporter.Report(errors.New("boom"), WithCustomData("callback", func() {})); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	data := sent[0].Details.UserCustomData.(map[string]interface{})
+	if data["user"] != "alice" {
+		t.Errorf("expected the other custom data to be kept, got %v", data)
+	}
+	if placeholder, _ := data["done"].(string); !strings.HasPrefix(placeholder, "unmarshalable custom data: ") {
+		t.Errorf("expected a placeholder for the channel, got %v", data["done"])
+	}
+	if _, ok := post.Details.UserCustomData.(map[string]interface{})["done"].(chan struct{}); !ok {
+		t.Error("the custom data of the post should not be modified")
+	}
+	callback, _ := sent[1].Details.UserCustomData.(map[string]interface{})["callback"].(string)
+	if !strings.Contains(callback, "func") {
+		t.Errorf("expected a placeholder for the function, got %v", sent[1].Details.UserCustomData)
+	}
+}
+
+func TestWithCustomDataMarshaler(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	marshal := func(v interface{}) ([]byte, error) {
+		if _, ok := v.(map[string]interface{})["secret"]; ok {
+			return nil, errors.New("secret custom data")
+		}
+		return json.Marshal(map[string]interface{}{"wrapped": v})
+	}
+	reporter := NewReporter("key", WithCustomDataMarshaler(marshal))
+	reporter.CaptureMessage("custom", WithCustomData("user", "alice"))
+	reporter.CaptureMessage("secret", WithCustomData("secret", "hunter2"))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	wrapped, _ := sent[0].Details.UserCustomData.(map[string]interface{})["wrapped"].(map[string]interface{})
+	if wrapped["user"] != "alice" {
+		t.Errorf("expected the custom data converted by the marshaler, got %v", sent[0].Details.UserCustomData)
+	}
+	if sent[1].Details.UserCustomData != "unmarshalable custom data: secret custom data" {
+		t.Errorf("expected a placeholder when the marshaler fails, got %v", sent[1].Details.UserCustomData)
+	}
+}
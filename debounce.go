@@ -0,0 +1,92 @@
+package crashreport
+
+import (
+	"sync"
+	"time"
+)
+
+// WithDebounce submits each error at most once per interval: the first occurrence of an error starts a timer, and
+// when it fires the latest occurrence is submitted, with its own context. Errors are identified by their Fingerprint,
+// or by their StackFingerprint with WithStackOnlyFingerprint.
+// The reports are delayed by up to interval, and the ones still waiting are submitted by Close. An occurrence that
+// occurred an interval after the first waiting one, such as a report replayed WithOccurredOn, submits the waiting one
+// right away and starts a new timer.
+func WithDebounce(interval time.Duration) Option {
+	return func(r *Reporter) {
+		r.debounce.interval = interval
+		r.debounce.latest = map[string]*debounced{}
+	}
+}
+
+// debounce holds the latest occurrence of each error waiting for its timer
+type debounce struct {
+	interval time.Duration
+
+	mu     sync.Mutex
+	latest map[string]*debounced // by fingerprint
+}
+
+type debounced struct {
+	pendingPost
+	timer *time.Timer
+	start time.Time // the occurrence time of the first occurrence
+}
+
+// debounced replaces the waiting occurrence of the post's error, or starts a timer if there is none
+func (r *Reporter) debounced(post Post, key string) {
+	fingerprint := r.fingerprint(post)
+	at := occurredAt(post)
+
+	r.debounce.mu.Lock()
+	var expired []pendingPost
+	if d, ok := r.debounce.latest[fingerprint]; ok {
+		if at.Sub(d.start) < r.debounce.interval {
+			replaced := d.post
+			d.post, d.key = post, key
+			r.debounce.mu.Unlock()
+			post.outcome.record(OutcomeQueued, nil)
+			r.dropped(replaced, DropDeduped)
+			return
+		}
+		// Replayed after the interval of the waiting occurrence, which is submitted now
+		if d.timer.Stop() {
+			expired = append(expired, d.pendingPost)
+		}
+		delete(r.debounce.latest, fingerprint)
+	}
+	d := &debounced{pendingPost: pendingPost{post, key}, start: at}
+	post.outcome.record(OutcomeQueued, nil)
+	d.timer = time.AfterFunc(r.debounce.interval, func() {
+		r.debounce.mu.Lock()
+		if r.debounce.latest[fingerprint] == d {
+			delete(r.debounce.latest, fingerprint)
+		}
+		p := d.pendingPost
+		r.debounce.mu.Unlock()
+
+		r.dispatch(p.post, p.key)
+	})
+	r.debounce.latest[fingerprint] = d
+	r.debounce.mu.Unlock()
+
+	for _, p := range expired {
+		r.dispatch(p.post, p.key)
+	}
+}
+
+// flushDebounced submits the waiting occurrences without waiting for their timers
+func (r *Reporter) flushDebounced() {
+	r.debounce.mu.Lock()
+	var pending []pendingPost
+	for fingerprint, d := range r.debounce.latest {
+		if d.timer.Stop() {
+			pending = append(pending, d.pendingPost)
+			delete(r.debounce.latest, fingerprint)
+		}
+	}
+	r.debounce.mu.Unlock()
+
+	for _, p := range pending {
+		r.dispatch(p.post, p.key)
+	}
+}
@@ -0,0 +1,49 @@
+package crashreport
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithDebounce(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	var mu sync.Mutex
+	var sentAt []time.Time
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		sentAt = append(sentAt, time.Now())
+		mu.Unlock()
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	const interval = 50 * time.Millisecond
+	reporter := NewReporter("key", WithClient(client), WithDebounce(interval))
+	for i := 0; i < 20; i++ {
+		reporter.Report(errors.New("flapping"), WithCustomData("attempt", i))
+		time.Sleep(interval / 10)
+	}
+	time.Sleep(2 * interval)
+	defer reporter.Close(time.Second)
+
+	sent := server.Posts()
+	if len(sent) < 2 {
+		t.Fatalf("expected the error to be sent at least twice, got %d", len(sent))
+	}
+	last := sent[len(sent)-1].Details.UserCustomData.(map[string]interface{})
+	if last["attempt"] != float64(19) {
+		t.Errorf("expected the latest occurrence to be sent last, got attempt %v", last["attempt"])
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i := 1; i < len(sentAt); i++ {
+		if gap := sentAt[i].Sub(sentAt[i-1]); gap < interval {
+			t.Errorf("submissions %d and %d are only %s apart", i-1, i, gap)
+		}
+	}
+}
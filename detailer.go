@@ -0,0 +1,74 @@
+package crashreport
+
+import (
+	"errors"
+	"sync"
+)
+
+// detailers are the functions registered with RegisterErrorDetailer, after the built-in ones
+var detailers = struct {
+	sync.RWMutex
+	list []func(error) map[string]interface{}
+}{list: []func(error) map[string]interface{}{assertionDetails}}
+
+// RegisterErrorDetailer registers a function adding the details of an error to Error.Data in FromErr. It's meant for
+// the typed errors of libraries, such as the database drivers, that carry codes or names in their fields:
+//
+//	crashreport.RegisterErrorDetailer(func(err error) map[string]interface{} {
+//		var pqErr *pq.Error
+//		if !errors.As(err, &pqErr) {
+//			return nil
+//		}
+//		return map[string]interface{}{"sqlState": string(pqErr.Code), "constraint": pqErr.Constraint}
+//	})
+//
+// The function receives the reported error, and returns nil for the errors it doesn't know. The keys of the maps
+// returned by the detailers are set in Error.Data, in the order of the registration. It must be safe for concurrent
+// use.
+//
+// The assertion errors of the test libraries are detailed by default: the values of their `Expected() interface{}`
+// and `Actual() interface{}` methods are set under the "expected" and "actual" keys, and the result of their
+// `Diff() string` method under the "diff" key.
+func RegisterErrorDetailer(detailer func(error) map[string]interface{}) {
+	detailers.Lock()
+	defer detailers.Unlock()
+	detailers.list = append(detailers.list, detailer)
+}
+
+// assertionDetails is the built-in detailer of the assertion errors, see RegisterErrorDetailer
+func assertionDetails(err error) map[string]interface{} {
+	type expectedActual interface {
+		Expected() interface{}
+		Actual() interface{}
+	}
+	type differ interface {
+		Diff() string
+	}
+
+	var details map[string]interface{}
+	var assertion expectedActual
+	if errors.As(err, &assertion) {
+		details = map[string]interface{}{"expected": assertion.Expected(), "actual": assertion.Actual()}
+	}
+	var diff differ
+	if errors.As(err, &diff) {
+		if details == nil {
+			details = map[string]interface{}{}
+		}
+		details["diff"] = diff.Diff()
+	}
+	return details
+}
+
+// detail sets the details of the registered detailers in the data of the error
+func detail(e *Error, err error) {
+	detailers.RLock()
+	defer detailers.RUnlock()
+	for _, detailer := range detailers.list {
+		var data map[string]interface{}
+		safely("error detailer", func() { data = detailer(err) })
+		for k, v := range data {
+			e.SetData(k, v)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package crashreport
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// pgError mimics the error of a postgres driver
+type pgError struct {
+	Code       string
+	Constraint string
+}
+
+func (e *pgError) Error() string {
+	return "duplicate key value violates unique constraint \"" + e.Constraint + "\""
+}
+
+func TestRegisterErrorDetailer(t *testing.T) {
+	defer func(list []func(error) map[string]interface{}) { detailers.list = list }(detailers.list)
+
+	RegisterErrorDetailer(func(err error) map[string]interface{} {
+		var pgErr *pgError
+		if !errors.As(err, &pgErr) {
+			return nil
+		}
+		return map[string]interface{}{"sqlState": pgErr.Code, "constraint": pgErr.Constraint}
+	})
+
+	err := fmt.Errorf("create user: %w", &pgError{Code: "23505", Constraint: "users_email_key"})
+	data, ok := FromErr(err).Data.(map[string]interface{})
+	if !ok || data["sqlState"] != "23505" || data["constraint"] != "users_email_key" {
+		t.Errorf("expected the details of the driver error, got %v", FromErr(err).Data)
+	}
+
+	if data := FromErr(errors.New("other")).Data; data != nil {
+		t.Errorf("the other errors should have no details, got %v", data)
+	}
+}
+
+// assertionError mimics the error of an assertion library
+type assertionError struct {
+	expected, actual interface{}
+}
+
+func (e *assertionError) Error() string         { return "values differ" }
+func (e *assertionError) Expected() interface{} { return e.expected }
+func (e *assertionError) Actual() interface{}   { return e.actual }
+func (e *assertionError) Diff() string {
+	return fmt.Sprintf("- %v\n+ %v\n", e.expected, e.actual)
+}
+
+func TestAssertionDetails(t *testing.T) {
+	err := fmt.Errorf("checkout total: %w", &assertionError{expected: 42, actual: 41})
+	data, ok := FromErr(err).Data.(map[string]interface{})
+	if !ok || data["expected"] != 42 || data["actual"] != 41 || data["diff"] != "- 42\n+ 41\n" {
+		t.Errorf("expected the expected and actual values and the diff, got %v", FromErr(err).Data)
+	}
+}
@@ -0,0 +1,355 @@
+package crashreport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	crand "crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Extensions of the files of the disk queue. The encrypted files have the extension of their content followed by
+// queueExtEncrypted.
+const (
+	queueExt          = ".json"
+	queueExtGzip      = ".json.gz"
+	queueExtEncrypted = ".enc"
+)
+
+// errUndecryptable is returned for the files of the disk queue that can't be decrypted with the key of the reporter
+var errUndecryptable = errors.New("crashreport: can't decrypt the queued report")
+
+// diskQueue persists the reports that couldn't be submitted
+type diskQueue struct {
+	mu       sync.Mutex
+	dir      string
+	compress bool
+	batched  bool          // see WithDiskQueueBatching
+	batching []BatchOption // of the sink of the batches
+	aead     cipher.AEAD   // see WithDiskQueueEncryption
+}
+
+// WithDiskQueue persists in dir the reports that couldn't be submitted because of the network or of a server error,
+// one file per report. They are submitted again by DrainQueue, for example at the next start.
+func WithDiskQueue(dir string) Option {
+	return func(r *Reporter) {
+		r.diskQueue.dir = dir
+	}
+}
+
+// WithDiskQueueCompression gzips the files of the disk queue. Files written with and without compression can be mixed
+// in the same queue, they are told apart by their extension.
+func WithDiskQueueCompression(enabled bool) Option {
+	return func(r *Reporter) {
+		r.diskQueue.compress = enabled
+	}
+}
+
+// WithDiskQueueBatching makes DrainQueue submit the reports of the disk queue in gzipped batches to the bulk endpoint,
+// instead of one by one, for the large queues replayed after an outage. The batches are limited as the ones of a
+// BatchSink, and the options configure them: WithBatchSize, WithBatchBytes, or WithBatchCompression(false) to send
+// them uncompressed. By default they are submitted to the endpoint and with the client of the reporter.
+func WithDiskQueueBatching(opts ...BatchOption) Option {
+	return func(r *Reporter) {
+		r.diskQueue.batched = true
+		r.diskQueue.batching = opts
+	}
+}
+
+// WithDiskQueueEncryption encrypts the files of the disk queue with AES-GCM, for the reports holding personal data that
+// mustn't stay in plain text on the disk. The key is of 16, 24 or 32 bytes, for AES-128, AES-192 or AES-256: another
+// size is a configuration error, returned by every report. Each file has its own random nonce, stored before the
+// ciphertext.
+// DrainQueue skips the files that can't be decrypted, such as the ones written with a previous key, and logs them:
+// they stay in the queue for a reporter with the right key.
+func WithDiskQueueEncryption(key []byte) Option {
+	return func(r *Reporter) {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			r.err = errors.Wrap(err, "disk queue encryption")
+			return
+		}
+		r.diskQueue.aead, _ = cipher.NewGCM(block) // never fails with the block of aes
+	}
+}
+
+// persist writes the post to the disk queue. The file is written under a temporary name and renamed, so that
+// DrainQueue never reads a partial file.
+func (r *Reporter) persist(post Post) error {
+	if err := os.MkdirAll(r.diskQueue.dir, 0700); err != nil {
+		return errors.Wrapf(err, "create queue dir")
+	}
+
+	ext := queueExt
+	if r.diskQueue.compress {
+		ext = queueExtGzip
+	}
+	if r.diskQueue.aead != nil {
+		ext += queueExtEncrypted
+	}
+	name := fmt.Sprintf("%020d-%08x%s", time.Now().UnixNano(), rand.Uint32(), ext)
+
+	tmp, err := ioutil.TempFile(r.diskQueue.dir, ".tmp-")
+	if err != nil {
+		return errors.Wrapf(err, "create queue file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if r.diskQueue.aead != nil {
+		err = r.writeEncrypted(tmp, post)
+	} else {
+		err = writePost(tmp, post, r.diskQueue.compress)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "write queue file")
+	}
+	return os.Rename(tmp.Name(), filepath.Join(r.diskQueue.dir, name))
+}
+
+func writePost(w io.Writer, post Post, compress bool) error {
+	if !compress {
+		return json.NewEncoder(w).Encode(post)
+	}
+	gz := getGzipWriter(w)
+	defer gzipPool.Put(gz)
+	if err := json.NewEncoder(gz).Encode(post); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeEncrypted writes the post encrypted with the key of WithDiskQueueEncryption: the nonce, then the ciphertext
+func (r *Reporter) writeEncrypted(w io.Writer, post Post) error {
+	var buf bytes.Buffer
+	if err := writePost(&buf, post, r.diskQueue.compress); err != nil {
+		return err
+	}
+	nonce := make([]byte, r.diskQueue.aead.NonceSize())
+	if _, err := io.ReadFull(crand.Reader, nonce); err != nil {
+		return errors.Wrap(err, "generate nonce")
+	}
+	_, err := w.Write(r.diskQueue.aead.Seal(nonce, nonce, buf.Bytes(), nil))
+	return err
+}
+
+// readPost reads a file of the disk queue, decompressing it according to its extension
+func readPost(path string) (Post, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Post{}, err
+	}
+	defer f.Close()
+	return decodePost(f, strings.HasSuffix(path, queueExtGzip))
+}
+
+// decodePost reads a post, gzipped or not
+func decodePost(reader io.Reader, gzipped bool) (Post, error) {
+	var post Post
+	if gzipped {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return post, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	err := json.NewDecoder(reader).Decode(&post)
+	return post, err
+}
+
+// readQueued reads a file of the disk queue, decrypting it if it's encrypted. It returns errUndecryptable, and logs
+// it, if the file can't be decrypted with the key of the reporter.
+func (r *Reporter) readQueued(path string) (Post, error) {
+	if !strings.HasSuffix(path, queueExtEncrypted) {
+		return readPost(path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Post{}, err
+	}
+	aead := r.diskQueue.aead
+	if aead == nil || len(data) < aead.NonceSize() {
+		log.Printf("crashreport: skipping %s, it can't be decrypted", filepath.Base(path))
+		return Post{}, errUndecryptable
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		log.Printf("crashreport: skipping %s, it can't be decrypted", filepath.Base(path))
+		return Post{}, errUndecryptable
+	}
+	return decodePost(bytes.NewReader(plain), strings.HasSuffix(path, queueExtGzip+queueExtEncrypted))
+}
+
+// queuedFiles returns the files of the disk queue, oldest first
+func (r *Reporter) queuedFiles() ([]string, error) {
+	entries, err := ioutil.ReadDir(r.diskQueue.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		plain := strings.TrimSuffix(name, queueExtEncrypted)
+		if strings.HasSuffix(plain, queueExt) || strings.HasSuffix(plain, queueExtGzip) {
+			files = append(files, filepath.Join(r.diskQueue.dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// DrainQueue submits the reports of the disk queue, oldest first, and deletes their files. It stops at the first
+// failed submission, leaving the remaining files for the next call. Files that can't be read are deleted. With
+// WithDiskQueueBatching, the reports are submitted in batches, and the files of a batch are only deleted once the
+// batch is accepted. With WithRetryBudget, it also stops when the budget is exhausted.
+func (r *Reporter) DrainQueue() error {
+	if r.diskQueue.dir == "" {
+		return nil
+	}
+
+	r.diskQueue.mu.Lock()
+	defer r.diskQueue.mu.Unlock()
+
+	files, err := r.queuedFiles()
+	if err != nil {
+		return errors.Wrapf(err, "read queue dir")
+	}
+	if r.diskQueue.batched {
+		return r.drainBatches(files)
+	}
+
+	for _, file := range files {
+		post, err := r.readQueued(file)
+		if err == errUndecryptable {
+			continue
+		}
+		if err != nil {
+			os.Remove(file)
+			continue
+		}
+
+		key := r.keyFor(post)
+		if key != "" {
+			if !r.retryBudget.retry() {
+				return ErrRetryBudgetExhausted
+			}
+			release, err := r.acquireSubmit(r.ctx)
+			if err != nil {
+				return err
+			}
+			err = r.redactProxy(SubmitContextToUrl(r.ctx, post, r.endpoint+"/entries", key, r.clientFor(key)))
+			release()
+			if err != nil {
+				return err
+			}
+		}
+		os.Remove(file)
+	}
+
+	return nil
+}
+
+// drainBatches submits the reports of the files in batches, see WithDiskQueueBatching. A batch holds consecutive
+// reports with the same api key.
+func (r *Reporter) drainBatches(files []string) error {
+	// Without a client in the options, the batches are submitted with the client of the reporter for their key
+	opts := []BatchOption{WithBatchEndpoint(r.endpoint), WithBatchCompression(true), WithBatchInterval(0),
+		WithBatchClient(nil)}
+	sink := NewBatchSink(append(opts, r.diskQueue.batching...)...)
+	reporterClient := sink.client == nil
+
+	var pending *batch
+	var pendingFiles []string
+	flush := func() error {
+		if pending == nil {
+			return nil
+		}
+		if !r.retryBudget.retry() {
+			return ErrRetryBudgetExhausted
+		}
+		release, err := r.acquireSubmit(r.ctx)
+		if err != nil {
+			return err
+		}
+		if reporterClient {
+			sink.client = r.clientFor(pending.key)
+		}
+		err = r.redactProxy(sink.submit(r.ctx, pending))
+		release()
+		if err != nil {
+			return err
+		}
+		for _, file := range pendingFiles {
+			os.Remove(file)
+		}
+		pending, pendingFiles = nil, nil
+		return nil
+	}
+
+	for _, file := range files {
+		post, err := r.readQueued(file)
+		if err == errUndecryptable {
+			continue
+		}
+		if err != nil {
+			os.Remove(file)
+			continue
+		}
+		key := r.keyFor(post)
+		if key == "" {
+			os.Remove(file)
+			continue
+		}
+		data, err := marshalPost(post)
+		if err != nil {
+			os.Remove(file)
+			continue
+		}
+
+		if pending != nil && (pending.key != key || pending.size+1+len(data) > sink.maxBytes) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if pending == nil {
+			pending = &batch{key: key, size: len("[]")}
+		} else {
+			pending.size++ // comma
+		}
+		pending.posts = append(pending.posts, data)
+		pending.size += len(data)
+		pendingFiles = append(pendingFiles, file)
+		if len(pending.posts) >= sink.maxCount || pending.size >= sink.maxBytes {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
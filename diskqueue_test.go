@@ -0,0 +1,218 @@
+package crashreport
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDiskQueueCompression(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "crashreport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server.Reject(func(w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+
+	plain := NewReporter("key", WithDiskQueue(dir))
+	compressed := NewReporter("key", WithDiskQueue(dir), WithDiskQueueCompression(true))
+	if err := plain.Report(errors.New("plain")); err == nil {
+		t.Fatal("the report should fail while the server is down")
+	}
+	if err := compressed.Report(errors.New("compressed")); err == nil {
+		t.Fatal("the report should fail while the server is down")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	sort.Strings(files)
+	if len(files) != 2 {
+		t.Fatalf("expected 2 queued files, got %v", files)
+	}
+	if !strings.HasSuffix(files[0], ".json") || !strings.HasSuffix(files[1], ".json.gz") {
+		t.Errorf("the extension should reflect the compression, got %v", files)
+	}
+
+	server.Reject(nil)
+	if err := compressed.DrainQueue(); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 2 || sent[0].Details.Error.Message != "plain" || sent[1].Details.Error.Message != "compressed" {
+		t.Errorf("both files should be replayed in order, got %d posts", len(sent))
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("replayed files should be deleted, got %v", files)
+	}
+}
+
+func TestDrainQueueEndpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crashreport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var down, hits int32 = 1, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&down) == 1 || req.URL.Path != "/entries" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	reporter := NewReporter("key", WithEndpoint(server.URL), WithDiskQueue(dir))
+	if err := reporter.Report(errors.New("queued")); err == nil {
+		t.Fatal("the report should fail while the server is down")
+	}
+	atomic.StoreInt32(&down, 0)
+	if err := reporter.DrainQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("expected the queued report to be replayed to the endpoint of the reporter, got %d hits", n)
+	}
+}
+
+func TestDiskQueueSkipsClientErrors(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "crashreport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server.Reject(func(w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusBadRequest)
+		return true
+	})
+
+	NewReporter("key", WithDiskQueue(dir)).Report(errors.New("invalid"))
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("reports rejected by Raygun should not be queued, got %v", files)
+	}
+}
+
+func TestDiskQueueBatching(t *testing.T) {
+	server := newBulkServer(t)
+	defer server.Close()
+
+	dir := t.TempDir()
+	reporter := NewReporter("key", WithEndpoint(server.URL), WithDiskQueue(dir),
+		WithDiskQueueBatching(WithBatchSize(4)))
+	for i := 0; i < 10; i++ {
+		post := NewPost()
+		post.Details.Error.Message = "queued"
+		if err := reporter.persist(post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server.rejectBatch(2)
+	if err := reporter.DrainQueue(); err == nil {
+		t.Error("expected the error of the rejected batch")
+	}
+	if counts := server.Counts(); len(counts) != 1 || counts[0] != 4 {
+		t.Errorf("expected the first batch to be accepted, got %v", counts)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 6 {
+		t.Errorf("expected the files of the rejected batch and after it to stay, got %d files", len(files))
+	}
+
+	if err := reporter.DrainQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if counts := server.Counts(); len(counts) != 3 || counts[1] != 4 || counts[2] != 2 {
+		t.Errorf("expected the remaining reports in 2 batches, got %v", counts)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("expected the queue to be empty, got %d files", len(files))
+	}
+	for i, gzipped := range server.gzipped {
+		if !gzipped {
+			t.Errorf("expected batch %d to be gzipped", i)
+		}
+	}
+}
+
+func TestDiskQueueEncryption(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "crashreport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server.Reject(func(w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+	key := []byte("0123456789abcdef0123456789abcdef")
+	encrypted := NewReporter("key", WithDiskQueue(dir), WithDiskQueueEncryption(key))
+	compressed := NewReporter("key", WithDiskQueue(dir), WithDiskQueueEncryption(key), WithDiskQueueCompression(true))
+	encrypted.Report(errors.New("card 4242 declined"))
+	compressed.Report(errors.New("card 5555 declined"))
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	sort.Strings(files)
+	if len(files) != 2 || !strings.HasSuffix(files[0], ".json.enc") || !strings.HasSuffix(files[1], ".json.gz.enc") {
+		t.Fatalf("expected 2 encrypted files, got %v", files)
+	}
+	for _, file := range files {
+		if data, _ := ioutil.ReadFile(file); strings.Contains(string(data), "declined") {
+			t.Errorf("expected %s to be encrypted, got %q", file, data)
+		}
+	}
+
+	server.Reject(nil)
+	wrongKey := NewReporter("key", WithDiskQueue(dir), WithDiskQueueEncryption([]byte("fedcba9876543210")))
+	if err := wrongKey.DrainQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewReporter("key", WithDiskQueue(dir)).DrainQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if sent := len(server.Posts()); sent != 0 {
+		t.Fatalf("expected nothing to be decrypted without the key, got %d posts", sent)
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, "*")); len(left) != 2 {
+		t.Fatalf("expected the undecryptable files to be kept, got %v", left)
+	}
+
+	if err := encrypted.DrainQueue(); err != nil {
+		t.Fatal(err)
+	}
+	sent := server.Posts()
+	if len(sent) != 2 || sent[0].Details.Error.Message != "card 4242 declined" ||
+		sent[1].Details.Error.Message != "card 5555 declined" {
+		t.Errorf("expected both reports to be decrypted and replayed, got %d posts", len(sent))
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, "*")); len(left) != 0 {
+		t.Errorf("replayed files should be deleted, got %v", left)
+	}
+
+	if err := NewReporter("key", WithDiskQueueEncryption([]byte("short"))).Report(errors.New("boom")); err == nil {
+		t.Error("expected an invalid key to fail the reports")
+	}
+}
@@ -0,0 +1,59 @@
+package crashreport
+
+import (
+	"strconv"
+)
+
+// DropReason tells why a report was not submitted, see WithDropObserver
+type DropReason int
+
+// Reasons of the dropped reports
+const (
+	DropSampled     DropReason = iota + 1 // by WithSampleRate or WithAdaptiveSampling
+	DropDeduped                           // merged into another occurrence by WithDebounce or WithAffectedUsers
+	DropRateLimited                       // over WithGlobalRateLimit, or too many reports kept during a cool-down
+	DropQueueFull                         // the queue of an asynchronous reporter is full
+	DropVetoed                            // the key router returned no key, or the endpoint resolver no endpoint
+	DropSuppressed                        // already submitted, with WithReportOncePerFingerprint or WithCrashLoopThrottle
+	DropFiltered                          // the environment isn't allowed by WithEnvironmentFilter
+	DropLowLevel                          // the level is below the one of WithMinLevel
+)
+
+func (d DropReason) String() string {
+	switch d {
+	case DropSampled:
+		return "sampled"
+	case DropDeduped:
+		return "deduped"
+	case DropRateLimited:
+		return "rate-limited"
+	case DropQueueFull:
+		return "queue-full"
+	case DropVetoed:
+		return "vetoed"
+	case DropSuppressed:
+		return "suppressed"
+	case DropFiltered:
+		return "filtered"
+	case DropLowLevel:
+		return "low-level"
+	default:
+		return "DropReason(" + strconv.Itoa(int(d)) + ")"
+	}
+}
+
+// WithDropObserver sets a function called with the reports that are dropped instead of submitted, and the reason, to
+// count or log them. Use Fingerprint to identify the errors. It's called synchronously, so it must be fast.
+func WithDropObserver(observer func(post Post, reason DropReason)) Option {
+	return func(r *Reporter) {
+		r.dropObserver = observer
+	}
+}
+
+// dropped notifies the observer that the post is dropped
+func (r *Reporter) dropped(post Post, reason DropReason) {
+	post.outcome.record(dropOutcome(reason), nil)
+	if r.dropObserver != nil {
+		safely("drop observer", func() { r.dropObserver(post, reason) })
+	}
+}
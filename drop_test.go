@@ -0,0 +1,91 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// dropRecorder counts the dropped reports by reason
+type dropRecorder struct {
+	mu      sync.Mutex
+	reasons map[DropReason]int
+}
+
+func (d *dropRecorder) observe(post Post, reason DropReason) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if post.Details.Error.Message == "" {
+		panic("the observer should receive the dropped post")
+	}
+	d.reasons[reason]++
+}
+
+func (d *dropRecorder) Count(reason DropReason) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.reasons[reason]
+}
+
+// blockingSink blocks every post until it's closed
+type blockingSink chan struct{}
+
+func (s blockingSink) Send(ctx context.Context, post Post, key string) error {
+	<-s
+	return nil
+}
+
+func TestWithDropObserver(t *testing.T) {
+	drops := &dropRecorder{reasons: map[DropReason]int{}}
+	sink := &countingSink{counts: map[string]int{}}
+	observe := WithDropObserver(drops.observe)
+
+	sampled := NewReporter("key", observe, WithSink(sink), WithAdaptiveSampling(1, time.Hour))
+	for i := 0; i < 50; i++ {
+		sampled.Report(errors.New("sampled"))
+	}
+	if n := drops.Count(DropSampled); n == 0 || n != 50-sink.counts["sampled"] {
+		t.Errorf("expected every sampled report to be observed, got %d", n)
+	}
+
+	deduped := NewReporter("key", observe, WithSink(sink), WithDebounce(time.Hour))
+	for i := 0; i < 3; i++ {
+		deduped.Report(errors.New("deduped"))
+	}
+	deduped.Close(time.Second)
+	if n := drops.Count(DropDeduped); n != 2 {
+		t.Errorf("expected 2 deduped reports, got %d", n)
+	}
+
+	limited := NewReporter("key", observe, WithSink(sink))
+	limited.startCooldown(time.Hour)
+	for i := 0; i < maxPending+3; i++ {
+		limited.Report(errors.New("limited"))
+	}
+	if n := drops.Count(DropRateLimited); n != 3 {
+		t.Errorf("expected 3 rate-limited reports, got %d", n)
+	}
+
+	block := make(blockingSink)
+	full := NewReporter("key", observe, WithSink(block), WithAsync(1, 1))
+	for i := 0; i < 5; i++ {
+		full.Report(errors.New("full"))
+	}
+	close(block)
+	full.Close(time.Second)
+	if n := drops.Count(DropQueueFull); n < 3 {
+		t.Errorf("expected at least 3 reports dropped by the full queue, got %d", n)
+	}
+
+	vetoed := NewReporter("key", observe, WithSink(sink), WithKeyRouter(func(Post) string { return "" }))
+	vetoed.Report(errors.New("vetoed"))
+	if n := drops.Count(DropVetoed); n != 1 {
+		t.Errorf("expected 1 vetoed report, got %d", n)
+	}
+
+	if DropQueueFull.String() != "queue-full" || DropReason(42).String() != "DropReason(42)" {
+		t.Error("unexpected names of the drop reasons")
+	}
+}
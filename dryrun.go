@@ -0,0 +1,38 @@
+package crashreport
+
+import (
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// WithDryRun runs the whole pipeline of the reports except the submission when enabled: the body of each post, in json
+// or in the encoding of WithCodec, is passed to inspect instead of being sent, and counted in Stats().DryRun. It's
+// meant to check the payloads and the volume of a new integration in production, before enabling it:
+//
+//	crashreport.WithDryRun(os.Getenv("RAYGUN_DRY_RUN") != "", func(body []byte) { log.Printf("raygun: %s", body) })
+//
+// inspect may be nil to only count the reports.
+func WithDryRun(enabled bool, inspect func(body []byte)) Option {
+	return func(r *Reporter) {
+		r.dryRun = enabled
+		r.inspect = inspect
+	}
+}
+
+// dryRunSubmit encodes the post like a submission, and passes it to the inspect function
+func (r *Reporter) dryRunSubmit(post Post) error {
+	codec := r.codec
+	if codec == nil {
+		codec = JSONCodec
+	}
+	body, err := encode(codec, post)
+	if err != nil {
+		return errors.Wrap(err, "encode post")
+	}
+	atomic.AddInt64(&r.stats.dryRun, 1)
+	if r.inspect != nil {
+		safely("dry run inspector", func() { r.inspect(body) })
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestWithDryRun(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	var bodies [][]byte
+	reporter := NewReporter("key",
+		WithDryRun(true, func(body []byte) { bodies = append(bodies, body) }),
+		WithMessageTransform(func(msg string) string { return "transformed " + msg }),
+	)
+	reporter.Report(errors.New("first"), WithTags("dry"))
+	reporter.CaptureMessage("second")
+
+	if attempts := server.Attempts(); attempts != 0 {
+		t.Errorf("no request should be made in dry run, got %d", attempts)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 bodies, got %d", len(bodies))
+	}
+	var post Post
+	if err := json.Unmarshal(bodies[0], &post); err != nil {
+		t.Fatalf("the body should be valid json: %s", err)
+	}
+	if post.Details.Error.Message != "transformed first" || post.Details.Tags[0] != "dry" {
+		t.Errorf("the body should be the prepared post, got %+v", post.Details)
+	}
+	if n := reporter.Stats().DryRun; n != 2 {
+		t.Errorf("expected 2 dry run reports, got %d", n)
+	}
+
+	NewReporter("key", WithDryRun(false, nil)).Report(errors.New("sent"))
+	if attempts := server.Attempts(); attempts != 1 {
+		t.Errorf("a disabled dry run should submit, got %d attempts", attempts)
+	}
+}
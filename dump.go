@@ -0,0 +1,87 @@
+package crashreport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// dumpFrames is the number of frames of the stacktrace shown by Dump
+const dumpFrames = 10
+
+// Dump returns a human readable rendering of the important fields of the post, to check what a report holds while
+// integrating, or to log it. Only the fields that are set are shown, always in the same order:
+//
+//	ValidationError: invalid configuration
+//	occurred:    2020-05-17T10:30:15.123Z on web-1
+//	inner error: missing field
+//	tags:        checkout, severity:warning
+//	user:        ann
+//	request:     POST /orders/{id} (500)
+//	custom data: cart, level
+//	breadcrumbs: 2
+//	stacktrace:
+//		github.com/acme/shop/billing.Charge
+//			/src/billing/charge.go:42
+//
+// The stacktrace is cut to its top 10 frames. It's not meant to be parsed, use the json of the post for that.
+func (p Post) Dump() string {
+	var b strings.Builder
+	d := p.Details
+	title := d.Error.ClassName
+	if title != "" && d.Error.Message != "" {
+		title += ": "
+	}
+	b.WriteString(title + d.Error.Message + "\n")
+
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-13s%s\n", name+":", value)
+		}
+	}
+	occurred := p.OccuredOn
+	if d.MachineName != "" {
+		occurred += " on " + d.MachineName
+	}
+	field("occurred", occurred)
+	field("inner error", d.Error.InnerError)
+	field("version", d.Version)
+	field("tags", strings.Join(d.Tags, ", "))
+	field("user", d.User.Identifier)
+	field("context", d.Context.Identifier)
+	if d.Request.URL != "" {
+		request := strings.TrimSpace(d.Request.HTTPMethod + " " + d.Request.URL)
+		if d.Response.StatusCode != 0 {
+			request += fmt.Sprintf(" (%d)", d.Response.StatusCode)
+		}
+		field("request", request)
+	}
+	if data, ok := d.UserCustomData.(map[string]interface{}); ok {
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		field("custom data", strings.Join(keys, ", "))
+	}
+	if len(d.Breadcrumbs) > 0 {
+		field("breadcrumbs", fmt.Sprint(len(d.Breadcrumbs)))
+	}
+
+	if stack := d.Error.StackTrace; len(stack) > 0 {
+		b.WriteString("stacktrace:\n")
+		top := stack
+		if len(top) > dumpFrames {
+			top = top[:dumpFrames]
+		}
+		text, _ := top.MarshalText()
+		for _, line := range strings.SplitAfter(strings.TrimSuffix(string(text), "\n"), "\n") {
+			b.WriteString("\t" + line)
+		}
+		b.WriteString("\n")
+		if omitted := len(stack) - len(top); omitted > 0 {
+			fmt.Fprintf(&b, "\t... %d more frames\n", omitted)
+		}
+	}
+	return b.String()
+}
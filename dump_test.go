@@ -0,0 +1,42 @@
+package crashreport
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostDump(t *testing.T) {
+	post := NewPost()
+	post.Details.MachineName = "web-1"
+	post.Details.Error = Error{ClassName: "ValidationError", Message: "invalid configuration", InnerError: "missing field"}
+	for i := 0; i < 12; i++ {
+		post.Details.Error.StackTrace = append(post.Details.Error.StackTrace,
+			NewStackTraceElement("github.com/acme/shop/billing", "/src/billing/charge.go", "Charge", 42+i))
+	}
+	post.Details.Tags = []string{"checkout", "severity:warning"}
+	post.Details.User.Identifier = "ann"
+	post.Details.Request = Request{HTTPMethod: "POST", URL: "/orders/{id}"}
+	post.Details.Response.StatusCode = 500
+	post.SetCustomData("level", "warning")
+	post.SetCustomData("cart", 3)
+
+	dump := post.Dump()
+	for _, expected := range []string{
+		"ValidationError: invalid configuration\n",
+		"occurred:    " + post.OccuredOn + " on web-1\n",
+		"inner error: missing field\n",
+		"tags:        checkout, severity:warning\n",
+		"user:        ann\n",
+		"request:     POST /orders/{id} (500)\n",
+		"custom data: cart, level\n",
+		"stacktrace:\n\tgithub.com/acme/shop/billing.Charge\n\t\t/src/billing/charge.go:42\n",
+		"\t\t/src/billing/charge.go:51\n\t... 2 more frames\n",
+	} {
+		if !strings.Contains(dump, expected) {
+			t.Errorf("expected %q in the dump:\n%s", expected, dump)
+		}
+	}
+	if strings.Contains(dump, "context:") || strings.Contains(dump, "charge.go:52") {
+		t.Errorf("expected only the fields set and the top frames:\n%s", dump)
+	}
+}
@@ -0,0 +1,37 @@
+package crashreport
+
+import (
+	"log"
+	"net/url"
+	"strings"
+)
+
+// WithEndpointResolver sets a function choosing the endpoint of the raygun api of each report, for example from the
+// tag of its tenant, to send the reports of the tenants to different hosts, as WithKeyRouter does for the api keys.
+// The function returns a base url such as "https://raygun.eu.example.com", or the endpoint of the reporter. An empty
+// string drops the report, including the ones of Fatal and ReportShutdown, and so does an invalid url, which is
+// logged. The default clients are cached by host.
+//
+// The reports persisted by WithDiskQueue are replayed to the endpoint of the reporter.
+func WithEndpointResolver(resolver func(Post) string) Option {
+	return func(r *Reporter) {
+		r.endpointResolver = resolver
+	}
+}
+
+// resolveEndpoint sets the endpoint of the post with the resolver. It returns false if the post must be dropped.
+func (r *Reporter) resolveEndpoint(post *Post) bool {
+	endpoint := ""
+	safely("endpoint resolver", func() { endpoint = r.endpointResolver(*post) })
+	if endpoint == "" {
+		return false
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Printf("crashreport: dropping a report for the invalid endpoint %q", endpoint)
+		return false
+	}
+	post.endpoint = strings.TrimSuffix(endpoint, "/")
+	post.endpointHost = u.Host
+	return true
+}
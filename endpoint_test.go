@@ -0,0 +1,63 @@
+package crashreport
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestWithEndpointResolver(t *testing.T) {
+	eu := mockRaygun(t)
+	defer eu.Close()
+	us := mockRaygun(t)
+	defer us.Close()
+
+	var dropped int
+	reporter := NewReporter("key", WithEndpointResolver(func(post Post) string {
+		switch {
+		case hasTag(post.Details.Tags, "tenant:eu"):
+			return eu.URL + "/"
+		case hasTag(post.Details.Tags, "tenant:us"):
+			return us.URL
+		case hasTag(post.Details.Tags, "tenant:invalid"):
+			return "ftp://raygun.example.com"
+		default:
+			return ""
+		}
+	}), WithDropObserver(func(Post, DropReason) { dropped++ }))
+
+	for _, tenant := range []string{"eu", "us", "eu", "invalid", "unknown"} {
+		reporter.Report(errors.New("failure of "+tenant), WithTags("tenant:"+tenant))
+	}
+
+	if sent := eu.Posts(); len(sent) != 2 || !hasTag(sent[0].Details.Tags, "tenant:eu") {
+		t.Errorf("expected the 2 reports of the eu tenant at its endpoint, got %d posts", len(sent))
+	}
+	if sent := us.Posts(); len(sent) != 1 || !hasTag(sent[0].Details.Tags, "tenant:us") {
+		t.Errorf("expected the report of the us tenant at its endpoint, got %d posts", len(sent))
+	}
+	if dropped != 2 {
+		t.Errorf("expected the reports without a valid endpoint to be dropped, got %d", dropped)
+	}
+	if n := len(reporter.clients); n != 2 {
+		t.Errorf("expected a client per host, got %d", n)
+	}
+}
+
+func TestEndpointResolverShutdownAndFatal(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func() { exit = os.Exit }()
+	exit = func(int) {}
+
+	var dropped []DropReason
+	reporter := NewReporter("key", WithEndpointResolver(func(Post) string { return "" }),
+		WithDropObserver(func(post Post, reason DropReason) { dropped = append(dropped, reason) }))
+	if err := reporter.ReportShutdown("received terminated"); err != nil {
+		t.Fatal(err)
+	}
+	reporter.Fatal(errors.New("cannot start"))
+	if sent := server.Posts(); len(sent) != 0 || len(dropped) != 2 || dropped[1] != DropVetoed {
+		t.Errorf("expected the shutdown and fatal reports to be dropped, got %d posts and %v", len(sent), dropped)
+	}
+}
@@ -0,0 +1,99 @@
+package crashreport
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// WithEnvironment is WithEnvironmentName.
+//
+// Deprecated: use WithEnvironmentName. The tag is "environment:<name>", not the bare name anymore.
+func WithEnvironment(name string) Option {
+	return WithEnvironmentName(name)
+}
+
+// environmentVar is the environment variable naming the environment, see WithEnvironmentName
+const environmentVar = "RAYGUN_ENVIRONMENT"
+
+// WithEnvironmentName adds an "environment:<name>" tag to every report, to tell the environments apart in Raygun.
+// An empty name is read from the RAYGUN_ENVIRONMENT environment variable, and adds no tag if it's not set either.
+func WithEnvironmentName(name string) Option {
+	return func(r *Reporter) {
+		if name == "" {
+			name = os.Getenv(environmentVar)
+		}
+		r.environmentName = name
+		if name != "" {
+			r.enrichers = append(r.enrichers, func(post *Post) {
+				post.Details.Tags = append(post.Details.Tags, "environment:"+name)
+			})
+		}
+	}
+}
+
+// WithEnvironmentFilter drops every report unless the environment of the reporter is one of allowed, so that a
+// misconfigured machine of staging can't report to the application of production. The environment is the name of
+// WithEnvironmentName, or the RAYGUN_ENVIRONMENT environment variable: a reporter without one drops its reports.
+// They are dropped with DropFiltered, see WithDropObserver, including the ones of Fatal and ReportShutdown.
+func WithEnvironmentFilter(allowed ...string) Option {
+	return func(r *Reporter) {
+		r.environmentFilter = map[string]bool{}
+		for _, name := range allowed {
+			r.environmentFilter[name] = true
+		}
+	}
+}
+
+// environment returns the name of the environment of the reporter, see WithEnvironmentFilter
+func (r *Reporter) environment() string {
+	if r.environmentName != "" {
+		return r.environmentName
+	}
+	return os.Getenv(environmentVar)
+}
+
+// NewReporterFromEnv creates a reporter configured by the environment variables:
+//
+//	RAYGUN_API_KEY      the api key, required unless disabled
+//	RAYGUN_ENDPOINT     the endpoint of the raygun api, see WithEndpoint
+//	RAYGUN_SAMPLE_RATE  the fraction of the reports to send, between 0 and 1, see WithSampleRate
+//	RAYGUN_ENVIRONMENT  the environment, see WithEnvironmentName
+//	RAYGUN_DISABLED     a boolean, true to send nothing, see WithDryRun
+//
+// The given options are applied after the ones of the environment, so they take precedence. It returns an error if
+// the key is missing or a value is malformed.
+func NewReporterFromEnv(opts ...Option) (*Reporter, error) {
+	var envOpts []Option
+
+	disabled := false
+	if v := os.Getenv("RAYGUN_DISABLED"); v != "" {
+		var err error
+		if disabled, err = strconv.ParseBool(v); err != nil {
+			return nil, errors.Errorf("crashreport: invalid RAYGUN_DISABLED %q", v)
+		}
+		envOpts = append(envOpts, WithDryRun(disabled, nil))
+	}
+
+	key := os.Getenv("RAYGUN_API_KEY")
+	if key == "" && !disabled {
+		return nil, errors.New("crashreport: RAYGUN_API_KEY is not set")
+	}
+
+	if v := os.Getenv("RAYGUN_ENDPOINT"); v != "" {
+		envOpts = append(envOpts, WithEndpoint(v))
+	}
+	if v := os.Getenv("RAYGUN_SAMPLE_RATE"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, errors.Errorf("crashreport: invalid RAYGUN_SAMPLE_RATE %q, expected a number between 0 and 1", v)
+		}
+		envOpts = append(envOpts, WithSampleRate(rate))
+	}
+	if v := os.Getenv(environmentVar); v != "" {
+		envOpts = append(envOpts, WithEnvironmentName(v))
+	}
+
+	return NewReporter(key, append(envOpts, opts...)...), nil
+}
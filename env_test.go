@@ -0,0 +1,141 @@
+package crashreport
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewReporterFromEnv(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	t.Setenv("RAYGUN_API_KEY", "env-key")
+	t.Setenv("RAYGUN_ENDPOINT", server.URL+"/")
+	t.Setenv("RAYGUN_SAMPLE_RATE", "1")
+	t.Setenv("RAYGUN_ENVIRONMENT", "staging")
+	Endpoint = "http://unused.invalid"
+
+	reporter, err := NewReporterFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter.Report(errors.New("configured"))
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post at the endpoint of the environment, got %d", len(sent))
+	}
+	if key := server.Keys()[0]; key != "env-key" {
+		t.Errorf("expected the key of the environment, got %s", key)
+	}
+	if tags := sent[0].Details.Tags; len(tags) != 1 || tags[0] != "environment:staging" {
+		t.Errorf("expected the environment tag, got %v", tags)
+	}
+
+	var inspected int
+	reporter, err = NewReporterFromEnv(WithSampleRate(0), WithDryRun(true, func([]byte) { inspected++ }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter.Report(errors.New("overridden"))
+	if stats := reporter.Stats(); stats.Sampled != 1 || inspected != 0 {
+		t.Errorf("the explicit options should override the environment, got %+v", stats)
+	}
+
+	t.Setenv("RAYGUN_DISABLED", "true")
+	reporter, err = NewReporterFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporter.Report(errors.New("disabled"))
+	if len(server.Posts()) != 1 || reporter.Stats().DryRun != 1 {
+		t.Error("a disabled reporter should not send anything")
+	}
+}
+
+func TestNewReporterFromEnvErrors(t *testing.T) {
+	t.Setenv("RAYGUN_API_KEY", "")
+	if _, err := NewReporterFromEnv(); err == nil || !strings.Contains(err.Error(), "RAYGUN_API_KEY") {
+		t.Errorf("expected an error for the missing key, got %v", err)
+	}
+
+	t.Setenv("RAYGUN_API_KEY", "key")
+	for _, rate := range []string{"half", "1.5", "-0.1"} {
+		t.Setenv("RAYGUN_SAMPLE_RATE", rate)
+		if _, err := NewReporterFromEnv(); err == nil || !strings.Contains(err.Error(), "RAYGUN_SAMPLE_RATE") {
+			t.Errorf("expected an error for the sample rate %q, got %v", rate, err)
+		}
+	}
+
+	t.Setenv("RAYGUN_SAMPLE_RATE", "")
+	t.Setenv("RAYGUN_DISABLED", "maybe")
+	if _, err := NewReporterFromEnv(); err == nil || !strings.Contains(err.Error(), "RAYGUN_DISABLED") {
+		t.Errorf("expected an error for a malformed boolean, got %v", err)
+	}
+}
+
+func TestEnvironmentName(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	t.Setenv("RAYGUN_ENVIRONMENT", "staging")
+
+	var dropped []DropReason
+	observer := WithDropObserver(func(post Post, reason DropReason) { dropped = append(dropped, reason) })
+	tests := []struct {
+		name    string
+		opts    []Option
+		tag     string
+		allowed bool
+	}{
+		{"named", []Option{WithEnvironmentName("production")}, "environment:production", true},
+		{"default", []Option{WithEnvironmentName("")}, "environment:staging", true},
+		{"allowed", []Option{WithEnvironmentName("production"), WithEnvironmentFilter("production")},
+			"environment:production", true},
+		{"denied", []Option{WithEnvironmentName("staging"), WithEnvironmentFilter("production")}, "", false},
+		{"denied by default", []Option{WithEnvironmentFilter("production")}, "", false},
+		{"allowed by default", []Option{WithEnvironmentFilter("production", "staging")}, "", true},
+		{"deprecated", []Option{WithEnvironment("production"), WithEnvironmentFilter("production")},
+			"environment:production", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := len(server.Posts())
+			dropped = nil
+			reporter := NewReporter("key", append(test.opts, observer)...)
+			reporter.Report(errors.New("failure"))
+
+			sent := server.Posts()[before:]
+			if !test.allowed {
+				if len(sent) != 0 || len(dropped) != 1 || dropped[0] != DropFiltered {
+					t.Errorf("expected the report to be filtered, got %d posts and %v", len(sent), dropped)
+				}
+				return
+			}
+			if len(sent) != 1 {
+				t.Fatalf("expected 1 post, got %d", len(sent))
+			}
+			if test.tag != "" && !hasTag(sent[0].Details.Tags, test.tag) {
+				t.Errorf("expected the %s tag, got %v", test.tag, sent[0].Details.Tags)
+			}
+		})
+	}
+}
+
+func TestEnvironmentFilterShutdownAndFatal(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func() { exit = os.Exit }()
+	exit = func(int) {}
+	t.Setenv("RAYGUN_ENVIRONMENT", "staging")
+
+	reporter := NewReporter("key", WithEnvironmentFilter("production"))
+	if err := reporter.ReportShutdown("received terminated"); err != nil {
+		t.Fatal(err)
+	}
+	reporter.Fatal(errors.New("cannot start"))
+	if sent := server.Posts(); len(sent) != 0 {
+		t.Errorf("expected the shutdown and fatal reports of staging to be filtered, got %d posts", len(sent))
+	}
+}
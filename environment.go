@@ -0,0 +1,313 @@
+package crashreport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// environmentTimeout bounds the collection of the environment of the reports without a deadline
+const environmentTimeout = time.Second
+
+// deviceNameVar is the environment variable naming the device, such as the pod of a container
+const deviceNameVar = "POD_NAME"
+
+// unlimitedCgroupMemory is the smallest memory limit of cgroup v1 meaning no limit, the page-aligned max int64
+const unlimitedCgroupMemory = 1 << 62
+
+// cgroupRoot is where the cgroup filesystem is mounted, a variable for the tests
+var cgroupRoot = "/sys/fs/cgroup"
+
+// environmentCollector gathers a part of the environment, which may take time, and returns a function applying it
+type environmentCollector func() func(*Environment)
+
+// environmentCollectors are the collectors run by CollectEnvironmentCtx, a variable for the tests
+var environmentCollectors = []environmentCollector{collectMemory, collectCPU, collectDisk}
+
+// WithEnvironmentCollection fills the environment of the reports with the state of the machine: the total and
+// available memory, the model of the cpu and the free space of the root disk, see CollectEnvironmentCtx. The
+// collection takes half of the time left before the deadline of the report, set by the context given to ReportCtx,
+// and a second at most, so that the submission has time to happen: the report is then sent with what was collected
+// so far. In a container, the memory is the one of its cgroup, unless WithContainerAware(false) is set.
+//
+// When GOMAXPROCS exceeds the cpu quota of the cgroup, the reports are also tagged "gomaxprocs-mismatch", with the
+// values of both under the "gomaxprocs" and "cpuQuota" keys of the custom data: the go scheduler then runs more
+// threads than the quota allows, and the process is throttled, which causes unexpected timeouts.
+//
+// The settings of the build of the binary are under the "build" key of the custom data: the version of go, the
+// compiler, the os and the architecture, whether cgo and the race detector were enabled, and the build tags. The
+// reports are also tagged "race" and "cgo" when they were. Nothing is attached to the binaries without build info.
+func WithEnvironmentCollection() Option {
+	return func(r *Reporter) {
+		build := buildSettings()
+		r.enrichers = append(r.enrichers, func(post *Post) {
+			applyBuildSettings(post, build)
+			timeout := environmentTimeout
+			if !post.deadline.IsZero() && time.Until(post.deadline)/2 < timeout {
+				timeout = time.Until(post.deadline) / 2
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			collectEnvironment(ctx, &post.Details.Environment, !r.hostMemory)
+			if !r.hostMemory {
+				checkCPUQuota(post)
+			}
+		})
+	}
+}
+
+// WithContainerAware sets whether the environment collected by WithEnvironmentCollection has the memory limit and
+// usage of the cgroup of the container, as CollectEnvironment does, or the memory of the host. It's enabled by
+// default: in a container, such as a pod of kubernetes, the limit of the cgroup is what matters for the OOM kills.
+func WithContainerAware(enabled bool) Option {
+	return func(r *Reporter) {
+		r.hostMemory = !enabled
+	}
+}
+
+// WithEnvironmentFile merges the environment of a json file into the reports, for the deployments writing the
+// metadata of the machine when it's provisioned, such as its device name or its locale. The file has the fields of
+// Environment, all optional:
+//
+//	{"deviceName": "checkout-eu-1", "locale": "fr-FR", "packageVersion": "2.4.1"}
+//
+// It's read once, by the option. Its fields override the ones collected, by WithEnvironmentCollection or WithDeviceName
+// for example, and the others are kept. A missing or malformed file is logged and ignored.
+func WithEnvironmentFile(path string) Option {
+	return func(r *Reporter) {
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &Environment{})
+		}
+		if err != nil {
+			log.Printf("crashreport: ignoring the environment file: %v", err)
+			return
+		}
+		r.environmentFile = data
+	}
+}
+
+// applyEnvironmentFile overrides the environment of the post with the fields of the file of WithEnvironmentFile
+func (r *Reporter) applyEnvironmentFile(post *Post) {
+	json.Unmarshal(r.environmentFile, &post.Details.Environment)
+}
+
+// WithDeviceName sets the device name of the environment of the reports, a stable label for the machine in the
+// dashboard. An empty name falls back to the POD_NAME environment variable, set in the pods of kubernetes, then to the
+// hostname, as CollectEnvironment does.
+func WithDeviceName(name string) Option {
+	return func(r *Reporter) {
+		name := deviceName(name)
+		r.enrichers = append(r.enrichers, func(post *Post) {
+			post.Details.Environment.DeviceName = name
+		})
+	}
+}
+
+// deviceName returns the name if it's not empty, or the value of POD_NAME, or the hostname
+func deviceName(name string) string {
+	if name != "" {
+		return name
+	}
+	if name := os.Getenv(deviceNameVar); name != "" {
+		return name
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
+// CollectEnvironment returns the environment of the machine, see CollectEnvironmentCtx
+func CollectEnvironment() Environment {
+	return CollectEnvironmentCtx(context.Background())
+}
+
+// CollectEnvironmentCtx returns the environment of the machine: the number of cpus, the os and the architecture as
+// NewPost, the device name from the POD_NAME environment variable or the hostname, see WithDeviceName, and the total
+// and available memory, the model of the cpu and the free space of the root disk where they are available. The memory
+// and the cpu are read from /proc on linux. In a container whose memory is limited by a cgroup, v1 or v2, the total
+// memory is the limit and the available memory is what's left of it, see WithContainerAware.
+// The slow parts are collected concurrently, until the context is done: the environment is then returned with the
+// parts collected so far, and the others are left empty.
+func CollectEnvironmentCtx(ctx context.Context) Environment {
+	env := Environment{
+		ProcessorCount: runtime.NumCPU(),
+		OsVersion:      runtime.GOOS,
+		Architecture:   runtime.GOARCH,
+	}
+	collectEnvironment(ctx, &env, true)
+	return env
+}
+
+// collectEnvironment runs the collectors until the context is done, and sets what they collected in env. The other
+// fields of env are kept, and the device name is only set if it's empty. If containerAware, the memory of the
+// cgroup replaces the memory of the host.
+func collectEnvironment(ctx context.Context, env *Environment, containerAware bool) {
+	if env.DeviceName == "" {
+		env.DeviceName = deviceName("")
+	}
+	if containerAware {
+		if apply := collectCgroupMemory(); apply != nil {
+			defer apply(env)
+		}
+	}
+	results := make(chan func(*Environment), len(environmentCollectors))
+	for _, collect := range environmentCollectors {
+		go func(collect environmentCollector) {
+			results <- collect()
+		}(collect)
+	}
+	for range environmentCollectors {
+		select {
+		case apply := <-results:
+			if apply != nil {
+				apply(env)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// collectMemory reads the total and available memory from /proc/meminfo
+func collectMemory() func(*Environment) {
+	var total, available int64
+	scanProc("/proc/meminfo", func(key, value string) {
+		// Values are in kB, as in "MemTotal:       16316412 kB"
+		kb, err := strconv.ParseInt(strings.TrimSuffix(value, " kB"), 10, 64)
+		if err != nil {
+			return
+		}
+		switch key {
+		case "MemTotal":
+			total = kb * 1024
+		case "MemAvailable":
+			available = kb * 1024
+		}
+	})
+	if total == 0 {
+		return nil
+	}
+	return func(env *Environment) {
+		env.TotalPhysicalMemory = total
+		env.AvailablePhysicalMemory = available
+	}
+}
+
+// collectCgroupMemory reads the memory limit and usage of the cgroup of the process, v2 or v1. It returns nil outside
+// of a container, or if the memory of the cgroup isn't limited.
+func collectCgroupMemory() func(*Environment) {
+	limit, usage := "memory.max", "memory.current"
+	if _, err := os.Stat(filepath.Join(cgroupRoot, limit)); err != nil {
+		limit, usage = "memory/memory.limit_in_bytes", "memory/memory.usage_in_bytes"
+	}
+
+	total, ok := readCgroupValue(filepath.Join(cgroupRoot, limit))
+	if !ok || total >= unlimitedCgroupMemory {
+		return nil
+	}
+	used, _ := readCgroupValue(filepath.Join(cgroupRoot, usage))
+	available := total - used
+	if available < 0 {
+		available = 0
+	}
+	return func(env *Environment) {
+		env.TotalPhysicalMemory = total
+		env.AvailablePhysicalMemory = available
+	}
+}
+
+// readCgroupValue reads a number of bytes from a file of the cgroup filesystem, where "max" means no limit
+func readCgroupValue(path string) (int64, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(string(content))
+	if value == "max" {
+		return unlimitedCgroupMemory, true
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	return n, err == nil
+}
+
+// checkCPUQuota tags the post if GOMAXPROCS exceeds the cpu quota of the cgroup, see WithEnvironmentCollection
+func checkCPUQuota(post *Post) {
+	quota, ok := cgroupCPUQuota()
+	if !ok {
+		return
+	}
+	if procs := runtime.GOMAXPROCS(0); float64(procs) > math.Ceil(quota) {
+		post.Details.Tags = append(post.Details.Tags, "gomaxprocs-mismatch")
+		post.SetCustomData("gomaxprocs", procs)
+		post.SetCustomData("cpuQuota", quota)
+	}
+}
+
+// cgroupCPUQuota returns the number of cpus allowed by the cgroup of the process, v2 or v1, and false if it's not
+// limited
+func cgroupCPUQuota() (float64, bool) {
+	var quota, period string
+	if content, err := os.ReadFile(filepath.Join(cgroupRoot, "cpu.max")); err == nil {
+		// As in "200000 100000", or "max 100000" without limit
+		fields := strings.Fields(string(content))
+		if len(fields) != 2 {
+			return 0, false
+		}
+		quota, period = fields[0], fields[1]
+	} else {
+		q, errQuota := os.ReadFile(filepath.Join(cgroupRoot, "cpu/cpu.cfs_quota_us"))
+		p, errPeriod := os.ReadFile(filepath.Join(cgroupRoot, "cpu/cpu.cfs_period_us"))
+		if errQuota != nil || errPeriod != nil {
+			return 0, false
+		}
+		quota, period = strings.TrimSpace(string(q)), strings.TrimSpace(string(p))
+	}
+
+	q, errQuota := strconv.ParseFloat(quota, 64)
+	p, errPeriod := strconv.ParseFloat(period, 64)
+	if errQuota != nil || errPeriod != nil || q <= 0 || p <= 0 {
+		return 0, false
+	}
+	return q / p, true
+}
+
+// collectCPU reads the model of the cpu from /proc/cpuinfo
+func collectCPU() func(*Environment) {
+	var model string
+	scanProc("/proc/cpuinfo", func(key, value string) {
+		if key == "model name" && model == "" {
+			model = value
+		}
+	})
+	if model == "" {
+		return nil
+	}
+	return func(env *Environment) {
+		env.CPU = model
+	}
+}
+
+// scanProc calls fn with the keys and values of a file of /proc made of "key: value" lines
+func scanProc(path string, fn func(key, value string)) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if i := strings.IndexByte(scanner.Text(), ':'); i > 0 {
+			fn(strings.TrimSpace(scanner.Text()[:i]), strings.TrimSpace(scanner.Text()[i+1:]))
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd
+
+package crashreport
+
+// collectDisk doesn't know the free space of the disks on this platform
+func collectDisk() func(*Environment) {
+	return nil
+}
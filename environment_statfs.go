@@ -0,0 +1,19 @@
+//go:build linux || darwin || freebsd
+
+package crashreport
+
+import (
+	"syscall"
+)
+
+// collectDisk reads the free space of the root disk
+func collectDisk() func(*Environment) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs("/", &stat); err != nil {
+		return nil
+	}
+	free := int64(stat.Bavail) * int64(stat.Bsize)
+	return func(env *Environment) {
+		env.DiskSpaceFree = []int64{free}
+	}
+}
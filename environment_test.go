@@ -0,0 +1,284 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+func TestCollectEnvironmentCtx(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func(collectors []environmentCollector) { environmentCollectors = collectors }(environmentCollectors)
+
+	release := make(chan struct{})
+	defer close(release)
+	environmentCollectors = []environmentCollector{
+		func() func(*Environment) {
+			return func(env *Environment) { env.CPU = "fast cpu" }
+		},
+		func() func(*Environment) {
+			<-release
+			return func(env *Environment) { env.DiskSpaceFree = []int64{1} }
+		},
+	}
+
+	reporter := NewReporter("key", WithEnvironmentCollection())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := reporter.ReportCtx(ctx, errors.New("tight deadline")); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the collection should stop at the deadline, took %s", elapsed)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	env := sent[0].Details.Environment
+	if env.CPU != "fast cpu" || env.ProcessorCount == 0 {
+		t.Errorf("the collected environment should be sent, got %+v", env)
+	}
+	if env.DiskSpaceFree != nil {
+		t.Errorf("the slow collector should be skipped, got %v", env.DiskSpaceFree)
+	}
+}
+
+func TestCollectEnvironment(t *testing.T) {
+	env := CollectEnvironment()
+	if env.ProcessorCount == 0 || env.OsVersion == "" {
+		t.Errorf("expected the environment of the machine, got %+v", env)
+	}
+}
+
+func TestWithDeviceName(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	hostname, _ := os.Hostname()
+
+	t.Setenv(deviceNameVar, "")
+	if name := CollectEnvironment().DeviceName; name != hostname {
+		t.Errorf("expected the hostname by default, got %q", name)
+	}
+
+	t.Setenv(deviceNameVar, "checkout-7d9f8-xk2p4")
+	if name := CollectEnvironment().DeviceName; name != "checkout-7d9f8-xk2p4" {
+		t.Errorf("expected the name of the pod, got %q", name)
+	}
+
+	NewReporter("key", WithDeviceName("")).CaptureMessage("pod")
+	NewReporter("key", WithDeviceName("checkout-1"), WithEnvironmentCollection()).CaptureMessage("override")
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	if name := sent[0].Details.Environment.DeviceName; name != "checkout-7d9f8-xk2p4" {
+		t.Errorf("an empty name should fall back to the name of the pod, got %q", name)
+	}
+	if name := sent[1].Details.Environment.DeviceName; name != "checkout-1" {
+		t.Errorf("the configured name should take precedence, got %q", name)
+	}
+}
+
+func TestWithEnvironmentFile(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "environment.json")
+	err := os.WriteFile(path, []byte(`{"deviceName": "checkout-eu-1", "locale": "fr-FR", "osVersion": "alpine"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	malformed := filepath.Join(dir, "malformed.json")
+	if err := os.WriteFile(malformed, []byte(`{"deviceName": `), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	NewReporter("key", WithEnvironmentFile(path), WithDeviceName("checkout-1")).CaptureMessage("merged")
+	NewReporter("key", WithEnvironmentFile(malformed), WithDeviceName("checkout-1")).CaptureMessage("malformed")
+	NewReporter("key", WithEnvironmentFile(filepath.Join(dir, "missing.json"))).CaptureMessage("missing")
+
+	sent := server.Posts()
+	if len(sent) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(sent))
+	}
+	env := sent[0].Details.Environment
+	if env.DeviceName != "checkout-eu-1" || env.Locale != "fr-FR" || env.OsVersion != "alpine" {
+		t.Errorf("expected the fields of the file to override the collected ones, got %+v", env)
+	}
+	if env.ProcessorCount != runtime.NumCPU() || env.Architecture != runtime.GOARCH {
+		t.Errorf("expected the other fields to be kept, got %+v", env)
+	}
+	if name := sent[1].Details.Environment.DeviceName; name != "checkout-1" {
+		t.Errorf("a malformed file should be ignored, got %q", name)
+	}
+	if osVersion := sent[2].Details.Environment.OsVersion; osVersion != runtime.GOOS {
+		t.Errorf("a missing file should be ignored, got %q", osVersion)
+	}
+}
+
+func TestBuildSettings(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func() { readBuildInfo = debug.ReadBuildInfo }()
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{GoVersion: "go1.22.4", Settings: []debug.BuildSetting{
+			{Key: "-compiler", Value: "gc"},
+			{Key: "-race", Value: "true"},
+			{Key: "-tags", Value: "netgo,osusergo"},
+			{Key: "CGO_ENABLED", Value: "1"},
+			{Key: "GOARCH", Value: "amd64"},
+			{Key: "GOOS", Value: "linux"},
+			{Key: "vcs.revision", Value: "4f2a9c1"},
+		}}, true
+	}
+	NewReporter("key", WithEnvironmentCollection()).CaptureMessage("race")
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return nil, false }
+	NewReporter("key", WithEnvironmentCollection()).CaptureMessage("no build info")
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	build := sent[0].Details.UserCustomData.(map[string]interface{})["build"]
+	expected := map[string]interface{}{"goVersion": "go1.22.4", "compiler": "gc", "race": "true", "tags": "netgo,osusergo",
+		"cgo": "1", "goarch": "amd64", "goos": "linux"}
+	if !reflect.DeepEqual(build, expected) {
+		t.Errorf("expected the build settings, got %v", build)
+	}
+	if tags := sent[0].Details.Tags; !hasTag(tags, "race") || !hasTag(tags, "cgo") {
+		t.Errorf("expected the race and cgo tags, got %v", tags)
+	}
+	if data, ok := sent[1].Details.UserCustomData.(map[string]interface{}); ok && data["build"] != nil {
+		t.Errorf("expected no build settings without build info, got %v", data["build"])
+	}
+}
+
+func TestCollectCgroupMemory(t *testing.T) {
+	defer func(collectors []environmentCollector) { environmentCollectors = collectors }(environmentCollectors)
+	defer func(root string) { cgroupRoot = root }(cgroupRoot)
+
+	environmentCollectors = []environmentCollector{func() func(*Environment) {
+		return func(env *Environment) {
+			env.TotalPhysicalMemory, env.AvailablePhysicalMemory = 64<<30, 32<<30
+		}
+	}}
+	write := func(root, name, content string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v2 := t.TempDir()
+	write(v2, "memory.max", "536870912\n")
+	write(v2, "memory.current", "134217728\n")
+	v1 := t.TempDir()
+	write(v1, "memory/memory.limit_in_bytes", "268435456\n")
+	write(v1, "memory/memory.usage_in_bytes", "67108864\n")
+	unlimited := t.TempDir()
+	write(unlimited, "memory.max", "max\n")
+	write(unlimited, "memory.current", "134217728\n")
+
+	tests := []struct {
+		name             string
+		root             string
+		containerAware   bool
+		total, available int64
+	}{
+		{"v2", v2, true, 512 << 20, 384 << 20},
+		{"v1", v1, true, 256 << 20, 192 << 20},
+		{"unlimited", unlimited, true, 64 << 30, 32 << 30},
+		{"host", t.TempDir(), true, 64 << 30, 32 << 30},
+		{"disabled", v2, false, 64 << 30, 32 << 30},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cgroupRoot = test.root
+			var env Environment
+			collectEnvironment(context.Background(), &env, test.containerAware)
+			if env.TotalPhysicalMemory != test.total || env.AvailablePhysicalMemory != test.available {
+				t.Errorf("expected %d bytes available of %d, got %d of %d",
+					test.available, test.total, env.AvailablePhysicalMemory, env.TotalPhysicalMemory)
+			}
+		})
+	}
+}
+
+func TestCPUQuotaMismatch(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func(collectors []environmentCollector) { environmentCollectors = collectors }(environmentCollectors)
+	defer func(root string) { cgroupRoot = root }(cgroupRoot)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	environmentCollectors = nil
+
+	write := func(root, name, content string) string {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return root
+	}
+	v2 := write(t.TempDir(), "cpu.max", "150000 100000\n")
+	v1 := write(t.TempDir(), "cpu/cpu.cfs_quota_us", "200000\n")
+	write(v1, "cpu/cpu.cfs_period_us", "100000\n")
+	unlimited := write(t.TempDir(), "cpu.max", "max 100000\n")
+
+	tests := []struct {
+		name       string
+		root       string
+		gomaxprocs int
+		mismatch   bool
+	}{
+		{"v2 over quota", v2, 4, true},
+		{"v2 rounded up quota", v2, 2, false},
+		{"v1 over quota", v1, 3, true},
+		{"v1 within quota", v1, 2, false},
+		{"unlimited", unlimited, 8, false},
+		{"no cgroup", t.TempDir(), 8, false},
+	}
+	for _, test := range tests {
+		cgroupRoot = test.root
+		runtime.GOMAXPROCS(test.gomaxprocs)
+		NewReporter("key", WithEnvironmentCollection()).Report(errors.New(test.name))
+	}
+	cgroupRoot = v2
+	runtime.GOMAXPROCS(4)
+	NewReporter("key", WithEnvironmentCollection(), WithContainerAware(false)).Report(errors.New("not aware"))
+
+	sent := server.Posts()
+	if len(sent) != len(tests)+1 {
+		t.Fatalf("expected %d posts, got %d", len(tests)+1, len(sent))
+	}
+	for i, test := range tests {
+		if mismatch := hasTag(sent[i].Details.Tags, "gomaxprocs-mismatch"); mismatch != test.mismatch {
+			t.Errorf("%s: expected mismatch %v, got tags %v", test.name, test.mismatch, sent[i].Details.Tags)
+		}
+	}
+	data := sent[0].Details.UserCustomData.(map[string]interface{})
+	if data["gomaxprocs"] != float64(4) || data["cpuQuota"] != 1.5 {
+		t.Errorf("expected the values in the custom data, got %v", data)
+	}
+	if hasTag(sent[len(tests)].Details.Tags, "gomaxprocs-mismatch") {
+		t.Error("expected no check without container awareness")
+	}
+}
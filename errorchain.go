@@ -0,0 +1,48 @@
+package crashreport
+
+import (
+	"fmt"
+	"time"
+)
+
+// BreadcrumbsFromError returns a breadcrumb of category "error" per layer of the chain of wrapped errors, the root
+// cause first, to tell the story of the error in the breadcrumb trail. The chain is unwrapped with Unwrap or Cause,
+// and the layers that don't change the message, such as the ones only adding a stack trace, are skipped. The type of
+// each layer is in the custom data, under the "type" key.
+func BreadcrumbsFromError(err error) []Breadcrumb {
+	var layers []error
+	message := ""
+	for ; err != nil; err = unwrapOnce(err) {
+		if m := err.Error(); len(layers) == 0 || m != message {
+			layers = append(layers, err)
+			message = m
+		}
+	}
+
+	timestamp := millis(time.Now())
+	crumbs := make([]Breadcrumb, 0, len(layers))
+	for i := len(layers) - 1; i >= 0; i-- {
+		crumbs = append(crumbs, Breadcrumb{
+			Message:    layers[i].Error(),
+			Category:   "error",
+			CustomData: map[string]interface{}{"type": fmt.Sprintf("%T", layers[i])},
+			Timestamp:  timestamp,
+			Level:      BreadcrumbError,
+		})
+	}
+	return crumbs
+}
+
+// WithErrorChainBreadcrumbs appends the breadcrumbs of BreadcrumbsFromError to the reports of wrapped errors
+func WithErrorChainBreadcrumbs() Option {
+	return func(r *Reporter) {
+		r.enrichers = append(r.enrichers, func(post *Post) {
+			if post.err == nil {
+				return
+			}
+			if crumbs := BreadcrumbsFromError(post.err); len(crumbs) > 1 {
+				post.Details.Breadcrumbs = append(post.Details.Breadcrumbs, crumbs...)
+			}
+		})
+	}
+}
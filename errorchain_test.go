@@ -0,0 +1,47 @@
+package crashreport
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	pkerr "github.com/pkg/errors"
+)
+
+func TestWithErrorChainBreadcrumbs(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	root := errors.New("connection refused")
+	err := fmt.Errorf("handle request: %w", pkerr.Wrap(fmt.Errorf("query orders: %w", root), "load order"))
+
+	reporter := NewReporter("key", WithErrorChainBreadcrumbs())
+	reporter.Report(err)
+	reporter.Report(errors.New("not wrapped"))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+
+	expected := []struct{ message, kind string }{
+		{"connection refused", "*errors.errorString"},
+		{"query orders: connection refused", "*fmt.wrapError"},
+		{"load order: query orders: connection refused", "*errors.withStack"},
+		{"handle request: load order: query orders: connection refused", "*fmt.wrapError"},
+	}
+	crumbs := sent[0].Details.Breadcrumbs
+	if len(crumbs) != len(expected) {
+		t.Fatalf("expected a breadcrumb per layer, got %+v", crumbs)
+	}
+	for i, e := range expected {
+		kind := crumbs[i].CustomData.(map[string]interface{})["type"]
+		if crumbs[i].Message != e.message || kind != e.kind || crumbs[i].Category != "error" {
+			t.Errorf("breadcrumb %d: expected '%s' of type %s, got '%s' of type %v",
+				i, e.message, e.kind, crumbs[i].Message, kind)
+		}
+	}
+	if len(sent[1].Details.Breadcrumbs) != 0 {
+		t.Errorf("expected no breadcrumb for an error without chain, got %+v", sent[1].Details.Breadcrumbs)
+	}
+}
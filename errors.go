@@ -0,0 +1,94 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnauthorized is wrapped by the ResponseError returned when Raygun rejects the api key
+var ErrUnauthorized = errors.New("crashreport: invalid api key")
+
+// ErrQuotaExceeded is wrapped by the ResponseError returned when the monthly quota of the Raygun plan is exhausted
+var ErrQuotaExceeded = errors.New("crashreport: quota exceeded")
+
+// ResponseError is returned by Submit when Raygun doesn't accept the post. A 403 wraps either ErrQuotaExceeded or
+// ErrUnauthorized, depending on the body, to be tested with errors.Is.
+type ResponseError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	RetryAfter time.Duration // from the Retry-After header, zero if missing
+}
+
+func (e *ResponseError) Error() string {
+	return "unexpected answer '" + e.Status + "' from Raygun: " + e.Body
+}
+
+// Unwrap returns the reason of a 401 or 403: ErrQuotaExceeded or ErrUnauthorized
+func (e *ResponseError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		if strings.Contains(strings.ToLower(e.Body), "quota") {
+			return ErrQuotaExceeded
+		}
+		return ErrUnauthorized
+	default:
+		return nil
+	}
+}
+
+// Retryable tells if a failed submission may succeed if it's retried later. These errors are retryable:
+//
+//   - the ResponseErrors of status 5xx, 429 Too Many Requests and 408 Request Timeout
+//   - the network errors, including the timeouts of the http client: every net.Error, such as the ones of
+//     http.Client.Do
+//   - context.DeadlineExceeded, when the deadline of the submission expired
+//   - ErrQueueFull, since the queue of an asynchronous reporter empties as the reports are submitted, and
+//     ErrRateLimited
+//
+// Every other error is not: the other ResponseErrors, such as 400 for an invalid post, 401 and 403 (ErrUnauthorized,
+// ErrQuotaExceeded) or 413 Payload Too Large, context.Canceled, ErrClosed, and the errors of the json conversion or
+// of the configuration. The errors are matched with errors.Is and errors.As, so they may be wrapped.
+// It's the classifier of the disk queue: only the retryable errors are persisted.
+func Retryable(err error) bool {
+	var responseErr *ResponseError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrQueueFull), errors.Is(err, ErrRateLimited):
+		return true
+	case errors.As(err, &responseErr):
+		return responseErr.StatusCode >= 500 || responseErr.StatusCode == http.StatusTooManyRequests ||
+			responseErr.StatusCode == http.StatusRequestTimeout
+	case errors.As(err, &netErr):
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter parses the value of a Retry-After header, either in seconds or as an http date
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
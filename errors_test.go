@@ -0,0 +1,83 @@
+package crashreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pkerr "github.com/pkg/errors"
+)
+
+func TestRetryable(t *testing.T) {
+	response := func(status int, body string) error {
+		return &ResponseError{StatusCode: status, Status: http.StatusText(status), Body: body}
+	}
+	_, jsonErr := json.Marshal(math.Inf(1))
+
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"nil", nil, false},
+		{"500", response(500, ""), true},
+		{"502", response(502, ""), true},
+		{"503", response(503, ""), true},
+		{"429", response(429, ""), true},
+		{"408", response(408, ""), true},
+		{"400", response(400, "invalid payload"), false},
+		{"401", response(401, ""), false},
+		{"403 unauthorized", response(403, "invalid key"), false},
+		{"403 quota", response(403, "quota exceeded"), false},
+		{"413", response(413, "payload too large"), false},
+		{"wrapped 503", fmt.Errorf("submit: %w", response(503, "")), true},
+		{"deadline", context.DeadlineExceeded, true},
+		{"canceled", pkerr.Wrap(context.Canceled, "execute req"), false},
+		{"queue full", ErrQueueFull, true},
+		{"rate limited", ErrRateLimited, true},
+		{"closed", ErrClosed, false},
+		{"unauthorized", ErrUnauthorized, false},
+		{"quota", ErrQuotaExceeded, false},
+		{"json", pkerr.Wrap(jsonErr, "convert to json"), false},
+		{"dns", &net.DNSError{Err: "no such host", Name: "api.raygun.invalid"}, true},
+		{"other", fmt.Errorf("invalid proxy url"), false},
+	}
+	for _, test := range tests {
+		if retryable := Retryable(test.err); retryable != test.retryable {
+			t.Errorf("%s: expected retryable %v, got %v", test.name, test.retryable, retryable)
+		}
+	}
+}
+
+func TestRetryableSubmitErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	err := SubmitToUrl(NewPost(), url, "key", nil)
+	if err == nil || !Retryable(err) {
+		t.Errorf("a refused connection should be retryable, got %v", err)
+	}
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+	err = SubmitToUrl(NewPost(), server.URL, "key", &http.Client{Timeout: 10 * time.Millisecond})
+	if err == nil || !Retryable(err) {
+		t.Errorf("a timeout should be retryable, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = SubmitContextToUrl(ctx, NewPost(), server.URL, "key", nil)
+	if err == nil || Retryable(err) {
+		t.Errorf("a cancelled submission should not be retryable, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package crashreport
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// fatalTimeout bounds the submission of the report and the flush of the queue by Fatal
+const fatalTimeout = 5 * time.Second
+
+// exit is os.Exit, replaced in tests
+var exit = os.Exit
+
+// WithExitCode sets the exit code of Fatal and Fatalf, 1 by default
+func WithExitCode(code int) Option {
+	return func(r *Reporter) {
+		r.exitCode = code
+	}
+}
+
+// Fatal reports the error with the fatal level and exits, like log.Fatal. The report is submitted synchronously, even
+// by an asynchronous reporter, then the reporter is closed to submit the queued reports. Both are bounded by a 5s
+// timeout, so that a slow Raygun doesn't prevent the exit. The report isn't held by WithDebounce, WithAffectedUsers or
+// WithRollup, nor dropped by WithGlobalRateLimit, but it goes through the options and the filters like the others.
+func (r *Reporter) Fatal(err error, opts ...ReportOption) {
+	r.fatal(err, caller(0), opts...)
+}
+
+// Fatalf is like Fatal, with the error formatted by fmt.Errorf
+func (r *Reporter) Fatalf(format string, args ...interface{}) {
+	r.fatal(fmt.Errorf(format, args...), caller(0))
+}
+
+func (r *Reporter) fatal(err error, caller string, opts ...ReportOption) {
+	deadline := time.Now().Add(fatalTimeout)
+
+	post := r.newPost()
+	post.Details.Error = FromErr(err)
+	post.err = err
+	post.level = LevelFatal
+	post.deadline = deadline
+	post.synchronous = true
+	identify(&post, caller)
+	r.send(post, opts...)
+
+	r.Close(time.Until(deadline))
+	exit(r.exitCode)
+}
@@ -0,0 +1,101 @@
+package crashreport
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFatal(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func() { exit = os.Exit }()
+
+	var codes []int
+	var sentAtExit []int
+	exit = func(code int) {
+		codes = append(codes, code)
+		sentAtExit = append(sentAtExit, len(server.Posts()))
+	}
+
+	reporter := NewReporter("key", WithAsync(1, 10), WithDebounce(time.Hour), WithExitCode(3))
+	reporter.Report(errors.New("queued"))
+	reporter.Fatal(errors.New("cannot start"), WithTags("startup"))
+
+	NewReporter("key").Fatalf("cannot open %s", "config.yml")
+
+	if len(codes) != 2 || codes[0] != 3 || codes[1] != 1 {
+		t.Fatalf("expected exit codes [3 1], got %v", codes)
+	}
+	if sentAtExit[0] != 2 || sentAtExit[1] != 3 {
+		t.Errorf("the reports should be sent before exiting, got %v posts at exit", sentAtExit)
+	}
+
+	sent := server.Posts()
+	if sent[0].Details.Error.Message != "cannot start" {
+		t.Errorf("the fatal error should be submitted first, got %q", sent[0].Details.Error.Message)
+	}
+	if sent[1].Details.Error.Message != "queued" {
+		t.Errorf("the debounced report should be flushed, got %q", sent[1].Details.Error.Message)
+	}
+	if sent[2].Details.Error.Message != "cannot open config.yml" {
+		t.Errorf("unexpected message %q", sent[2].Details.Error.Message)
+	}
+	for _, post := range []Post{sent[0], sent[2]} {
+		if tags := post.Details.Tags; len(tags) == 0 || tags[len(tags)-1] != "severity:fatal" {
+			t.Errorf("expected the fatal level, got tags %v", tags)
+		}
+	}
+}
+
+func TestFatalPipeline(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func() { exit = os.Exit }()
+	exit = func(int) {}
+
+	reporter := NewReporter("key", WithAsync(1, 10))
+	session := reporter.StartSession()
+	reporter.With(WithTags("worker")).Fatal(errors.New("cannot start"), func(*Post) { panic("bug in an option") })
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected the fatal report despite the panicking option, got %d posts", len(sent))
+	}
+	if tags := sent[0].Details.Tags; !hasTag(tags, "worker") || !hasTag(tags, "session:"+session) {
+		t.Errorf("expected the tags of the defaults and of the session, got %v", tags)
+	}
+}
+
+func TestFatalNotHeld(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func() { exit = os.Exit }()
+
+	var sentAtExit int
+	exit = func(int) { sentAtExit = len(server.Posts()) }
+
+	reporter := NewReporter("key", WithAsync(1, 10), WithDebounce(time.Hour), WithAffectedUsers(time.Hour),
+		WithGlobalRateLimit(1))
+	reporter.Report(errors.New("held"))
+	if err := reporter.ReportShutdown("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := server.Posts(); len(sent) != 1 || sent[0].Details.Error.Message != "shutdown: deploy" {
+		t.Fatalf("the shutdown report should be submitted before ReportShutdown returns, got %d posts", len(sent))
+	}
+
+	reporter.Fatal(errors.New("cannot start"))
+	if sentAtExit != 3 {
+		t.Fatalf("expected the held report and the 2 synchronous ones at exit, got %d posts", sentAtExit)
+	}
+	for _, post := range server.Posts()[:2] {
+		if data, _ := post.Details.UserCustomData.(map[string]interface{}); data["affectedUsers"] != nil {
+			t.Errorf("%q shouldn't be aggregated, got custom data %v", post.Details.Error.Message, data)
+		}
+	}
+	if sent := server.Posts(); sent[1].Details.Error.Message != "cannot start" {
+		t.Errorf("the fatal report should be submitted before the held ones, got %q", sent[1].Details.Error.Message)
+	}
+}
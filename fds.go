@@ -0,0 +1,36 @@
+package crashreport
+
+import (
+	"strconv"
+)
+
+// WithOpenFiles adds the number of file descriptors open by the process to the custom data of the reports, under the
+// "openFiles" key, and tags the reports with "fd-high" when the number is above threshold, as a leak of descriptors
+// often precedes a crash. A threshold of 0 disables the tag. The number is read from /proc/self/fd on Linux and from
+// /dev/fd on macOS, and it's skipped on the other platforms.
+func WithOpenFiles(threshold int) Option {
+	return func(r *Reporter) {
+		r.enrichers = append(r.enrichers, func(post *Post) {
+			n, ok := openFiles()
+			if !ok {
+				return
+			}
+			post.SetCustomData("openFiles", n)
+			if threshold > 0 && n > threshold && !hasTag(post.Details.Tags, "fd-high") {
+				post.Details.Tags = append(post.Details.Tags, "fd-high")
+			}
+		})
+	}
+}
+
+// fdCount returns the number of descriptors listed in the directory, whose names are the descriptors, not counting
+// the one used to read it
+func fdCount(names []string, self uintptr) int {
+	n := 0
+	for _, name := range names {
+		if fd, err := strconv.ParseUint(name, 10, 64); err == nil && uintptr(fd) != self {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,43 @@
+//go:build linux || darwin
+
+package crashreport
+
+import (
+	"os"
+	"testing"
+)
+
+func TestWithOpenFiles(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	before, ok := openFiles()
+	if !ok {
+		t.Fatal("expected the number of open files")
+	}
+	for i := 0; i < 5; i++ {
+		f, err := os.Open(os.Args[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+	}
+
+	NewReporter("key", WithOpenFiles(before+2)).CaptureMessage("leak")
+	NewReporter("key", WithOpenFiles(before+100)).CaptureMessage("leak")
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	data := sent[0].Details.UserCustomData.(map[string]interface{})
+	if n, _ := data["openFiles"].(float64); int(n) < before+5 {
+		t.Errorf("expected at least %d open files, got %v", before+5, data["openFiles"])
+	}
+	if !hasTag(sent[0].Details.Tags, "fd-high") {
+		t.Errorf("expected the fd-high tag over the threshold, got %v", sent[0].Details.Tags)
+	}
+	if hasTag(sent[1].Details.Tags, "fd-high") {
+		t.Errorf("unexpected fd-high tag under the threshold, got %v", sent[1].Details.Tags)
+	}
+}
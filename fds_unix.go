@@ -0,0 +1,26 @@
+//go:build linux || darwin
+
+package crashreport
+
+import (
+	"os"
+	"runtime"
+)
+
+// openFiles returns the number of file descriptors open by the process
+func openFiles() (int, bool) {
+	dir := "/proc/self/fd"
+	if runtime.GOOS == "darwin" {
+		dir = "/dev/fd"
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return 0, false
+	}
+	return fdCount(names, f.Fd()), true
+}
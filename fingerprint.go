@@ -0,0 +1,89 @@
+package crashreport
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+)
+
+// Fingerprint returns a stable hash identifying the error of a post, used to group and deduplicate identical errors.
+// It's computed from the error class, the message and the stack frames. Line numbers are ignored, so that the
+// fingerprint survives small edits of the code around the error.
+func Fingerprint(post Post) string {
+	h := sha1.New()
+	e := post.Details.Error
+	io.WriteString(h, e.ClassName+"\n")
+	io.WriteString(h, e.Message+"\n")
+	for _, line := range e.StackTrace {
+		fmt.Fprintf(h, "%s.%s %s\n", line.PackageName, line.MethodName, line.FileName)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// defaultFingerprintFrames is the number of frames hashed by WithStackOnlyFingerprint by default
+const defaultFingerprintFrames = 5
+
+// WithStackOnlyFingerprint identifies the errors by the top k frames of the application in their stacktrace, ignoring
+// their message, so that "timeout after 5s" and "timeout after 3s" raised at the same place are grouped as the same
+// error. See StackFingerprint. The fingerprint replaces Fingerprint for the sampling, the debouncing and the
+// aggregation, and it's sent as the grouping key of the reports, so that Raygun groups them the same way.
+// k defaults to 5.
+func WithStackOnlyFingerprint(k int) Option {
+	return func(r *Reporter) {
+		if k <= 0 {
+			k = defaultFingerprintFrames
+		}
+		r.fingerprintFrames = k
+	}
+}
+
+// StackFingerprint returns a stable hash of the top k frames of the application in the stacktrace of the post, see
+// WithAppModule, or of the top k frames when none belongs to the application. Like Fingerprint, it ignores the line
+// numbers. It falls back to Fingerprint for the posts without stacktrace.
+func StackFingerprint(post Post, k int) string {
+	stack := post.Details.Error.StackTrace
+	var frames StackTrace
+	for _, frame := range stack {
+		if frame.InApp {
+			frames = append(frames, frame)
+		}
+	}
+	if len(frames) == 0 {
+		frames = stack
+	}
+	if len(frames) == 0 {
+		return Fingerprint(post)
+	}
+	if len(frames) > k {
+		frames = frames[:k]
+	}
+
+	h := sha1.New()
+	for _, line := range frames {
+		fmt.Fprintf(h, "%s.%s %s\n", line.PackageName, line.MethodName, line.FileName)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// fingerprint identifies the error of the post, see WithStackOnlyFingerprint
+func (r *Reporter) fingerprint(post Post) string {
+	if r.fingerprintFrames > 0 {
+		return StackFingerprint(post, r.fingerprintFrames)
+	}
+	return Fingerprint(post)
+}
+
+// RelateTo links the post to the report of another error, such as the original error of a re-raised one, by adding
+// the Fingerprint of the other post to the custom data, under the "related" key. A post can be related to several
+// others. The relations don't change the fingerprint of the post.
+func (p *Post) RelateTo(fingerprint string) {
+	data, _ := p.Details.UserCustomData.(map[string]interface{})
+	related, _ := data["related"].([]string)
+	for _, f := range related {
+		if f == fingerprint {
+			return
+		}
+	}
+	// Copied, since the slice may be shared with a clone of the post
+	p.SetCustomData("related", append(append([]string(nil), related...), fingerprint))
+}
@@ -0,0 +1,62 @@
+package crashreport
+
+import (
+	"log"
+	"time"
+)
+
+// flagTimeout bounds the call of the provider of WithFlagProvider
+const flagTimeout = 100 * time.Millisecond
+
+// WithFlagProvider adds the feature flags active when an error is reported, to correlate the crashes with the
+// combinations of flags: provider is called at report time, and the flags it returns are copied under the "flags" key
+// of the custom data. The tagged flags that are enabled are also added to the tags, as "flag:<name>", to filter the
+// dashboards by the most impactful ones. The provider is given 100ms at most, and half of the time left before the
+// deadline of the report: the report is sent without the flags if it takes longer or panics.
+func WithFlagProvider(provider func() map[string]bool, tagged ...string) Option {
+	return func(r *Reporter) {
+		r.enrichers = append(r.enrichers, func(post *Post) {
+			timeout := flagTimeout
+			if !post.deadline.IsZero() && time.Until(post.deadline)/2 < timeout {
+				timeout = time.Until(post.deadline) / 2
+			}
+			flags, ok := snapshotFlags(provider, timeout)
+			if !ok {
+				return
+			}
+			post.SetCustomData("flags", flags)
+			for _, name := range tagged {
+				if flags[name] {
+					post.Details.Tags = append(post.Details.Tags, "flag:"+name)
+				}
+			}
+		})
+	}
+}
+
+// snapshotFlags returns a copy of the flags of the provider, or false if it didn't return within the timeout
+func snapshotFlags(provider func() map[string]bool, timeout time.Duration) (map[string]bool, bool) {
+	result := make(chan map[string]bool, 1)
+	go func() {
+		var flags map[string]bool
+		if safely("flag provider", func() {
+			flags = map[string]bool{}
+			for name, enabled := range provider() {
+				flags[name] = enabled
+			}
+		}) {
+			result <- flags
+		}
+		close(result)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case flags, ok := <-result:
+		return flags, ok
+	case <-timer.C:
+		log.Printf("crashreport: the flag provider took more than %s, skipped", timeout)
+		return nil, false
+	}
+}
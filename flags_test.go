@@ -0,0 +1,45 @@
+package crashreport
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithFlagProvider(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	flags := map[string]bool{"new-checkout": true, "dark-mode": false, "fast-search": true}
+	reporter := NewReporter("key", WithFlagProvider(func() map[string]bool { return flags }, "new-checkout",
+		"dark-mode"))
+	reporter.Report(errors.New("failure"))
+
+	stuck := NewReporter("key", WithFlagProvider(func() map[string]bool {
+		time.Sleep(time.Second)
+		return flags
+	}))
+	stuck.Report(errors.New("slow provider"))
+	panicking := NewReporter("key", WithFlagProvider(func() map[string]bool { panic("boom") }))
+	panicking.Report(errors.New("panicking provider"))
+
+	sent := server.Posts()
+	if len(sent) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(sent))
+	}
+	details := sent[0].Details
+	expected := map[string]interface{}{"new-checkout": true, "dark-mode": false, "fast-search": true}
+	if data := details.UserCustomData.(map[string]interface{}); !reflect.DeepEqual(data["flags"], expected) {
+		t.Errorf("expected the flags %v, got %v", expected, data["flags"])
+	}
+	if !hasTag(details.Tags, "flag:new-checkout") || hasTag(details.Tags, "flag:dark-mode") ||
+		hasTag(details.Tags, "flag:fast-search") {
+		t.Errorf("expected the tag of the enabled tagged flag only, got %v", details.Tags)
+	}
+	for _, post := range sent[1:] {
+		if post.Details.UserCustomData != nil {
+			t.Errorf("%s: expected no flags, got %v", post.Details.Error.Message, post.Details.UserCustomData)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package crashreport
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// globalContext is the custom data of SetContext, added to every report
+type globalContext struct {
+	mu     sync.RWMutex
+	values map[string]interface{}
+	size   int32 // atomic, the number of values, so that the reports skip the lock when there is none
+}
+
+// SetContext sets a value added to the custom data of every report under the key, for the context of the process
+// that changes occasionally, such as the id of the deployment or the state of a feature flag. The custom data of a
+// report takes precedence over the context on key collision. The reporters created by With share the context. It's
+// safe to call while reports are generated.
+func (r *Reporter) SetContext(key string, value interface{}) {
+	r.globals.mu.Lock()
+	defer r.globals.mu.Unlock()
+	if r.globals.values == nil {
+		r.globals.values = map[string]interface{}{}
+	}
+	r.globals.values[key] = value
+	atomic.StoreInt32(&r.globals.size, int32(len(r.globals.values)))
+}
+
+// RemoveContext removes the value set with SetContext under the key
+func (r *Reporter) RemoveContext(key string) {
+	r.globals.mu.Lock()
+	defer r.globals.mu.Unlock()
+	delete(r.globals.values, key)
+	atomic.StoreInt32(&r.globals.size, int32(len(r.globals.values)))
+}
+
+// hasGlobals tells if values were set with SetContext
+func (r *Reporter) hasGlobals() bool {
+	return atomic.LoadInt32(&r.globals.size) > 0
+}
+
+// applyGlobals adds the values of SetContext to the custom data of the post, without overriding its keys
+func (r *Reporter) applyGlobals(post *Post) {
+	if !r.hasGlobals() {
+		return
+	}
+	r.globals.mu.RLock()
+	defer r.globals.mu.RUnlock()
+
+	for key, value := range r.globals.values {
+		if data, ok := post.Details.UserCustomData.(map[string]interface{}); ok {
+			if _, set := data[key]; set {
+				continue
+			}
+		}
+		post.SetCustomData(key, cloneValue(value))
+	}
+}
@@ -0,0 +1,59 @@
+package crashreport
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSetContext(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	reporter.SetContext("deployment", "d-42")
+	reporter.SetContext("region", "eu-west-1")
+	reporter.With(WithCustomData("region", "us-east-1")).Report(errors.New("overridden"))
+	reporter.RemoveContext("region")
+	reporter.Report(errors.New("removed"))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	data := sent[0].Details.UserCustomData.(map[string]interface{})
+	if data["deployment"] != "d-42" || data["region"] != "us-east-1" {
+		t.Errorf("expected the context with the custom data of the report first, got %v", data)
+	}
+	data = sent[1].Details.UserCustomData.(map[string]interface{})
+	if _, ok := data["region"]; ok || data["deployment"] != "d-42" {
+		t.Errorf("expected the removed key to be gone, got %v", data)
+	}
+}
+
+func TestSetContextConcurrent(t *testing.T) {
+	reporter := NewReporter("key", WithSink(discardSink{}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := "flag" + strconv.Itoa(j%5)
+				reporter.SetContext(key, map[string]interface{}{"enabled": j%2 == 0})
+				if j%3 == 0 {
+					reporter.RemoveContext(key)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				reporter.Report(errors.New("concurrent"), WithCustomData("attempt", j))
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,3 +1,19 @@
 module github.com/chennqqi/crashreport
 
-go 1.13
+go 1.25.0
+
+require (
+	github.com/pkg/errors v0.9.1
+	go.uber.org/zap v1.28.0
+	golang.org/x/sync v0.20.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260414002931-afd174a4e478
+	google.golang.org/grpc v1.82.1
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/net v0.53.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+)
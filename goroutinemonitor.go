@@ -0,0 +1,88 @@
+package crashreport
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// goroutineLeakRises is the number of consecutive rises of the goroutines over the threshold reported as a leak
+const goroutineLeakRises = 3
+
+// numGoroutine is runtime.NumGoroutine, a variable for the tests
+var numGoroutine = runtime.NumGoroutine
+
+// GoroutineMonitor reports the goroutine leaks, see NewGoroutineMonitor
+type GoroutineMonitor struct {
+	reporter  *Reporter
+	threshold int
+	stop      chan struct{}
+	stopOnce  sync.Once
+
+	mu       sync.Mutex
+	samples  []int // of the current rise, the first one before the rise
+	reported bool  // the current episode was reported
+}
+
+// NewGoroutineMonitor starts counting the goroutines every interval. When there are more than threshold goroutines and
+// their number rose at the last 3 counts, which tells a leak from a spike of activity, a warning is reported with
+// CaptureMessage, tagged "goroutine-leak", with the counts under the "goroutineCounts" key of the custom data and the
+// stacks of all the goroutines as in CapturePanicFull. A leak is reported once: the next report needs the number of
+// goroutines to go under the threshold first. Call Stop to stop the monitor.
+func NewGoroutineMonitor(reporter *Reporter, threshold int, interval time.Duration) *GoroutineMonitor {
+	m := &GoroutineMonitor{reporter: reporter, threshold: threshold, stop: make(chan struct{})}
+	go m.run(interval)
+	return m
+}
+
+// Stop stops the monitor
+func (m *GoroutineMonitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+func (m *GoroutineMonitor) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.sample()
+		case <-m.stop:
+			return
+		case <-m.reporter.ctx.Done():
+			return
+		}
+	}
+}
+
+// sample counts the goroutines, and reports a leak
+func (m *GoroutineMonitor) sample() {
+	n := numGoroutine()
+
+	m.mu.Lock()
+	switch {
+	case n <= m.threshold:
+		m.samples, m.reported = nil, false
+		m.mu.Unlock()
+		return
+	case len(m.samples) > 0 && n > m.samples[len(m.samples)-1]:
+		m.samples = append(m.samples, n)
+	default:
+		m.samples = []int{n}
+	}
+	if m.reported || len(m.samples) <= goroutineLeakRises {
+		m.mu.Unlock()
+		return
+	}
+	m.reported = true
+	counts := append([]int(nil), m.samples...)
+	m.mu.Unlock()
+
+	dump := goroutineDump()
+	m.reporter.CaptureMessage(fmt.Sprintf("possible goroutine leak: %d goroutines", n),
+		WithLevel(LevelWarning), WithIdentifier("crashreport.GoroutineMonitor"), WithTags("goroutine-leak"),
+		WithCustomData("goroutineCounts", counts), func(post *Post) {
+			setGoroutineDump(post, dump, m.reporter.goroutineDumpLimit)
+		})
+}
@@ -0,0 +1,51 @@
+package crashreport
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestGoroutineMonitor(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func() { numGoroutine = runtime.NumGoroutine }()
+
+	var count int
+	numGoroutine = func() int { return count }
+	monitor := NewGoroutineMonitor(NewReporter("key"), 100, time.Hour)
+	defer monitor.Stop()
+	sample := func(counts ...int) {
+		for _, c := range counts {
+			count = c
+			monitor.sample()
+		}
+	}
+
+	sample(50, 120, 300, 150, 90) // a spike
+	sample(110, 110, 120, 130)    // not rising enough yet
+	if n := len(server.Posts()); n != 0 {
+		t.Fatalf("no leak should be reported yet, got %d posts", n)
+	}
+	sample(140, 150, 160, 170) // a leak, reported once
+	sample(80, 120, 130, 140)  // a new episode
+	sample(150)
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected a report per leak, got %d posts", len(sent))
+	}
+	if sent[0].Details.Error.Message != "possible goroutine leak: 140 goroutines" {
+		t.Errorf("unexpected message %q", sent[0].Details.Error.Message)
+	}
+	if !hasTag(sent[0].Details.Tags, "goroutine-leak") || !hasTag(sent[0].Details.Tags, "severity:warning") {
+		t.Errorf("expected the goroutine-leak tag and the warning level, got %v", sent[0].Details.Tags)
+	}
+	data := sent[0].Details.UserCustomData.(map[string]interface{})
+	if counts, _ := data["goroutineCounts"].([]interface{}); len(counts) != 4 || counts[0] != float64(110) {
+		t.Errorf("expected the counts of the rise, got %v", data["goroutineCounts"])
+	}
+	if data["goroutines"] == nil && data["goroutinesGzip"] == nil {
+		t.Error("expected the goroutine dump")
+	}
+}
@@ -0,0 +1,146 @@
+package crashreport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"runtime/pprof"
+)
+
+// defaultGoroutineDumpLimit is the maximum size of the goroutine dump attached by CapturePanicFull
+const defaultGoroutineDumpLimit = 64 << 10
+
+// WithGoroutineDumpLimit sets the maximum size in bytes of the goroutine dump attached by CapturePanicFull. The
+// default is 64KiB.
+func WithGoroutineDumpLimit(n int) Option {
+	return func(r *Reporter) {
+		r.goroutineDumpLimit = n
+	}
+}
+
+// CapturePanicFull recovers a panic and reports it like the middleware does, attaching the stacks of all the
+// goroutines to the custom data under the "goroutines" key. It's meant for the panics that depend on other
+// goroutines, such as deadlocks, and must be deferred directly:
+//
+//	defer reporter.CapturePanicFull()
+//
+// The dump can be megabytes, so it's kept under the limit set with WithGoroutineDumpLimit: a bigger dump is gzipped
+// and encoded in base64 under the "goroutinesGzip" key instead. If it's still too big, the goroutines idling in the
+// runtime, such as the network pollers and the garbage collector workers, are left out first, and
+// "goroutinesTruncated" and "goroutinesOmitted", the number of goroutines left out, are set in the custom data.
+func (r *Reporter) CapturePanicFull(opts ...ReportOption) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	post := r.newPost()
+	post.Details.Error = FromPanic(v)
+	post.err = panicError(v)
+	post.panicked = true
+	setGoroutineDump(&post, goroutineDump(), r.goroutineDumpLimit)
+	r.send(post, opts...)
+}
+
+// goroutineDump returns the stacks of all the goroutines, in the format of an unrecovered panic
+func goroutineDump() []byte {
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 2)
+	return buf.Bytes()
+}
+
+// idleGoroutineStates are the states of the goroutines waiting in the runtime, which rarely explain a deadlock
+var idleGoroutineStates = map[string]bool{
+	"IO wait":                true,
+	"sleep":                  true,
+	"syscall":                true,
+	"finalizer wait":         true,
+	"force gc (idle)":        true,
+	"GC worker (idle)":       true,
+	"GC sweep wait":          true,
+	"GC scavenge wait":       true,
+	"timer goroutine (idle)": true,
+}
+
+// setGoroutineDump adds the dump to the custom data of the post, within limit bytes, see CapturePanicFull
+func setGoroutineDump(post *Post, dump []byte, limit int) {
+	if limit <= 0 {
+		limit = defaultGoroutineDumpLimit
+	}
+	if len(dump) <= limit {
+		post.SetCustomData("goroutines", string(dump))
+		return
+	}
+
+	goroutines := byRelevance(bytes.Split(bytes.TrimSpace(dump), []byte("\n\n")))
+
+	// Find the largest number of goroutines that fits, the compressed size growing with the number
+	var fitting string
+	kept, lo, hi := 0, 1, len(goroutines)
+	for lo <= hi {
+		n := (lo + hi) / 2
+		if encoded := gzipGoroutines(goroutines[:n], len(goroutines)-n); len(encoded) <= limit {
+			fitting, kept = encoded, n
+			lo = n + 1
+		} else {
+			hi = n - 1
+		}
+	}
+
+	if kept == 0 {
+		// Even the current goroutine doesn't fit
+		post.SetCustomData("goroutines", string(dump[:limit]))
+		post.SetCustomData("goroutinesTruncated", true)
+		return
+	}
+	post.SetCustomData("goroutinesGzip", fitting)
+	if omitted := len(goroutines) - kept; omitted > 0 {
+		post.SetCustomData("goroutinesTruncated", true)
+		post.SetCustomData("goroutinesOmitted", omitted)
+	}
+}
+
+// byRelevance sorts the goroutines of a dump, keeping the first one, the current goroutine, first and moving the
+// ones idling in the runtime last. The order of the dump is kept otherwise.
+func byRelevance(goroutines [][]byte) [][]byte {
+	sorted := make([][]byte, 0, len(goroutines))
+	var idle [][]byte
+	for i, g := range goroutines {
+		if i > 0 && idleGoroutineStates[goroutineState(g)] {
+			idle = append(idle, g)
+		} else {
+			sorted = append(sorted, g)
+		}
+	}
+	return append(sorted, idle...)
+}
+
+// goroutineState returns the state of a goroutine from its header, as in "goroutine 7 [IO wait, 5 minutes]:"
+func goroutineState(goroutine []byte) string {
+	start := bytes.IndexByte(goroutine, '[')
+	end := bytes.IndexByte(goroutine, ']')
+	if start < 0 || end < start {
+		return ""
+	}
+	state := goroutine[start+1 : end]
+	if i := bytes.IndexByte(state, ','); i >= 0 {
+		state = state[:i]
+	}
+	return string(state)
+}
+
+// gzipGoroutines returns the goroutines gzipped and encoded in base64, noting the number of omitted ones at the end
+func gzipGoroutines(goroutines [][]byte, omitted int) string {
+	var buf bytes.Buffer
+	encoder := base64.NewEncoder(base64.StdEncoding, &buf)
+	gz := getGzipWriter(encoder)
+	defer gzipPool.Put(gz)
+
+	gz.Write(bytes.Join(goroutines, []byte("\n\n")))
+	if omitted > 0 {
+		fmt.Fprintf(gz, "\n\n... %d goroutines omitted\n", omitted)
+	}
+	gz.Close()
+	encoder.Close()
+	return buf.String()
+}
@@ -0,0 +1,128 @@
+package crashreport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCapturePanicFull(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	for i := 0; i < 50; i++ {
+		go func() { <-stop }()
+	}
+
+	capture := func(reporter *Reporter) {
+		defer reporter.CapturePanicFull()
+		panic("deadlock")
+	}
+	capture(NewReporter("key"))
+	capture(NewReporter("key", WithGoroutineDumpLimit(1024)))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+
+	full := sent[0].Details.UserCustomData.(map[string]interface{})
+	if sent[0].Details.Error.Message != "panic: deadlock" {
+		t.Errorf("unexpected message %q", sent[0].Details.Error.Message)
+	}
+	dump, _ := full["goroutines"].(string)
+	if !strings.Contains(dump, "goroutine ") || !strings.Contains(dump, "TestCapturePanicFull") {
+		t.Errorf("expected the goroutine dump, got %q", dump)
+	}
+	if _, ok := full["goroutinesTruncated"]; ok {
+		t.Error("the default limit should not truncate the dump")
+	}
+
+	limited := sent[1].Details.UserCustomData.(map[string]interface{})
+	encoded, _ := limited["goroutinesGzip"].(string)
+	if len(encoded) == 0 || len(encoded) > 1024 {
+		t.Errorf("expected the dump to be compressed within 1024 bytes, got %d", len(encoded))
+	}
+	if dump := gunzipDump(t, encoded); !strings.Contains(dump, "TestCapturePanicFull") {
+		t.Errorf("expected the current goroutine in the compressed dump, got %q", dump)
+	}
+}
+
+// gunzipDump decodes a dump of the "goroutinesGzip" custom data
+func gunzipDump(t *testing.T, encoded string) string {
+	gz, err := gzip.NewReader(base64.NewDecoder(base64.StdEncoding, strings.NewReader(encoded)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(dump)
+}
+
+// syntheticDump writes a dump of the current goroutine followed by the given goroutines, whose frames are random
+// to resist compression
+func syntheticDump(states ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("goroutine 1 [running]:\nmain.deadlock()\n\t/src/main.go:10 +0x1d\n")
+	for i, state := range states {
+		fmt.Fprintf(&buf, "\ngoroutine %d [%s]:\n", i+2, state)
+		for j := 0; j < 5; j++ {
+			fmt.Fprintf(&buf, "main.worker%x()\n\t/src/worker%x.go:%d +0x%x\n", rand.Uint64(), rand.Uint32(), j, rand.Uint32())
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestSetGoroutineDump(t *testing.T) {
+	var states []string
+	for i := 0; i < 200; i++ {
+		states = append(states, "chan receive", "IO wait, 5 minutes")
+	}
+	dump := syntheticDump(states...)
+	limit := 16 << 10
+
+	var post Post
+	setGoroutineDump(&post, dump, limit)
+	data := post.Details.UserCustomData.(map[string]interface{})
+
+	if _, ok := data["goroutines"]; ok {
+		t.Error("the dump over the limit should not be attached as text")
+	}
+	encoded, _ := data["goroutinesGzip"].(string)
+	if len(encoded) == 0 || len(encoded) > limit {
+		t.Fatalf("expected the compressed dump within %d bytes, got %d", limit, len(encoded))
+	}
+	omitted, _ := data["goroutinesOmitted"].(int)
+	if omitted <= 200 || omitted >= 400 || data["goroutinesTruncated"] != true {
+		t.Errorf("the idle goroutines and some others should be omitted, got %d omitted", omitted)
+	}
+
+	text := gunzipDump(t, encoded)
+	if !strings.HasPrefix(text, "goroutine 1 [running]:") {
+		t.Error("the current goroutine should be kept first")
+	}
+	if strings.Contains(text, "IO wait") {
+		t.Error("the idle goroutines should be omitted first")
+	}
+	if kept := strings.Count(text, "[chan receive]"); kept != 400-omitted {
+		t.Errorf("expected %d goroutines blocked on channels, got %d", 400-omitted, kept)
+	}
+	if !strings.HasSuffix(text, fmt.Sprintf("... %d goroutines omitted\n", omitted)) {
+		t.Errorf("the dump should note the omitted goroutines, got %q", text[len(text)-50:])
+	}
+
+	var small Post
+	setGoroutineDump(&small, syntheticDump("chan receive", "select"), 1<<20)
+	if data := small.Details.UserCustomData.(map[string]interface{}); data["goroutines"] == nil {
+		t.Error("a dump under the limit should be attached as text")
+	}
+}
@@ -0,0 +1,148 @@
+// Package grpcreport provides gRPC server interceptors reporting the errors and the panics of the handlers to Raygun
+// with the crashreport package.
+package grpcreport
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/chennqqi/crashreport"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// defaultCodes are the codes reported by default: the ones of the failures of the server, not of the client
+var defaultCodes = []codes.Code{codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal,
+	codes.Unavailable, codes.DataLoss}
+
+// Option configures the interceptors
+type Option func(*interceptor)
+
+// WithReportedCodes sets the status codes of the errors that are reported, instead of the ones of the failures of the
+// server: Unknown, DeadlineExceeded, Unimplemented, Internal, Unavailable and DataLoss. An error that isn't a status
+// has the code Unknown.
+func WithReportedCodes(reported ...codes.Code) Option {
+	return func(i *interceptor) {
+		i.codes = map[codes.Code]bool{}
+		for _, code := range reported {
+			i.codes[code] = true
+		}
+	}
+}
+
+// WithReportOptions sets options applied to the reports of the interceptors, such as tags
+func WithReportOptions(opts ...crashreport.ReportOption) Option {
+	return func(i *interceptor) {
+		i.opts = append(i.opts, opts...)
+	}
+}
+
+type interceptor struct {
+	reporter *crashreport.Reporter
+	codes    map[codes.Code]bool
+	opts     []crashreport.ReportOption
+}
+
+func newInterceptor(reporter *crashreport.Reporter, opts []Option) *interceptor {
+	i := &interceptor{reporter: reporter}
+	WithReportedCodes(defaultCodes...)(i)
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// UnaryServerInterceptor returns an interceptor reporting the errors of the unary handlers with a reported code, see
+// WithReportedCodes, and their panics, which are answered with an Internal error:
+//
+//	server := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(grpcreport.UnaryServerInterceptor(reporter)),
+//		grpc.ChainStreamInterceptor(grpcreport.StreamServerInterceptor(reporter)),
+//	)
+//
+// The reports are tagged "grpc" and with the code, such as "grpc.code:Internal", their class is the name of the code
+// and their identifier the full name of the method. The details of the status, such as an errdetails.BadRequest, are
+// in Error.Data, see Details.
+func UnaryServerInterceptor(reporter *crashreport.Reporter, opts ...Option) grpc.UnaryServerInterceptor {
+	i := newInterceptor(reporter, opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer i.recover(info.FullMethod, &err)
+		resp, err = handler(ctx, req)
+		i.report(info.FullMethod, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for the streaming handlers
+func StreamServerInterceptor(reporter *crashreport.Reporter, opts ...Option) grpc.StreamServerInterceptor {
+	i := newInterceptor(reporter, opts)
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) (err error) {
+		defer i.recover(info.FullMethod, &err)
+		err = handler(srv, stream)
+		i.report(info.FullMethod, err)
+		return err
+	}
+}
+
+// recover reports the panic of the handler of the method, and answers it with an Internal error
+func (i *interceptor) recover(method string, err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	opts := append([]crashreport.ReportOption{crashreport.WithTags("grpc"), crashreport.WithIdentifier(method)},
+		i.opts...)
+	i.reporter.CapturePanic(v, opts...)
+	*err = status.Error(codes.Internal, "internal error")
+}
+
+// report reports the error of the handler of the method, if its code is reported
+func (i *interceptor) report(method string, err error) {
+	if err == nil {
+		return
+	}
+	st := status.Convert(err)
+	if !i.codes[st.Code()] {
+		return
+	}
+	opts := append([]crashreport.ReportOption{withStatus(st), crashreport.WithIdentifier(method)}, i.opts...)
+	i.reporter.Report(err, opts...)
+}
+
+// withStatus sets the class, the tags and the details of the report of a status
+func withStatus(st *status.Status) crashreport.ReportOption {
+	return func(post *crashreport.Post) {
+		code := st.Code().String()
+		post.Details.Error.ClassName = code
+		post.Details.Tags = append(post.Details.Tags, "grpc", "grpc.code:"+code)
+		data := map[string]interface{}{"code": code, "message": st.Message()}
+		if details := Details(st); len(details) > 0 {
+			data["details"] = details
+		}
+		post.Details.Error.Data = data
+	}
+}
+
+// Details returns the details of the status in a readable form: each one is its json mapping, with its type under the
+// "@type" key, for example:
+//
+//	{"@type": "type.googleapis.com/google.rpc.BadRequest",
+//		"fieldViolations": [{"field": "email", "description": "invalid address"}]}
+//
+// The details of a type that isn't linked in the program only have their type.
+func Details(st *status.Status) []map[string]interface{} {
+	var details []map[string]interface{}
+	for _, packed := range st.Proto().GetDetails() {
+		detail := map[string]interface{}{}
+		data, err := protojson.Marshal(packed)
+		if err != nil || json.Unmarshal(data, &detail) != nil {
+			detail = map[string]interface{}{"@type": packed.GetTypeUrl()}
+		}
+		details = append(details, detail)
+	}
+	return details
+}
@@ -0,0 +1,155 @@
+package grpcreport
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chennqqi/crashreport"
+	"github.com/chennqqi/crashreport/crashreporttest"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	sink := crashreporttest.NewMemorySink()
+	reporter := crashreport.NewReporter("key", crashreport.WithSink(sink))
+	intercept := UnaryServerInterceptor(reporter, WithReportedCodes(codes.InvalidArgument, codes.Internal))
+	info := &grpc.UnaryServerInfo{FullMethod: "/users.Users/Create"}
+
+	st, err := status.New(codes.InvalidArgument, "invalid user").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "email", Description: "invalid address"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notFound := status.Error(codes.NotFound, "no such user")
+	handlers := []grpc.UnaryHandler{
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, st.Err() },
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, notFound },
+		func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil },
+		func(ctx context.Context, req interface{}) (interface{}, error) { panic(errors.New("nil user")) },
+	}
+	var errs []error
+	for _, handler := range handlers {
+		_, err := intercept(context.Background(), nil, info, handler)
+		errs = append(errs, err)
+	}
+	if status.Code(errs[0]) != codes.InvalidArgument || status.Code(errs[3]) != codes.Internal {
+		t.Errorf("expected the errors of the handlers, and Internal for the panic, got %v", errs)
+	}
+
+	posts := sink.Posts()
+	if len(posts) != 2 {
+		t.Fatalf("expected the invalid argument and the panic to be reported, got %d posts", len(posts))
+	}
+	post := posts[0]
+	if post.Details.Error.ClassName != "InvalidArgument" || post.Details.Context.Identifier != info.FullMethod {
+		t.Errorf("expected the class of the code and the method, got %q and %q", post.Details.Error.ClassName,
+			post.Details.Context.Identifier)
+	}
+	if !hasTag(post.Details.Tags, "grpc.code:InvalidArgument") {
+		t.Errorf("expected the tag of the code, got %v", post.Details.Tags)
+	}
+	data, _ := post.Details.Error.Data.(map[string]interface{})
+	details, _ := data["details"].([]map[string]interface{})
+	if len(details) != 1 || details[0]["@type"] != "type.googleapis.com/google.rpc.BadRequest" {
+		t.Fatalf("expected the BadRequest detail, got %v", data)
+	}
+	violations, _ := details[0]["fieldViolations"].([]interface{})
+	if len(violations) != 1 {
+		t.Fatalf("expected the field violation, got %v", details[0])
+	}
+	violation := violations[0].(map[string]interface{})
+	if violation["field"] != "email" || violation["description"] != "invalid address" {
+		t.Errorf("expected the field and the description of the violation, got %v", violation)
+	}
+
+	if panicked := posts[1]; panicked.Details.Error.Message != "nil user" || !hasTag(panicked.Details.Tags, "grpc") {
+		t.Errorf("expected the panic to be reported, got %+v", panicked.Details.Error)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	sink := crashreporttest.NewMemorySink()
+	reporter := crashreport.NewReporter("key", crashreport.WithSink(sink))
+	intercept := StreamServerInterceptor(reporter, WithReportOptions(crashreport.WithTags("stream")))
+	info := &grpc.StreamServerInfo{FullMethod: "/users.Users/Watch", IsServerStream: true}
+
+	unavailable := status.Error(codes.Unavailable, "no backend")
+	cancelled := status.Error(codes.Canceled, "cancelled")
+	handlers := []grpc.StreamHandler{
+		func(srv interface{}, stream grpc.ServerStream) error { panic("closed channel") },
+		func(srv interface{}, stream grpc.ServerStream) error { return unavailable },
+		func(srv interface{}, stream grpc.ServerStream) error { return cancelled },
+	}
+	var errs []error
+	for _, handler := range handlers {
+		errs = append(errs, intercept(nil, nil, info, handler))
+	}
+	if status.Code(errs[0]) != codes.Internal || status.Code(errs[1]) != codes.Unavailable {
+		t.Errorf("expected Internal for the panic and the errors of the handlers, got %v", errs)
+	}
+
+	posts := sink.Posts()
+	if len(posts) != 2 {
+		t.Fatalf("expected the panic and the unavailable error to be reported, got %d posts", len(posts))
+	}
+	panicked := posts[0]
+	if panicked.Details.Error.Message != "panic: closed channel" || panicked.Details.Context.Identifier != info.FullMethod {
+		t.Errorf("expected the panic of the method, got %q and %q", panicked.Details.Error.Message,
+			panicked.Details.Context.Identifier)
+	}
+	for _, post := range posts {
+		if !hasTag(post.Details.Tags, "grpc") || !hasTag(post.Details.Tags, "stream") {
+			t.Errorf("expected the tags of the interceptor and of the options, got %v", post.Details.Tags)
+		}
+	}
+	if posts[1].Details.Error.ClassName != "Unavailable" {
+		t.Errorf("expected the class of the code, got %q", posts[1].Details.Error.ClassName)
+	}
+}
+
+func TestDetails(t *testing.T) {
+	if details := Details(status.New(codes.Internal, "no details")); details != nil {
+		t.Errorf("expected no details, got %v", details)
+	}
+
+	st, err := status.New(codes.InvalidArgument, "invalid user").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "email", Description: "invalid address"},
+			{Field: "age", Description: "negative"},
+		},
+	}, &errdetails.RequestInfo{RequestId: "r-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	details := Details(st)
+	if len(details) != 2 {
+		t.Fatalf("expected 2 details, got %v", details)
+	}
+	if details[0]["@type"] != "type.googleapis.com/google.rpc.BadRequest" {
+		t.Errorf("expected the type of the BadRequest, got %v", details[0]["@type"])
+	}
+	violations, _ := details[0]["fieldViolations"].([]interface{})
+	if len(violations) != 2 {
+		t.Fatalf("expected the 2 field violations, got %v", details[0])
+	}
+	if violation := violations[1].(map[string]interface{}); violation["field"] != "age" {
+		t.Errorf("expected the violations in order, got %v", violations)
+	}
+	if details[1]["@type"] != "type.googleapis.com/google.rpc.RequestInfo" || details[1]["requestId"] != "r-1" {
+		t.Errorf("expected the RequestInfo, got %v", details[1])
+	}
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package crashreport
+
+import (
+	"net/http"
+)
+
+// defaultHeaderAllowlist are the request headers reported by default, useful and not sensitive
+var defaultHeaderAllowlist = []string{"Content-Type", "Accept", "User-Agent", "Referer"}
+
+// WithHeaderAllowlist sets the request headers attached to the reports, compared case-insensitively. By default only
+// Content-Type, Accept, User-Agent and Referer are attached. The allowlist takes precedence over WithHeaderDenylist: a
+// header in both is attached.
+func WithHeaderAllowlist(names ...string) Option {
+	return func(r *Reporter) {
+		r.headers.allow = addHeaderNames(r.headers.allow, names)
+	}
+}
+
+// WithHeaderDenylist attaches all the request headers to the reports but the given ones, compared case-insensitively.
+// The sensitive headers, such as Authorization and Cookie, are still redacted. It's ignored if an allowlist is set
+// with WithHeaderAllowlist.
+func WithHeaderDenylist(names ...string) Option {
+	return func(r *Reporter) {
+		r.headers.deny = addHeaderNames(r.headers.deny, names)
+	}
+}
+
+// headerFilter chooses the request headers attached to the reports. The zero value keeps the default allowlist.
+type headerFilter struct {
+	allow map[string]bool // by canonical name
+	deny  map[string]bool
+}
+
+// addHeaderNames adds the canonical names to the set
+func addHeaderNames(set map[string]bool, names []string) map[string]bool {
+	if set == nil {
+		set = map[string]bool{}
+	}
+	for _, name := range names {
+		set[http.CanonicalHeaderKey(name)] = true
+	}
+	return set
+}
+
+// apply flattens the headers kept by the filter
+func (f headerFilter) apply(header http.Header) map[string]string {
+	kept := http.Header{}
+	for name, values := range header {
+		name = http.CanonicalHeaderKey(name)
+		switch {
+		case f.allow != nil:
+			if f.allow[name] {
+				kept[name] = values
+			}
+		case f.deny != nil:
+			if sensitiveHeaders[name] {
+				values = []string{redacted}
+			}
+			if !f.deny[name] {
+				kept[name] = values
+			}
+		default:
+			for _, allowed := range defaultHeaderAllowlist {
+				if name == allowed {
+					kept[name] = values
+				}
+			}
+		}
+	}
+	return arrayMapToStringMap(kept)
+}
@@ -0,0 +1,50 @@
+package crashreport
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHeaderFilter(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	req := httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "curl")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Request-Id", "42")
+	req.Header.Set("X-Internal", "yes")
+
+	reporters := []*Reporter{
+		NewReporter("key"),
+		NewReporter("key", WithHeaderAllowlist("x-request-id", "AUTHORIZATION")),
+		NewReporter("key", WithHeaderDenylist("x-internal", "user-agent")),
+		NewReporter("key", WithHeaderAllowlist("X-Internal"), WithHeaderDenylist("x-internal", "X-Request-Id")),
+	}
+	for _, reporter := range reporters {
+		reporter.ReportRequest(errors.New("failed"), req)
+	}
+
+	expected := []map[string]string{
+		{"Content-Type": "application/json", "User-Agent": "curl"},
+		{"Authorization": "Bearer secret", "X-Request-Id": "42"},
+		{"Content-Type": "application/json", "Authorization": redacted, "X-Request-Id": "42"},
+		{"X-Internal": "yes"},
+	}
+	sent := server.Posts()
+	if len(sent) != len(expected) {
+		t.Fatalf("expected %d posts, got %d", len(expected), len(sent))
+	}
+	for i, post := range sent {
+		if headers := post.Details.Request.Headers; !reflect.DeepEqual(headers, expected[i]) {
+			t.Errorf("reporter %d: expected the headers %v, got %v", i, expected[i], headers)
+		}
+	}
+
+	if headers := FromReq(req).Headers; !reflect.DeepEqual(headers, expected[0]) {
+		t.Errorf("FromReq should keep the default headers, got %v", headers)
+	}
+}
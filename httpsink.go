@@ -0,0 +1,86 @@
+package crashreport
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HTTPSinkOption configures an HTTPSink
+type HTTPSinkOption func(*HTTPSink)
+
+// WithHTTPSinkClient sets the http client posting the payloads. By default it has a 5s timeout.
+func WithHTTPSinkClient(client *http.Client) HTTPSinkOption {
+	return func(s *HTTPSink) {
+		s.client = client
+	}
+}
+
+// HTTPSink posts the reports to another endpoint than Raygun, such as an ingestion gateway expecting its own json
+// shape: each post is converted by a transform function, and the payload is posted with the configured headers. The
+// api key of the reporter is not sent, set the authentication of the endpoint in the headers.
+//
+// Any status other than 2xx is returned as a ResponseError, so that the cool-down and the disk queue of the reporter
+// apply like with Raygun.
+type HTTPSink struct {
+	url       string
+	transform func(Post) ([]byte, error)
+	headers   map[string]string
+	client    *http.Client
+}
+
+// NewHTTPSink creates a sink posting the payloads returned by transform to url, see WithSink. The Content-Type is
+// application/json unless it's set in the headers.
+func NewHTTPSink(url string, transform func(Post) ([]byte, error), headers map[string]string, opts ...HTTPSinkOption) *HTTPSink {
+	s := &HTTPSink{
+		url:       url,
+		transform: transform,
+		headers:   headers,
+		client:    &http.Client{Timeout: 5 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Send converts the post and posts the payload. The key is not sent.
+func (s *HTTPSink) Send(ctx context.Context, post Post, key string) error {
+	payload, err := s.transform(post)
+	if err != nil {
+		return errors.Wrap(err, "transform post")
+	}
+
+	req, err := http.NewRequestWithContext(submission(ctx), "POST", s.url, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "create req")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "execute req")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			body = []byte("no body")
+		}
+		return &ResponseError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       string(body),
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestHTTPSink(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		payloads []map[string]string
+		status   = http.StatusOK
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token" || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected headers %v", req.Header)
+		}
+		if req.Header.Get("X-ApiKey") != "" {
+			t.Error("the api key should not be sent")
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		var payload map[string]string
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("unexpected payload %q", body)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		payloads = append(payloads, payload)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	transform := func(post Post) ([]byte, error) {
+		return json.Marshal(map[string]string{"title": post.Details.Error.Message, "severity": post.Level().String()})
+	}
+	sink := NewHTTPSink(server.URL, transform, map[string]string{"Authorization": "Bearer token"})
+	reporter := NewReporter("key", WithSink(sink))
+	if err := reporter.CaptureMessage("gateway"); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	status = http.StatusServiceUnavailable
+	mu.Unlock()
+	err := reporter.CaptureMessage("unavailable")
+	if e, ok := err.(*ResponseError); !ok || e.StatusCode != http.StatusServiceUnavailable || !Retryable(err) {
+		t.Errorf("expected a retryable response error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(payloads) != 2 || payloads[0]["title"] != "gateway" || payloads[0]["severity"] != "info" {
+		t.Errorf("expected the transformed payloads, got %v", payloads)
+	}
+}
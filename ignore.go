@@ -0,0 +1,44 @@
+package crashreport
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// WithIgnoreErrors drops the errors matching any of the matchers before they are reported, such as
+// context.Canceled from client disconnections, whichever way they are reported: Report, the recovered panics, the
+// slog handler, etc. See IgnoreIs and IgnoreType.
+// Dropped errors are counted in Stats().Ignored.
+func WithIgnoreErrors(matchers ...func(error) bool) Option {
+	return func(r *Reporter) {
+		r.ignore = append(r.ignore, matchers...)
+	}
+}
+
+// IgnoreIs matches the errors wrapping target, according to errors.Is
+func IgnoreIs(target error) func(error) bool {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+// IgnoreType matches the errors wrapping an error of type T, according to errors.As
+func IgnoreType[T error]() func(error) bool {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
+// ignored tells if the error must not be reported, and counts it
+func (r *Reporter) ignored(err error) bool {
+	for _, match := range r.ignore {
+		matched := false
+		safely("ignore matcher", func() { matched = match(err) })
+		if matched {
+			atomic.AddInt64(&r.stats.ignored, 1)
+			return true
+		}
+	}
+	return false
+}
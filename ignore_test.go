@@ -0,0 +1,64 @@
+package crashreport
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+)
+
+func TestWithIgnoreErrors(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithIgnoreErrors(
+		IgnoreIs(context.Canceled),
+		IgnoreIs(sql.ErrNoRows),
+		IgnoreType[*net.DNSError](),
+	))
+
+	canceled := fmt.Errorf("handler: %w", fmt.Errorf("query: %w", fmt.Errorf("read: %w", context.Canceled)))
+	reporter.Report(canceled)
+	reporter.Report(fmt.Errorf("user: %w", sql.ErrNoRows))
+	reporter.Report(fmt.Errorf("lookup: %w", &net.DNSError{Err: "no such host", Name: "example.invalid"}))
+	reporter.Report(errors.New("real failure"))
+
+	sent := server.Posts()
+	if len(sent) != 1 || sent[0].Details.Error.Message != "real failure" {
+		t.Errorf("only the real failure should be reported, got %d posts", len(sent))
+	}
+	if ignored := reporter.Stats().Ignored; ignored != 3 {
+		t.Errorf("expected 3 ignored errors, got %d", ignored)
+	}
+}
+
+func TestWithIgnoreErrorsEveryPath(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithIgnoreErrors(IgnoreIs(context.Canceled)),
+		WithPostMapper(func(interface{}, *Post) {}))
+	canceled := fmt.Errorf("query: %w", context.Canceled)
+
+	logger := slog.New(NewSlogHandler(slog.NewTextHandler(io.Discard, nil), reporter))
+	logger.Error("query failed", "err", canceled)
+	reporter.ReportObject(canceled)
+	reporter.CapturePanic(canceled)
+	reporter.ReportMany([]error{canceled, errors.New("real failure")})
+	outcome := reporter.ReportResult(canceled)
+
+	sent := server.Posts()
+	if len(sent) != 1 || sent[0].Details.Error.Message != "real failure" {
+		t.Errorf("only the real failure should be reported, got %d posts", len(sent))
+	}
+	if ignored := reporter.Stats().Ignored; ignored != 5 {
+		t.Errorf("expected the 5 ignored errors to be counted, got %d", ignored)
+	}
+	if outcome != OutcomeDropped {
+		t.Errorf("expected the ignored error to be dropped, got %v", outcome)
+	}
+}
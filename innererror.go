@@ -0,0 +1,53 @@
+package crashreport
+
+import "errors"
+
+// InnerErrorMode tells which wrapped error Error.InnerError shows, see WithInnerErrorMode
+type InnerErrorMode int
+
+// Modes of Error.InnerError
+const (
+	InnerErrorImmediate InnerErrorMode = iota // the error wrapped by the reported one, the default
+	InnerErrorDeepest                         // the root cause, at the end of the chain
+	InnerErrorOff                             // no inner error
+)
+
+// WithInnerErrorMode sets which wrapped error the InnerError of the reports shows. By default FromErr shows the
+// message of the error immediately wrapped by the reported one, with fmt.Errorf's %w or a Cause method, so that the
+// layers of wrapping are visible. The wrappers that don't change the message, such as the ones only adding a stack
+// trace, are skipped. InnerErrorDeepest shows the root cause instead, and InnerErrorOff leaves InnerError empty.
+func WithInnerErrorMode(mode InnerErrorMode) Option {
+	return func(r *Reporter) {
+		r.innerErrorMode = mode
+	}
+}
+
+// innerError returns the message of the error wrapped by err, or an empty string if err wraps nothing
+func innerError(err error, mode InnerErrorMode) string {
+	if mode == InnerErrorOff {
+		return ""
+	}
+
+	message := err.Error()
+	inner := ""
+	for wrapped := unwrapOnce(err); wrapped != nil; wrapped = unwrapOnce(wrapped) {
+		if m := wrapped.Error(); m != message {
+			inner, message = m, m
+			if mode == InnerErrorImmediate {
+				break
+			}
+		}
+	}
+	return inner
+}
+
+// unwrapOnce returns the error wrapped by err, with Unwrap or Cause, or nil
+func unwrapOnce(err error) error {
+	if wrapped := errors.Unwrap(err); wrapped != nil {
+		return wrapped
+	}
+	if causer, ok := err.(interface{ Cause() error }); ok {
+		return causer.Cause()
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package crashreport
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWithInnerErrorMode(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	root := errors.New("connection refused")
+	err := fmt.Errorf("load order: %w", fmt.Errorf("query orders: %w", root))
+
+	if inner := FromErr(err).InnerError; inner != "query orders: connection refused" {
+		t.Errorf("expected FromErr to show the immediate inner error, got '%s'", inner)
+	}
+
+	tests := []struct {
+		mode InnerErrorMode
+		want string
+	}{
+		{InnerErrorImmediate, "query orders: connection refused"},
+		{InnerErrorDeepest, "connection refused"},
+		{InnerErrorOff, ""},
+	}
+	for _, test := range tests {
+		NewReporter("key", WithInnerErrorMode(test.mode)).Report(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != len(tests) {
+		t.Fatalf("expected %d posts, got %d", len(tests), len(sent))
+	}
+	for i, test := range tests {
+		if inner := sent[i].Details.Error.InnerError; inner != test.want {
+			t.Errorf("mode %d: expected the inner error '%s', got '%s'", test.mode, test.want, inner)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package crashreport
+
+// WithJoinedStacks handles the errors wrapping several errors, such as the ones of errors.Join: the stacktrace of the
+// report is the one of the first joined error that has one, and the stacktraces of the other ones are added to
+// Error.Data under the "additionalStacks" key, in the format of a go panic. By default the stacktrace of the report
+// is the one where the errors were joined.
+func WithJoinedStacks() Option {
+	return func(r *Reporter) {
+		r.joinedStacks = true
+	}
+}
+
+// applyStacks replaces the stacktrace of the post by the ones of its error, see WithDeepestStack and
+// WithJoinedStacks
+func (r *Reporter) applyStacks(post *Post) {
+	if r.joinedStacks {
+		var stacks []StackTrace
+		for _, err := range joinedErrors(post.err) {
+			if stacker := r.stacker(err); stacker != nil {
+				stacks = append(stacks, stacktrace(stacker))
+			}
+		}
+		if len(stacks) > 0 {
+			post.Details.Error.StackTrace = stacks[0]
+			if len(stacks) > 1 {
+				rendered := make([]string, 0, len(stacks)-1)
+				for _, stack := range stacks[1:] {
+					rendered = append(rendered, stack.String())
+				}
+				post.Details.Error.SetData("additionalStacks", rendered)
+			}
+			return
+		}
+	}
+
+	if r.deepestStack {
+		if stacker := r.stacker(post.err); stacker != nil {
+			post.Details.Error.StackTrace = stacktrace(stacker)
+		}
+	}
+}
+
+// stacker returns the error of the chain whose stacktrace is reported: the outermost one, or the deepest one with
+// WithDeepestStack. It returns nil if none has a stacktrace.
+func (r *Reporter) stacker(err error) error {
+	errs := stackers(err)
+	switch {
+	case len(errs) == 0:
+		return nil
+	case r.deepestStack:
+		return errs[len(errs)-1]
+	default:
+		return errs[0]
+	}
+}
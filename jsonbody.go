@@ -0,0 +1,74 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"mime"
+	"strings"
+)
+
+// WithJSONRequestBody parses the json bodies of the requests into the custom data, under the "requestBody" key,
+// instead of reporting them raw in Request.RawData, so that their fields can be searched in the dashboard. Only the
+// bodies whose Content-Type is application/json, or ends with +json, and of maxBytes at most are parsed: the others
+// stay in RawData, as do the bodies that aren't valid json.
+//
+// The fields named password, or one of scrub, are replaced by "[REDACTED]" at any depth of the body, in the objects
+// and in the arrays. The names are compared without case:
+//
+//	crashreport.WithJSONRequestBody(16<<10, "token", "cardNumber")
+func WithJSONRequestBody(maxBytes int, scrub ...string) Option {
+	return func(r *Reporter) {
+		fields := map[string]bool{"password": true}
+		for _, field := range scrub {
+			fields[strings.ToLower(field)] = true
+		}
+		r.jsonBody = &jsonBody{maxBytes: maxBytes, scrub: fields}
+	}
+}
+
+// jsonBody parses the json bodies of the requests, see WithJSONRequestBody
+type jsonBody struct {
+	maxBytes int
+	scrub    map[string]bool // lowercase
+}
+
+// apply moves the body of the post's request to its custom data, if it's json
+func (j *jsonBody) apply(post *Post) {
+	body, ok := post.Details.Request.RawData.([]byte)
+	if !ok || len(body) == 0 || len(body) > j.maxBytes || !isJSON(post.Details.Request.contentType) {
+		return
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return
+	}
+	post.SetCustomData("requestBody", j.scrubbed(parsed, 0))
+	post.Details.Request.RawData = nil
+}
+
+// scrubbed redacts the fields to scrub in the parsed json, in place
+func (j *jsonBody) scrubbed(v interface{}, depth int) interface{} {
+	if depth > maxRedactDepth {
+		return nil
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if j.scrub[strings.ToLower(k)] {
+				v[k] = redacted
+			} else {
+				v[k] = j.scrubbed(value, depth+1)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = j.scrubbed(value, depth+1)
+		}
+	}
+	return v
+}
+
+// isJSON tells if the content type is the one of a json document
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
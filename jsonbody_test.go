@@ -0,0 +1,52 @@
+package crashreport
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithJSONRequestBody(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithJSONRequestBody(1024, "cardNumber"))
+	body := `{"user":"ann","password":"hunter2","payment":{"cardNumber":"4242","amount":12},` +
+		`"accounts":[{"Password":"swordfish","id":1}]}`
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	reporter.ReportRequest(errors.New("invalid order"), req)
+
+	form := httptest.NewRequest("POST", "/orders", strings.NewReader("user=ann&password=hunter2"))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	reporter.ReportRequest(errors.New("invalid form"), form)
+
+	large := httptest.NewRequest("POST", "/orders", strings.NewReader(`{"items":"`+strings.Repeat("x", 1024)+`"}`))
+	large.Header.Set("Content-Type", "application/json")
+	reporter.ReportRequest(errors.New("large order"), large)
+
+	sent := server.Posts()
+	if len(sent) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(sent))
+	}
+	expected := map[string]interface{}{
+		"user":     "ann",
+		"password": redacted,
+		"payment":  map[string]interface{}{"cardNumber": redacted, "amount": float64(12)},
+		"accounts": []interface{}{map[string]interface{}{"Password": redacted, "id": float64(1)}},
+	}
+	parsed := sent[0].Details.UserCustomData.(map[string]interface{})["requestBody"]
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("expected the parsed and scrubbed body, got %v", parsed)
+	}
+	if raw := sent[0].Details.Request.RawData; raw != nil {
+		t.Errorf("expected the raw body to be dropped, got %v", raw)
+	}
+	for _, post := range sent[1:] {
+		if post.Details.Request.RawData == nil || post.Details.UserCustomData != nil {
+			t.Errorf("%s: expected the body to stay raw, got %v", post.Details.Error.Message, post.Details.UserCustomData)
+		}
+	}
+}
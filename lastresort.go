@@ -0,0 +1,58 @@
+package crashreport
+
+import (
+	"log"
+	"os"
+	"sync"
+)
+
+// WithLastResortFile appends the reports that would be lost to the file, one json post per line, for a human or a
+// tool to recover them: the reports whose submission failed and couldn't be persisted to the disk queue, the ones
+// dropped because the queue of an asynchronous reporter is full or closed, and the ones still queued when Close
+// times out. The file isn't replayed by the reporter. If the file can't be written, the reports are dropped and the
+// error is logged once.
+func WithLastResortFile(path string) Option {
+	return func(r *Reporter) {
+		r.lastResort = &lastResortFile{path: path}
+	}
+}
+
+// lastResortFile is the file of WithLastResortFile
+type lastResortFile struct {
+	path string
+
+	mu        sync.Mutex // serializes the writes, so that the lines aren't mixed
+	errLogged sync.Once
+}
+
+// writeLastResort appends the post to the last resort file, if any
+func (r *Reporter) writeLastResort(post Post) {
+	if r.lastResort == nil {
+		return
+	}
+	if err := r.lastResort.write(post); err != nil {
+		r.lastResort.errLogged.Do(func() {
+			log.Printf("crashreport: can't write to the last resort file, reports are lost: %v", err)
+		})
+	}
+}
+
+func (f *lastResortFile) write(post Post) error {
+	line, err := marshalPost(post)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(line)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
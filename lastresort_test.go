@@ -0,0 +1,56 @@
+package crashreport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type failingSink struct{}
+
+func (failingSink) Send(ctx context.Context, post Post, key string) error {
+	return &ResponseError{StatusCode: 503, Status: "503 Service Unavailable"}
+}
+
+func TestWithLastResortFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lost.ndjson")
+
+	// The disk queue can't be created under a file
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.WriteFile(blocked, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	reporter := NewReporter("key", WithSink(failingSink{}), WithDiskQueue(filepath.Join(blocked, "queue")),
+		WithLastResortFile(path))
+	reporter.Report(errors.New("first"))
+	reporter.Report(errors.New("second"))
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var messages []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var post Post
+		if err := json.Unmarshal(scanner.Bytes(), &post); err != nil {
+			t.Fatalf("expected a post per line, got %s: %v", scanner.Text(), err)
+		}
+		messages = append(messages, post.Details.Error.Message)
+	}
+	if len(messages) != 2 || messages[0] != "first" || messages[1] != "second" {
+		t.Errorf("expected the lost reports in order, got %v", messages)
+	}
+
+	unwritable := NewReporter("key", WithSink(failingSink{}), WithLastResortFile(filepath.Join(blocked, "lost")))
+	if err := unwritable.Report(errors.New("lost")); err == nil {
+		t.Error("expected the error of the submission")
+	}
+}
@@ -0,0 +1,91 @@
+package crashreport
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+)
+
+// Level is the severity of a report. Raygun has no severity, so it's sent as a "severity:<level>" tag and as the
+// "level" key of the custom data.
+type Level int
+
+// Levels of the reports, from the least to the most severe
+const (
+	LevelDebug Level = iota + 1
+	LevelInfo
+	LevelWarning
+	LevelError
+	LevelFatal
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarning:
+		return "warning"
+	case LevelError:
+		return "error"
+	case LevelFatal:
+		return "fatal"
+	default:
+		return "Level(" + strconv.Itoa(int(l)) + ")"
+	}
+}
+
+// Level returns the severity of the post. Posts without an explicit level are errors.
+func (p Post) Level() Level {
+	if p.level == 0 {
+		return LevelError
+	}
+	return p.level
+}
+
+// WithLevel sets the severity of the report
+func WithLevel(level Level) ReportOption {
+	return func(post *Post) {
+		post.level = level
+	}
+}
+
+// WithMinLevel drops the reports below the level, before the rest of the pipeline: with LevelError, the messages of
+// CaptureMessage and the warnings are dropped, and the errors are reported. The level of a report is the one of
+// WithLevel, applied first, and the reports without one are errors, see Post.Level. The dropped reports are counted
+// in Stats.BelowMinLevel, with the reason DropLowLevel.
+func WithMinLevel(level Level) Option {
+	return func(r *Reporter) {
+		r.minLevel = level
+	}
+}
+
+// belowMinLevel tells if the post is dropped by WithMinLevel, and counts it
+func (r *Reporter) belowMinLevel(post Post) bool {
+	if r.minLevel == 0 || post.Level() >= r.minLevel {
+		return false
+	}
+	atomic.AddInt64(&r.stats.belowMinLevel, 1)
+	r.dropped(post, DropLowLevel)
+	return true
+}
+
+// CaptureMessage reports a message that is not an error, with the current stacktrace. Its level is info unless
+// set with WithLevel.
+func (r *Reporter) CaptureMessage(message string, opts ...ReportOption) error {
+	post := r.newPost()
+	post.Details.Error = FromErr(errors.New(message))
+	post.level = LevelInfo
+	identify(&post, caller(0))
+	return r.send(post, opts...)
+}
+
+// applyLevel tags the post with its level, if it was set
+func applyLevel(post *Post) {
+	if post.level == 0 {
+		return
+	}
+	post.Details.Tags = append(post.Details.Tags, "severity:"+post.level.String())
+	post.SetCustomData("level", post.level.String())
+}
@@ -0,0 +1,74 @@
+package crashreport
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLevel(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	reporter.Report(errors.New("failure"), WithLevel(LevelWarning))
+	reporter.CaptureMessage("starting")
+	reporter.CaptureMessage("disk full", WithLevel(LevelFatal))
+	reporter.Report(errors.New("no level"))
+
+	sent := server.Posts()
+	if len(sent) != 4 {
+		t.Fatalf("expected 4 posts, got %d", len(sent))
+	}
+
+	expected := []string{"warning", "info", "fatal"}
+	for i, level := range expected {
+		details := sent[i].Details
+		if len(details.Tags) != 1 || details.Tags[0] != "severity:"+level {
+			t.Errorf("post %d should be tagged severity:%s, got %v", i, level, details.Tags)
+		}
+		data, _ := details.UserCustomData.(map[string]interface{})
+		if data["level"] != level {
+			t.Errorf("post %d should have level %s in custom data, got %v", i, level, data["level"])
+		}
+	}
+	if sent[1].Details.Error.Message != "starting" {
+		t.Errorf("message should be 'starting', got '%s'", sent[1].Details.Error.Message)
+	}
+	if len(sent[3].Details.Tags) != 0 || sent[3].Details.UserCustomData != nil {
+		t.Error("posts without level should not be tagged")
+	}
+
+	if (Post{}).Level() != LevelError {
+		t.Error("posts without level should be errors")
+	}
+	if LevelWarning.String() != "warning" || Level(42).String() != "Level(42)" {
+		t.Error("unexpected level names")
+	}
+}
+
+func TestWithMinLevel(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	var reasons []DropReason
+	reporter := NewReporter("key", WithMinLevel(LevelError), WithDropObserver(func(post Post, reason DropReason) {
+		reasons = append(reasons, reason)
+	}))
+	reporter.CaptureMessage("cache warmed")
+	reporter.Report(errors.New("retrying"), WithLevel(LevelWarning))
+	reporter.Report(errors.New("payment failed"))
+	reporter.CaptureMessage("disk full", WithLevel(LevelFatal))
+	reporter.With(WithLevel(LevelDebug)).Report(errors.New("trace"))
+
+	sent := server.Posts()
+	if len(sent) != 2 || sent[0].Details.Error.Message != "payment failed" ||
+		sent[1].Details.Error.Message != "disk full" {
+		t.Fatalf("expected only the error and the fatal reports, got %d posts", len(sent))
+	}
+	if n := reporter.Stats().BelowMinLevel; n != 3 {
+		t.Errorf("expected 3 reports below the level, got %d", n)
+	}
+	if len(reasons) != 3 || reasons[0] != DropLowLevel {
+		t.Errorf("expected the reports to be dropped for their level, got %v", reasons)
+	}
+}
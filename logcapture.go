@@ -0,0 +1,175 @@
+package crashreport
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Breadcrumb levels, as defined by Raygun
+const (
+	BreadcrumbDebug = iota
+	BreadcrumbInfo
+	BreadcrumbWarning
+	BreadcrumbError
+)
+
+// WithMinBreadcrumbLevel drops the breadcrumbs of the reports below the level, such as BreadcrumbInfo to drop the debug
+// ones, whatever their source: the context, the log captures, the operations or the options. A breadcrumb without a
+// level has the level 0, BreadcrumbDebug, as Raygun shows it: set the level of the breadcrumbs to keep.
+func WithMinBreadcrumbLevel(level int) Option {
+	return func(r *Reporter) {
+		r.minBreadcrumbLevel = level
+	}
+}
+
+// filterBreadcrumbs returns the breadcrumbs at the level or above
+func filterBreadcrumbs(crumbs []Breadcrumb, level int) []Breadcrumb {
+	var kept []Breadcrumb
+	for _, crumb := range crumbs {
+		if crumb.Level >= level {
+			kept = append(kept, crumb)
+		}
+	}
+	return kept
+}
+
+// maxLogLineLength is the maximum length in bytes of a log line kept as breadcrumb
+const maxLogLineLength = 1024
+
+// NewLogCapture returns a writer that keeps the last n lines written to it, and a function returning them as
+// breadcrumbs, oldest first. Everything is also written to the current output of the standard logger, so the writer can
+// be installed with log.SetOutput without losing the logs:
+//
+//	w, breadcrumbs := crashreport.NewLogCapture(20)
+//	log.SetOutput(w)
+//	reporter := crashreport.NewReporter(key, crashreport.WithBreadcrumbSource(breadcrumbs))
+//
+// The level of the breadcrumbs is guessed from the content of the line, and lines are truncated to 1024 bytes.
+func NewLogCapture(n int) (io.Writer, func() []Breadcrumb) {
+	c := &logCapture{out: log.Writer(), ring: newBreadcrumbRing(n)}
+	return c, c.ring.breadcrumbs
+}
+
+// logCapture splits the log output in lines, kept in a ring buffer
+type logCapture struct {
+	mu      sync.Mutex
+	out     io.Writer
+	ring    *breadcrumbRing
+	partial []byte
+}
+
+func (c *logCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	c.partial = append(c.partial, p...)
+	for {
+		i := bytes.IndexByte(c.partial, '\n')
+		if i < 0 {
+			break
+		}
+		c.add(string(c.partial[:i]))
+		c.partial = c.partial[i+1:]
+	}
+	if len(c.partial) > maxLogLineLength {
+		c.add(string(c.partial))
+		c.partial = nil
+	}
+	c.mu.Unlock()
+
+	return c.out.Write(p)
+}
+
+// add stores a line as a breadcrumb
+func (c *logCapture) add(line string) {
+	if line == "" {
+		return
+	}
+	c.ring.add(Breadcrumb{
+		Message:   truncateLine(line),
+		Category:  "log",
+		Timestamp: millis(time.Now()),
+		Level:     logLevel(line),
+	})
+}
+
+// breadcrumbRing keeps the last breadcrumbs added to it
+type breadcrumbRing struct {
+	mu     sync.Mutex
+	crumbs []Breadcrumb
+	next   int
+	size   int
+}
+
+func newBreadcrumbRing(n int) *breadcrumbRing {
+	if n < 0 {
+		n = 0
+	}
+	return &breadcrumbRing{crumbs: make([]Breadcrumb, 0, n), size: n}
+}
+
+// add stores a breadcrumb, overwriting the oldest one when the buffer is full
+func (r *breadcrumbRing) add(crumb Breadcrumb) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size == 0 {
+		return
+	}
+	if len(r.crumbs) < r.size {
+		r.crumbs = append(r.crumbs, crumb)
+		return
+	}
+	r.crumbs[r.next] = crumb
+	r.next = (r.next + 1) % r.size
+}
+
+// breadcrumbs returns the breadcrumbs, oldest first
+func (r *breadcrumbRing) breadcrumbs() []Breadcrumb {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	crumbs := make([]Breadcrumb, 0, len(r.crumbs))
+	crumbs = append(crumbs, r.crumbs[r.next:]...)
+	crumbs = append(crumbs, r.crumbs[:r.next]...)
+	return crumbs
+}
+
+// truncateLine cuts a line to maxLogLineLength bytes without splitting a multibyte character
+func truncateLine(line string) string {
+	if len(line) <= maxLogLineLength {
+		return line
+	}
+	cut := maxLogLineLength
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut]
+}
+
+// logLevel guesses the level of a log line from the usual level markers. Lines without a marker are info.
+func logLevel(line string) int {
+	upper := strings.ToUpper(line)
+	switch {
+	case containsAny(upper, "ERROR", "FATAL", "PANIC", "CRIT"):
+		return BreadcrumbError
+	case containsAny(upper, "WARN"):
+		return BreadcrumbWarning
+	case containsAny(upper, "DEBUG", "TRACE"):
+		return BreadcrumbDebug
+	default:
+		return BreadcrumbInfo
+	}
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package crashreport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLogCapture(t *testing.T) {
+	var out bytes.Buffer
+	oldOut, oldFlags := log.Writer(), log.Flags()
+	log.SetOutput(&out)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(oldOut)
+		log.SetFlags(oldFlags)
+	}()
+
+	w, breadcrumbs := NewLogCapture(3)
+	log.SetOutput(w)
+
+	log.Print("starting")
+	log.Print("WARN disk almost full")
+	log.Print("ERROR cannot write\ncontinuation")
+	log.Print("DEBUG " + strings.Repeat("é", maxLogLineLength))
+
+	if !strings.Contains(out.String(), "starting\n") {
+		t.Error("logs should be written to the original output")
+	}
+
+	crumbs := breadcrumbs()
+	if len(crumbs) != 3 {
+		t.Fatalf("expected the last 3 lines, got %d", len(crumbs))
+	}
+	expected := []struct {
+		message string
+		level   int
+	}{
+		{"ERROR cannot write", BreadcrumbError},
+		{"continuation", BreadcrumbInfo},
+	}
+	for i, exp := range expected {
+		if crumbs[i].Message != exp.message || crumbs[i].Level != exp.level {
+			t.Errorf("breadcrumb %d should be '%s' at level %d, got '%s' at level %d",
+				i, exp.message, exp.level, crumbs[i].Message, crumbs[i].Level)
+		}
+	}
+	if crumbs[2].Level != BreadcrumbDebug || len(crumbs[2].Message) > maxLogLineLength {
+		t.Errorf("long debug line should be truncated, got %d bytes at level %d", len(crumbs[2].Message), crumbs[2].Level)
+	}
+	if !strings.HasSuffix(crumbs[2].Message, "é") {
+		t.Error("truncation should not split multibyte characters")
+	}
+}
+
+func TestWithBreadcrumbSource(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	oldOut := log.Writer()
+	log.SetOutput(ioutil.Discard)
+	w, breadcrumbs := NewLogCapture(10)
+	log.SetOutput(oldOut)
+
+	logger := log.New(w, "", 0)
+	logger.Print("user logged in")
+
+	reporter := NewReporter("key", WithBreadcrumbSource(breadcrumbs))
+	if err := reporter.Report(errors.New("failure")); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	crumbs := sent[0].Details.Breadcrumbs
+	if len(crumbs) != 1 || crumbs[0].Message != "user logged in" || crumbs[0].Category != "log" {
+		t.Errorf("log lines should be attached as breadcrumbs, got %+v", crumbs)
+	}
+}
+
+func TestWithMinBreadcrumbLevel(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithMinBreadcrumbLevel(BreadcrumbWarning), WithBreadcrumbSource(func() []Breadcrumb {
+		return []Breadcrumb{{Message: "source debug"}, {Message: "source error", Level: BreadcrumbError}}
+	}))
+	reporter.TrackOperation("operation", nil)
+	ctx := ContextWithBreadcrumbs(context.Background())
+	AddBreadcrumb(ctx, Breadcrumb{Message: "context warning", Level: BreadcrumbWarning})
+	AddBreadcrumb(ctx, Breadcrumb{Message: "context info", Level: BreadcrumbInfo})
+	reporter.ReportCtx(ctx, errors.New("failure"), WithBreadcrumbs(Breadcrumb{Message: "manual debug"},
+		Breadcrumb{Message: "manual error", Level: BreadcrumbError}))
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	var messages []string
+	for _, crumb := range sent[0].Details.Breadcrumbs {
+		messages = append(messages, crumb.Message)
+	}
+	expected := []string{"context warning", "manual error", "source error"}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected the breadcrumbs %v, got %v", expected, messages)
+	}
+}
@@ -0,0 +1,50 @@
+package crashreport
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// errNoPostMapper is returned by ReportObject when the reporter has no mapper
+var errNoPostMapper = errors.New("crashreport: ReportObject needs WithPostMapper")
+
+// WithPostMapper sets the function filling the posts of ReportObject from the reported objects, such as the domain
+// events of the application: it can set any field of the post, such as the version, the tags or the grouping key,
+// so that the events are reported without assembling the posts by hand. The options of the report apply after it.
+func WithPostMapper(mapper func(src interface{}, dst *Post)) Option {
+	return func(r *Reporter) {
+		r.postMapper = mapper
+	}
+}
+
+// ReportObject reports an arbitrary object, filling the post with the mapper of WithPostMapper:
+//
+//	reporter := crashreport.NewReporter(key, crashreport.WithPostMapper(func(src interface{}, dst *crashreport.Post) {
+//		if e, ok := src.(PaymentFailed); ok {
+//			dst.Details.Error.Message = "payment failed: " + e.Reason
+//			dst.Details.Tags = append(dst.Details.Tags, "payments", "provider:"+e.Provider)
+//			dst.Details.GroupingKey = "payment-" + e.Provider
+//		}
+//	}))
+//	reporter.ReportObject(PaymentFailed{Provider: "stripe", Reason: "card declined"})
+//
+// Before the mapper, an error is set as Report does, and another object as an error with its type as class name, its
+// value as message and the stacktrace of the caller. A panic of the mapper is logged, and the post is reported as it
+// was before it. Without a mapper, nothing is reported and an error is returned.
+func (r *Reporter) ReportObject(obj interface{}, opts ...ReportOption) error {
+	if r.postMapper == nil {
+		return errNoPostMapper
+	}
+
+	post := r.newPost()
+	if err, ok := obj.(error); ok {
+		post.Details.Error = FromErr(err)
+		post.err = err
+	} else {
+		post.Details.Error = Error{ClassName: fmt.Sprintf("%T", obj), Message: fmt.Sprint(obj), StackTrace: callers(0)}
+	}
+	identify(&post, caller(0))
+	applySafely("post mapper", &post, func(post *Post) { r.postMapper(obj, post) })
+	return r.send(post, opts...)
+}
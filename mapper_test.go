@@ -0,0 +1,67 @@
+package crashreport
+
+import (
+	"errors"
+	"testing"
+)
+
+type paymentFailed struct {
+	Provider string
+	Reason   string
+	Release  string
+}
+
+func mapPayment(src interface{}, dst *Post) {
+	e, ok := src.(paymentFailed)
+	if !ok {
+		return
+	}
+	dst.Details.Error.Message = "payment failed: " + e.Reason
+	dst.Details.Version = e.Release
+	dst.Details.Tags = append(dst.Details.Tags, "payments", "provider:"+e.Provider)
+	dst.Details.GroupingKey = "payment-" + e.Provider
+	dst.SetCustomData("provider", e.Provider)
+}
+
+func TestReportObject(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	if err := NewReporter("key").ReportObject(paymentFailed{}); err != errNoPostMapper {
+		t.Errorf("expected an error without a mapper, got %v", err)
+	}
+
+	reporter := NewReporter("key", WithPostMapper(mapPayment))
+	event := paymentFailed{Provider: "stripe", Reason: "card declined", Release: "1.4.2"}
+	if err := reporter.ReportObject(event, WithTags("checkout")); err != nil {
+		t.Fatal(err)
+	}
+	if err := reporter.ReportObject(errors.New("gateway unreachable")); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	d := sent[0].Details
+	if d.Error.Message != "payment failed: card declined" || d.Error.ClassName != "crashreport.paymentFailed" {
+		t.Errorf("expected the error to be mapped from the event, got %+v", d.Error)
+	}
+	if len(d.Error.StackTrace) == 0 || d.Error.StackTrace[0].MethodName != "TestReportObject" {
+		t.Errorf("expected the stacktrace of the caller, got %v", d.Error.StackTrace)
+	}
+	if d.Version != "1.4.2" || d.GroupingKey != "payment-stripe" {
+		t.Errorf("expected the version and the grouping key of the event, got %q and %q", d.Version, d.GroupingKey)
+	}
+	if !hasTag(d.Tags, "provider:stripe") || !hasTag(d.Tags, "checkout") {
+		t.Errorf("expected the tags of the mapper and of the options, got %v", d.Tags)
+	}
+	if data, _ := d.UserCustomData.(map[string]interface{}); data["provider"] != "stripe" {
+		t.Errorf("expected the custom data of the mapper, got %v", d.UserCustomData)
+	}
+
+	if sent[1].Details.Error.Message != "gateway unreachable" || hasTag(sent[1].Details.Tags, "payments") {
+		t.Errorf("expected the error to be reported as it is, got %+v", sent[1].Details)
+	}
+}
@@ -0,0 +1,140 @@
+package crashreport
+
+import (
+	"math"
+	"runtime/metrics"
+	"strconv"
+)
+
+// Runtime metrics attached by WithRuntimeMetrics by default
+const (
+	MetricGoroutines  = "/sched/goroutines:goroutines"
+	MetricHeapObjects = "/gc/heap/objects:objects"
+	MetricGCPauses    = "/gc/pauses:seconds"
+)
+
+// defaultRuntimeMetrics are the metrics of WithRuntimeMetrics without arguments
+var defaultRuntimeMetrics = []string{MetricGoroutines, MetricHeapObjects, MetricGCPauses}
+
+// WithRuntimeMetrics attaches a snapshot of runtime metrics to the reports, under the "runtimeMetrics" key of the custom
+// data, by name. The names are the ones of the runtime/metrics package, by default the number of goroutines, the
+// number of heap objects and the distribution of the GC pauses; the names unknown to the go version are skipped. The
+// histograms, such as the GC pauses, are summarized by their median, 99th percentile and maximum, in the unit of the
+// metric.
+//
+// The reports are also tagged with a bucket of the number of goroutines, from "goroutines:<100" to
+// "goroutines:>=10k", and of the 99th percentile of the GC pauses, from "gc-pause:<1ms" to "gc-pause:>=100ms", when
+// these metrics are attached. Unlike the memory stats of WithMemoryPressure, reading the metrics doesn't stop the
+// world.
+func WithRuntimeMetrics(names ...string) Option {
+	return func(r *Reporter) {
+		if len(names) == 0 {
+			names = defaultRuntimeMetrics
+		}
+		supported := map[string]bool{}
+		for _, desc := range metrics.All() {
+			supported[desc.Name] = true
+		}
+		var samples []metrics.Sample
+		for _, name := range names {
+			if supported[name] {
+				samples = append(samples, metrics.Sample{Name: name})
+			}
+		}
+
+		r.enrichers = append(r.enrichers, func(post *Post) {
+			snapshot := make([]metrics.Sample, len(samples))
+			copy(snapshot, samples)
+			metrics.Read(snapshot)
+
+			values := make(map[string]interface{}, len(snapshot))
+			for _, sample := range snapshot {
+				values[sample.Name] = metricValue(sample.Value)
+			}
+			post.SetCustomData("runtimeMetrics", values)
+
+			if n, ok := values[MetricGoroutines].(uint64); ok {
+				post.Details.Tags = append(post.Details.Tags, "goroutines:"+goroutinesBucket(n))
+			}
+			if pauses, ok := values[MetricGCPauses].(map[string]float64); ok {
+				post.Details.Tags = append(post.Details.Tags, "gc-pause:"+pauseBucket(pauses["p99"]))
+			}
+		})
+	}
+}
+
+// metricValue converts the value of a metric for the custom data
+func metricValue(v metrics.Value) interface{} {
+	switch v.Kind() {
+	case metrics.KindUint64:
+		return v.Uint64()
+	case metrics.KindFloat64:
+		return v.Float64()
+	case metrics.KindFloat64Histogram:
+		h := v.Float64Histogram()
+		return map[string]float64{
+			"p50": histogramQuantile(h, 0.5),
+			"p99": histogramQuantile(h, 0.99),
+			"max": histogramQuantile(h, 1),
+		}
+	default:
+		return nil
+	}
+}
+
+// histogramQuantile returns the upper bound of the bucket holding the quantile q of the histogram, or its lower bound
+// for the last bucket, whose upper bound is infinite
+func histogramQuantile(h *metrics.Float64Histogram, q float64) float64 {
+	var total uint64
+	for _, count := range h.Counts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for i, count := range h.Counts {
+		seen += count
+		if count > 0 && seen >= rank {
+			if upper := h.Buckets[i+1]; !math.IsInf(upper, 1) {
+				return upper
+			}
+			return h.Buckets[i]
+		}
+	}
+	return 0
+}
+
+// goroutinesBucket returns the tag bucket of a number of goroutines
+func goroutinesBucket(n uint64) string {
+	for _, max := range []uint64{100, 1000, 10000} {
+		if n < max {
+			return "<" + metricBucketName(max)
+		}
+	}
+	return ">=10k"
+}
+
+// metricBucketName writes the thousands of a bucket with a k
+func metricBucketName(n uint64) string {
+	if n >= 1000 {
+		return strconv.FormatUint(n/1000, 10) + "k"
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+// pauseBucket returns the tag bucket of a GC pause in seconds
+func pauseBucket(seconds float64) string {
+	switch {
+	case seconds < 0.001:
+		return "<1ms"
+	case seconds < 0.01:
+		return "<10ms"
+	case seconds < 0.1:
+		return "<100ms"
+	default:
+		return ">=100ms"
+	}
+}
@@ -0,0 +1,238 @@
+package crashreport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// redacted replaces the values that must not be sent to Raygun
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are the headers whose values are never reported
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Apikey":            true,
+}
+
+// MiddlewareOption configures the http middleware
+type MiddlewareOption func(*middleware)
+
+// WithResponseHeaders makes the middleware attach the response headers to the report, under the "responseHeaders" key
+// of the custom data. Sensitive headers such as Set-Cookie are redacted.
+func WithResponseHeaders() MiddlewareOption {
+	return func(m *middleware) {
+		m.responseHeaders = true
+	}
+}
+
+// WithRequestBreadcrumbs makes the middleware add a breadcrumb for the request to the report, with its method, path,
+// status and duration. The request context collects breadcrumbs, so that handlers can add their own, for example with
+// RequestBreadcrumb for the downstream calls. The options bound the breadcrumbs, see WithContextBreadcrumbLimits.
+func WithRequestBreadcrumbs(opts ...BreadcrumbOption) MiddlewareOption {
+	return func(m *middleware) {
+		m.requestBreadcrumbs = true
+		m.breadcrumbOptions = opts
+	}
+}
+
+type middleware struct {
+	reporter *Reporter
+	next     http.Handler
+
+	responseHeaders    bool
+	requestBreadcrumbs bool
+	breadcrumbOptions  []BreadcrumbOption
+	tlsInfo            bool
+	route              func(*http.Request) string
+	handler            func(*http.Request) http.Handler
+}
+
+// Middleware wraps an http.Handler, recovering its panics. A recovered panic is reported together with the request and
+// the response status, and answered with a 500 if nothing was written yet. The request body is recorded as the
+// handler reads it, see FromReq.
+// The request is stored in its own context, so that handlers can report errors with ReportFromContext.
+//
+// The reports of the middleware, and the ones of its handlers through ReportFromContext or ReportCtx, have the status
+// of the response: in Response.StatusCode, under the "statusCode" key of the custom data and as a tag of its class,
+// such as "http.status:5xx". The status of a handler that wrote nothing yet is 200, as net/http would answer.
+func (r *Reporter) Middleware(next http.Handler, opts ...MiddlewareOption) http.Handler {
+	m := &middleware{reporter: r, next: next}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *middleware) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK, snapshot: m.responseHeaders}
+	start := time.Now()
+
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		if !rec.wroteHeader {
+			rec.WriteHeader(http.StatusInternalServerError)
+		}
+
+		post := m.reporter.newPost()
+		post.Details.Error = FromPanic(v)
+		post.err = panicError(v)
+		post.panicked = true
+		post.Details.Request = fromReq(req, m.reporter.headers)
+		var template string
+		if m.route != nil {
+			if template = m.route(req); template != "" {
+				post.Details.Request.SetRoute(template, req.URL.Path)
+			}
+		}
+		if m.handler != nil {
+			m.identify(&post, req, template)
+		}
+		setResponseStatus(&post, rec.status)
+		if m.responseHeaders {
+			post.SetCustomData("responseHeaders", scrubHeaders(rec.header))
+		}
+		if m.tlsInfo {
+			WithRequestTLS(req)(&post)
+		}
+		if m.requestBreadcrumbs {
+			AddBreadcrumb(req.Context(), RequestBreadcrumb(req, rec.status, start))
+			post.Details.Breadcrumbs = BreadcrumbsFromContext(req.Context())
+		}
+		m.reporter.send(post)
+	}()
+
+	if req.Body != nil && req.Body != http.NoBody {
+		captureBody(req)
+	}
+	ctx := context.WithValue(ContextWithRequest(req.Context(), req), responseKey, rec)
+	if m.requestBreadcrumbs {
+		ctx = ContextWithBreadcrumbs(ctx, m.breadcrumbOptions...)
+	}
+	req = req.WithContext(ctx)
+	m.next.ServeHTTP(rec, req)
+}
+
+// identify sets the identifier of the post to the name of the handler of the request, or to the route template
+func (m *middleware) identify(post *Post, req *http.Request, template string) {
+	h := m.handler(req)
+	if h == nil {
+		h = m.next
+	}
+	if name := HandlerName(h); name != "" {
+		post.Details.Context.Identifier = name
+	} else if template != "" {
+		post.Details.Context.Identifier = template
+	}
+}
+
+// RequestBreadcrumb returns a breadcrumb of category "request" for a request that started at start and got the given
+// status, for example a call to another service:
+//
+//	start := time.Now()
+//	resp, err := client.Do(req)
+//	if err == nil {
+//		crashreport.AddBreadcrumb(ctx, crashreport.RequestBreadcrumb(req, resp.StatusCode, start))
+//	}
+func RequestBreadcrumb(req *http.Request, status int, start time.Time) Breadcrumb {
+	level := BreadcrumbInfo
+	if status >= 500 {
+		level = BreadcrumbError
+	} else if status >= 400 {
+		level = BreadcrumbWarning
+	}
+
+	return Breadcrumb{
+		Message:  fmt.Sprintf("%s %s %d", req.Method, req.URL.Path, status),
+		Category: "request",
+		Type:     "request",
+		CustomData: map[string]interface{}{
+			"method":     req.Method,
+			"path":       req.URL.Path,
+			"status":     status,
+			"durationMs": time.Since(start).Milliseconds(),
+		},
+		Timestamp: millis(start),
+		Level:     level,
+	}
+}
+
+// setResponseStatus sets the status of the response of the post, with its tag and custom data
+func setResponseStatus(post *Post, status int) {
+	post.Details.Response.StatusCode = status
+	post.SetCustomData("statusCode", status)
+	if tag := "http.status:" + strconv.Itoa(status/100) + "xx"; !hasTag(post.Details.Tags, tag) {
+		post.Details.Tags = append(post.Details.Tags, tag)
+	}
+}
+
+// panicError converts a recovered value to an error
+func panicError(v interface{}) error {
+	if err, ok := v.(error); ok {
+		return err
+	}
+	return fmt.Errorf("panic: %v", v)
+}
+
+// scrubHeaders flattens the headers, redacting the sensitive ones
+func scrubHeaders(header http.Header) map[string]string {
+	headers := arrayMapToStringMap(header)
+	for k := range headers {
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] {
+			headers[k] = redacted
+		}
+	}
+	return headers
+}
+
+// responseRecorder wraps a ResponseWriter to remember the status code. If snapshot is set it also copies the headers
+// at the moment they are written, since later changes never reach the client.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	snapshot    bool
+	header      http.Header
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.status = code
+		rec.wroteHeader = true
+		if rec.snapshot {
+			rec.header = rec.ResponseWriter.Header().Clone()
+		}
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Flush sends the buffered data to the client if the ResponseWriter supports it, for http.Flusher. It writes the
+// status 200 if nothing was written yet.
+func (rec *responseRecorder) Flush() {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the original ResponseWriter, for http.ResponseController
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
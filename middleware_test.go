@@ -0,0 +1,182 @@
+package crashreport
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareResponseHeaders(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	handler := reporter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Header().Set("X-Too-Late", "never sent")
+		w.Write([]byte("{}"))
+		panic("boom")
+	}), WithResponseHeaders())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/path", nil))
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	post := sent[0]
+	if post.Details.Response.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status should be 503, got %d", post.Details.Response.StatusCode)
+	}
+
+	data := post.Details.UserCustomData.(map[string]interface{})
+	headers := data["responseHeaders"].(map[string]interface{})
+	if headers["Content-Type"] != "application/json" {
+		t.Errorf("Content-Type should be captured, got %v", headers["Content-Type"])
+	}
+	if headers["Set-Cookie"] != redacted {
+		t.Errorf("Set-Cookie should be redacted, got %v", headers["Set-Cookie"])
+	}
+	if _, ok := headers["X-Too-Late"]; ok {
+		t.Error("headers set after WriteHeader are not sent and should not be captured")
+	}
+}
+
+func TestMiddlewareResponseHeadersImplicitWrite(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	handler := reporter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Error-Code", "E42")
+		w.Write([]byte("partial"))
+		panic("boom")
+	}), WithResponseHeaders())
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/path", nil))
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	headers := sent[0].Details.UserCustomData.(map[string]interface{})["responseHeaders"].(map[string]interface{})
+	if headers["X-Error-Code"] != "E42" {
+		t.Errorf("X-Error-Code should be captured, got %v", headers["X-Error-Code"])
+	}
+}
+
+func TestMiddlewareWithoutResponseHeaders(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	handler := reporter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/path", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status should be 500, got %d", w.Code)
+	}
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	if _, ok := sent[0].Details.UserCustomData.(map[string]interface{})["responseHeaders"]; ok {
+		t.Error("response headers should be opt-in")
+	}
+}
+
+func TestMiddlewareRequestBreadcrumbs(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	handler := reporter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		downstream := httptest.NewRequest("POST", "http://billing/charge", nil)
+		AddBreadcrumb(req.Context(), RequestBreadcrumb(downstream, http.StatusBadGateway, time.Now()))
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusConflict)
+		panic("boom")
+	}), WithRequestBreadcrumbs())
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/orders/42?force=1", nil))
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	breadcrumbs := sent[0].Details.Breadcrumbs
+	if len(breadcrumbs) != 2 {
+		t.Fatalf("expected the downstream and the inbound request breadcrumbs, got %v", breadcrumbs)
+	}
+	if breadcrumbs[0].Message != "POST /charge 502" || breadcrumbs[0].Level != BreadcrumbError {
+		t.Errorf("unexpected downstream breadcrumb %+v", breadcrumbs[0])
+	}
+
+	inbound := breadcrumbs[1]
+	if inbound.Category != "request" || inbound.Message != "PUT /orders/42 409" || inbound.Level != BreadcrumbWarning {
+		t.Errorf("unexpected request breadcrumb %+v", inbound)
+	}
+	data := inbound.CustomData.(map[string]interface{})
+	if data["method"] != "PUT" || data["path"] != "/orders/42" || data["status"] != float64(409) {
+		t.Errorf("unexpected request breadcrumb data %v", data)
+	}
+	if data["durationMs"].(float64) < 10 {
+		t.Errorf("expected a duration of at least 10ms, got %v", data["durationMs"])
+	}
+	if inbound.Timestamp == 0 {
+		t.Error("expected the request start as timestamp")
+	}
+}
+
+func TestMiddlewareStatusTag(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	handlers := []struct {
+		handler http.HandlerFunc
+		tag     string
+		status  int
+	}{
+		{func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			reporter.ReportFromContext(req.Context(), errors.New("unknown order"))
+		}, "http.status:4xx", 404},
+		{func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte("partial"))
+			reporter.ReportCtx(req.Context(), errors.New("slow query"))
+		}, "http.status:2xx", 200},
+		{func(w http.ResponseWriter, req *http.Request) {
+			reporter.ReportFromContext(req.Context(), errors.New("nothing written"))
+		}, "http.status:2xx", 200},
+		{func(w http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		}, "http.status:5xx", 500},
+	}
+	for _, h := range handlers {
+		reporter.Middleware(h.handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders", nil))
+	}
+
+	sent := server.Posts()
+	if len(sent) != len(handlers) {
+		t.Fatalf("expected %d posts, got %d", len(handlers), len(sent))
+	}
+	for i, h := range handlers {
+		details := sent[i].Details
+		data := details.UserCustomData.(map[string]interface{})
+		if !hasTag(details.Tags, h.tag) || details.Response.StatusCode != h.status ||
+			data["statusCode"] != float64(h.status) {
+			t.Errorf("%s: expected the tag %s and the status %d, got %v %d %v", details.Error.Message, h.tag,
+				h.status, details.Tags, details.Response.StatusCode, data["statusCode"])
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package crashreport
+
+import "time"
+
+// maxFutureOccurrence is how far in the future WithOccurredOn accepts a time, for the clocks out of sync
+const maxFutureOccurrence = time.Hour
+
+// WithOccurredOn sets the time the error occurred on, instead of the time of the report, for the events replayed from a
+// backlog. The time is converted to UTC. A time more than an hour in the future is ignored, as it can only be a
+// mistake: it's kept under the "invalidOccurredOn" key of the custom data instead.
+//
+// WithDebounce and WithAffectedUsers compare the times of the occurrences: an occurrence replayed a window after the
+// one waiting for its timer ends the window early, instead of being merged into it.
+func WithOccurredOn(t time.Time) ReportOption {
+	return func(post *Post) {
+		if t.After(time.Now().Add(maxFutureOccurrence)) {
+			post.SetCustomData("invalidOccurredOn", t.UTC().Format(TimeFormat))
+			return
+		}
+		post.OccuredOn = t.UTC().Format(TimeFormat)
+	}
+}
+
+// occurredAt returns the time the error of the post occurred on, or now if OccuredOn isn't valid
+func occurredAt(post Post) time.Time {
+	if t, err := post.OccurredTime(); err == nil {
+		return t
+	}
+	return time.Now()
+}
@@ -0,0 +1,56 @@
+package crashreport
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithOccurredOn(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	past := time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+	future := time.Now().Add(24 * time.Hour)
+
+	reporter := NewReporter("key")
+	reporter.Report(errors.New("replayed"), WithOccurredOn(past))
+	reporter.Report(errors.New("from the future"), WithOccurredOn(future))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	if sent[0].OccuredOn != "2024-03-01T11:30:00.000Z" {
+		t.Errorf("expected the replayed time in UTC, got %s", sent[0].OccuredOn)
+	}
+	if occurred, err := sent[1].OccurredTime(); err != nil || time.Since(occurred) > time.Minute {
+		t.Errorf("expected a time in the future to be ignored, got %s", sent[1].OccuredOn)
+	}
+	data := sent[1].Details.UserCustomData.(map[string]interface{})
+	if data["invalidOccurredOn"] != future.UTC().Format(TimeFormat) {
+		t.Errorf("expected the invalid time in the custom data, got %v", data)
+	}
+}
+
+func TestWithOccurredOnDebounce(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	reporter := NewReporter("key", WithDebounce(time.Hour))
+	reporter.Report(errors.New("replayed"), WithOccurredOn(start))
+	reporter.Report(errors.New("replayed"), WithOccurredOn(start.Add(time.Minute)))
+	reporter.Report(errors.New("replayed"), WithOccurredOn(start.Add(2*time.Hour)))
+
+	sent := server.Posts()
+	if len(sent) != 1 || sent[0].OccuredOn != "2024-03-01T12:01:00.000Z" {
+		t.Fatalf("expected the first window to be submitted by the replay of the next one, got %+v", sent)
+	}
+
+	reporter.Close(time.Second)
+	sent = server.Posts()
+	if len(sent) != 2 || sent[1].OccuredOn != "2024-03-01T14:00:00.000Z" {
+		t.Errorf("expected the second window to be submitted on close, got %+v", sent)
+	}
+}
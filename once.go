@@ -0,0 +1,74 @@
+package crashreport
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// WithReportOncePerFingerprint submits each error once per process: once a report is submitted successfully, the
+// later occurrences of its error are dropped for the lifetime of the reporter, and counted in Stats.Suppressed. It
+// suits the startup or configuration errors repeated by retry loops. Errors are identified by their Fingerprint, or by
+// their StackFingerprint with WithStackOnlyFingerprint. The occurrences reported before the first submission
+// succeeds, such as the ones of an asynchronous reporter still in the queue, are submitted.
+func WithReportOncePerFingerprint() Option {
+	return func(r *Reporter) {
+		r.once.submitted = map[string]bool{}
+	}
+}
+
+// once holds the fingerprints of the errors already submitted, see WithReportOncePerFingerprint
+type once struct {
+	mu        sync.Mutex
+	submitted map[string]bool
+}
+
+// suppressed tells if the error of the post was already submitted
+func (r *Reporter) suppressed(post Post) bool {
+	fingerprint := r.fingerprint(post)
+	r.once.mu.Lock()
+	submitted := r.once.submitted[fingerprint]
+	r.once.mu.Unlock()
+
+	if submitted {
+		atomic.AddInt64(&r.stats.suppressed, 1)
+	}
+	return submitted
+}
+
+// submittedOnce records that the error of the post was submitted
+func (r *Reporter) submittedOnce(post Post) {
+	fingerprint := r.fingerprint(post)
+	r.once.mu.Lock()
+	r.once.submitted[fingerprint] = true
+	r.once.mu.Unlock()
+}
+
+// ReportOnce returns a function running the initialization f once, like sync.OnceValue: the first call runs f, and
+// the later ones return its error without running it again. An error is reported once, tagged "init-failure", with
+// the function that called ReportOnce as identifier:
+//
+//	var initDB = crashreport.ReportOnce(reporter, func() error {
+//		return db.Ping()
+//	})
+//
+//	func handle() error {
+//		if err := initDB(); err != nil {
+//			return err
+//		}
+//		...
+//	}
+func ReportOnce(reporter *Reporter, f func() error) func() error {
+	site := caller(0)
+	var (
+		once sync.Once
+		err  error
+	)
+	return func() error {
+		once.Do(func() {
+			if err = f(); err != nil {
+				reporter.Report(err, WithTags("init-failure"), WithIdentifier(site))
+			}
+		})
+		return err
+	}
+}
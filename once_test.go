@@ -0,0 +1,61 @@
+package crashreport
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithReportOncePerFingerprint(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	var reasons []DropReason
+	reporter := NewReporter("key", WithReportOncePerFingerprint(), WithDropObserver(func(post Post, reason DropReason) {
+		reasons = append(reasons, reason)
+	}))
+	for i := 0; i < 3; i++ {
+		reporter.Report(errors.New("invalid configuration"))
+	}
+	reporter.Report(errors.New("another error"))
+
+	sent := server.Posts()
+	if len(sent) != 2 || sent[0].Details.Error.Message != "invalid configuration" {
+		t.Fatalf("expected each error to be submitted once, got %d posts", len(sent))
+	}
+	if suppressed := reporter.Stats().Suppressed; suppressed != 2 {
+		t.Errorf("expected 2 suppressed occurrences, got %d", suppressed)
+	}
+	if len(reasons) != 2 || reasons[0] != DropSuppressed {
+		t.Errorf("expected the occurrences to be dropped as suppressed, got %v", reasons)
+	}
+}
+
+func TestReportOnce(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	calls := 0
+	initialize := ReportOnce(NewReporter("key"), func() error {
+		calls++
+		return errors.New("database unreachable")
+	})
+	for i := 0; i < 3; i++ {
+		if err := initialize(); err == nil || err.Error() != "database unreachable" {
+			t.Errorf("call %d: expected the error of the initialization, got %v", i, err)
+		}
+	}
+
+	sent := server.Posts()
+	if calls != 1 || len(sent) != 1 {
+		t.Fatalf("expected a single initialization and report, got %d calls and %d posts", calls, len(sent))
+	}
+	if details := sent[0].Details; !hasTag(details.Tags, "init-failure") ||
+		details.Context.Identifier != "github.com/chennqqi/crashreport.TestReportOnce" {
+		t.Errorf("expected the init-failure tag and the initializing function, got %v %s", details.Tags,
+			details.Context.Identifier)
+	}
+
+	if err := ReportOnce(NewReporter("key"), func() error { return nil })(); err != nil || len(server.Posts()) != 1 {
+		t.Errorf("a successful initialization should not be reported, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package crashreport
+
+// defaultOperationHistory is the number of operations kept by default, see TrackOperation
+const defaultOperationHistory = 10
+
+// WithOperationHistory sets the number of operations kept by TrackOperation, 10 by default. 0 disables the tracking.
+func WithOperationHistory(n int) Option {
+	return func(r *Reporter) {
+		r.operations = newBreadcrumbRing(n)
+	}
+}
+
+// TrackOperation records an operation of the application, such as a database query or a call to another service,
+// with its metadata. The last operations are added to every report as breadcrumbs of category "operation", oldest
+// first, to see what the application was doing right before an error:
+//
+//	reporter.TrackOperation("db.query", map[string]interface{}{"table": "orders", "rows": n})
+//
+// The history is shared by the goroutines, and limited by WithOperationHistory. The metadata is copied.
+func (r *Reporter) TrackOperation(name string, meta map[string]interface{}) {
+	crumb := Breadcrumb{
+		Message:   name,
+		Category:  "operation",
+		Timestamp: millis(timeNow()),
+		Level:     BreadcrumbInfo,
+	}
+	if meta != nil {
+		crumb.CustomData = cloneValue(meta)
+	}
+	r.operations.add(crumb)
+}
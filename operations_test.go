@@ -0,0 +1,51 @@
+package crashreport
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestTrackOperation(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithOperationHistory(3))
+	reporter.Report(errors.New("before"))
+
+	meta := map[string]interface{}{"table": "orders"}
+	for i := 1; i <= 5; i++ {
+		meta["rows"] = i
+		reporter.TrackOperation(fmt.Sprintf("query %d", i), meta)
+	}
+	reporter.Report(errors.New("after"))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	if crumbs := sent[0].Details.Breadcrumbs; len(crumbs) != 0 {
+		t.Errorf("no operation was tracked before the first report, got %v", crumbs)
+	}
+
+	crumbs := sent[1].Details.Breadcrumbs
+	if len(crumbs) != 3 {
+		t.Fatalf("the history should keep the last 3 operations, got %d", len(crumbs))
+	}
+	for i, crumb := range crumbs {
+		if crumb.Message != fmt.Sprintf("query %d", i+3) || crumb.Category != "operation" {
+			t.Errorf("unexpected breadcrumb %d: %+v", i, crumb)
+		}
+		data := crumb.CustomData.(map[string]interface{})
+		if data["table"] != "orders" || data["rows"] != float64(i+3) {
+			t.Errorf("the metadata should be copied when tracked, got %v", data)
+		}
+	}
+
+	disabled := NewReporter("key", WithOperationHistory(0))
+	disabled.TrackOperation("ignored", nil)
+	disabled.Report(errors.New("untracked"))
+	if crumbs := server.Posts()[2].Details.Breadcrumbs; len(crumbs) != 0 {
+		t.Errorf("the tracking should be disabled, got %v", crumbs)
+	}
+}
@@ -0,0 +1,112 @@
+package crashreport
+
+import (
+	"strconv"
+	"sync/atomic"
+)
+
+// ReportOutcome tells what the pipeline of a reporter did with a report, see ReportResult
+type ReportOutcome int32
+
+// Outcomes of the reports
+const (
+	OutcomeSent    ReportOutcome = iota + 1 // submitted and accepted
+	OutcomeQueued                           // queued by WithAsync, or held by WithDebounce, WithAffectedUsers or a cool-down
+	OutcomeSampled                          // dropped by WithSampleRate or WithAdaptiveSampling
+	OutcomeDeduped                          // merged into another occurrence, or already submitted once
+	OutcomeDropped                          // dropped for another reason, see DropReason
+	OutcomeFailed                           // the submission failed, or the reporter couldn't take the report
+)
+
+func (o ReportOutcome) String() string {
+	switch o {
+	case OutcomeSent:
+		return "sent"
+	case OutcomeQueued:
+		return "queued"
+	case OutcomeSampled:
+		return "sampled"
+	case OutcomeDeduped:
+		return "deduped"
+	case OutcomeDropped:
+		return "dropped"
+	case OutcomeFailed:
+		return "failed"
+	default:
+		return "ReportOutcome(" + strconv.Itoa(int(o)) + ")"
+	}
+}
+
+// dropOutcome returns the outcome of a report dropped for the reason
+func dropOutcome(reason DropReason) ReportOutcome {
+	switch reason {
+	case DropSampled:
+		return OutcomeSampled
+	case DropDeduped, DropSuppressed:
+		return OutcomeDeduped
+	default:
+		return OutcomeDropped
+	}
+}
+
+// outcomeOf returns the outcome of a submission
+func outcomeOf(err error) ReportOutcome {
+	if err != nil {
+		return OutcomeFailed
+	}
+	return OutcomeSent
+}
+
+// ReportResult is Report, returning what the pipeline did with the report instead of an error, to fall back to
+// another logging when the report didn't make it out. The outcome of an asynchronous reporter is OutcomeQueued once
+// the report is queued, use WithDeliveryCallback for the final outcome. As with Report, the ignored errors return
+// OutcomeDropped.
+func (r *Reporter) ReportResult(err error, opts ...ReportOption) ReportOutcome {
+	post := r.newPost()
+	post.Details.Error = FromErr(err)
+	post.err = err
+	post.outcome = &outcomeRecorder{}
+	identify(&post, caller(0))
+
+	sendErr := r.send(post, opts...)
+	if outcome := post.outcome.load(); outcome != 0 {
+		return outcome
+	}
+	return outcomeOf(sendErr)
+}
+
+// WithDeliveryCallback sets a function called with the final outcome of the report, once it's submitted or dropped,
+// and the error of the submission if it failed. Unlike the result of ReportResult, it's never OutcomeQueued: it's
+// called by the worker of an asynchronous reporter, or when the timer of WithDebounce or WithAffectedUsers fires, so
+// it must be safe to call from another goroutine.
+func WithDeliveryCallback(callback func(outcome ReportOutcome, err error)) ReportOption {
+	return func(post *Post) {
+		if post.outcome == nil {
+			post.outcome = &outcomeRecorder{}
+		}
+		post.outcome.callback = callback
+	}
+}
+
+// outcomeRecorder records the outcome of a report as it goes through the pipeline. It's shared by the copies of the
+// post, and nil if nobody asked for the outcome.
+type outcomeRecorder struct {
+	outcome  int32 // atomic, the last ReportOutcome
+	callback func(outcome ReportOutcome, err error)
+}
+
+// record sets the outcome of the report, and calls the callback if it's final
+func (o *outcomeRecorder) record(outcome ReportOutcome, err error) {
+	if o == nil {
+		return
+	}
+	atomic.StoreInt32(&o.outcome, int32(outcome))
+	if outcome != OutcomeQueued && o.callback != nil {
+		safely("delivery callback", func() { o.callback(outcome, err) })
+	}
+}
+
+// load returns the last outcome of the report, zero if none was recorded
+func (o *outcomeRecorder) load() ReportOutcome {
+	return ReportOutcome(atomic.LoadInt32(&o.outcome))
+}
@@ -0,0 +1,70 @@
+package crashreport
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestReportResult(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	failing := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})}
+
+	tests := []struct {
+		name     string
+		reporter *Reporter
+		reports  int
+		want     ReportOutcome
+	}{
+		{"sent", NewReporter("key"), 1, OutcomeSent},
+		{"failed", NewReporter("key", WithClient(failing)), 1, OutcomeFailed},
+		{"sampled", NewReporter("key", WithSampleRate(0)), 1, OutcomeSampled},
+		{"debounced", NewReporter("key", WithDebounce(time.Hour)), 1, OutcomeQueued},
+		{"deduped", NewReporter("key", WithAffectedUsers(time.Hour)), 2, OutcomeDeduped},
+		{"vetoed", NewReporter("key", WithKeyRouter(func(Post) string { return "" })), 1, OutcomeDropped},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var outcome ReportOutcome
+			for i := 0; i < test.reports; i++ {
+				outcome = test.reporter.ReportResult(errors.New("outcome"))
+			}
+			if outcome != test.want {
+				t.Errorf("expected %s, got %s", test.want, outcome)
+			}
+		})
+	}
+}
+
+func TestReportResultAsync(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	delivered := make(chan ReportOutcome, 1)
+	reporter := NewReporter("key", WithAsync(1, 1))
+	outcome := reporter.ReportResult(errors.New("async"), WithDeliveryCallback(func(outcome ReportOutcome, err error) {
+		delivered <- outcome
+	}))
+	if outcome != OutcomeQueued {
+		t.Errorf("expected the report to be queued, got %s", outcome)
+	}
+
+	select {
+	case outcome := <-delivered:
+		if outcome != OutcomeSent {
+			t.Errorf("expected the report to be delivered, got %s", outcome)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the delivery callback wasn't called")
+	}
+	reporter.Close(time.Second)
+
+	if outcome := reporter.ReportResult(errors.New("closed")); outcome != OutcomeFailed {
+		t.Errorf("expected a closed reporter to fail, got %s", outcome)
+	}
+}
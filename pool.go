@@ -0,0 +1,84 @@
+package crashreport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBuffer is the capacity above which a buffer is left to the garbage collector instead of being pooled, so
+// that a few huge posts don't pin memory
+const maxPooledBuffer = 1 << 20
+
+// bufferPool holds the buffers of the json bodies, see getBuffer
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool. Buffers grow to the size of the posts they hold, so the pool adapts
+// to the usual size of the posts.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer gives the buffer back to the pool. It must not be used afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// pooledBuffer is a pooled buffer shared by the bodies of a request: the first one, and the ones of GetBody for the
+// redirects and the retries of the transport. It goes back to the pool once the caller and every body released it.
+// The transport may still read a body after the response arrives, so the buffer can't be put back when Do returns.
+type pooledBuffer struct {
+	buf  *bytes.Buffer
+	refs int32
+}
+
+// newPooledBuffer returns the shared buffer, held by the caller until it calls release
+func newPooledBuffer(buf *bytes.Buffer) *pooledBuffer {
+	return &pooledBuffer{buf: buf, refs: 1}
+}
+
+// body returns a new body reading the buffer from its start, holding the buffer until it's closed
+func (p *pooledBuffer) body() *pooledBody {
+	atomic.AddInt32(&p.refs, 1)
+	return &pooledBody{Reader: bytes.NewReader(p.buf.Bytes()), shared: p}
+}
+
+// release gives the buffer back to the pool if it was the last holder
+func (p *pooledBuffer) release() {
+	if atomic.AddInt32(&p.refs, -1) == 0 {
+		putBuffer(p.buf)
+	}
+}
+
+// pooledBody is the body of a request, releasing its pooled buffer when the transport closes it
+type pooledBody struct {
+	*bytes.Reader
+	shared *pooledBuffer
+	once   sync.Once
+}
+
+func (b *pooledBody) Close() error {
+	b.once.Do(b.shared.release)
+	return nil
+}
+
+// gzipPool holds the gzip writers of the disk queue and of the goroutine dumps, which are expensive to allocate
+var gzipPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// getGzipWriter returns a gzip writer from the pool, writing to w
+func getGzipWriter(w io.Writer) *gzip.Writer {
+	gz := gzipPool.Get().(*gzip.Writer)
+	gz.Reset(w)
+	return gz
+}
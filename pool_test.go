@@ -0,0 +1,89 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubmitBufferNotReusedInFlight(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(time.Millisecond) // let other submissions run while the body is pending
+		body, _ := ioutil.ReadAll(req.Body)
+		var post Post
+		if err := json.Unmarshal(body, &post); err != nil {
+			t.Errorf("corrupted body: %s", err)
+		}
+		mu.Lock()
+		received[post.Details.Error.Message] = true
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			post := NewPost()
+			post.Details.Error = Error{Message: fmt.Sprintf("error %d", i)}
+			post.SetCustomData("padding", make([]int, i*100))
+			if err := SubmitToUrl(post, server.URL, "key", nil); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(received) != 50 {
+		t.Errorf("expected 50 distinct posts, got %d", len(received))
+	}
+}
+
+func TestSubmitRedirect(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		if req.URL.Path == "/entries" {
+			http.Redirect(w, req, "/moved", http.StatusTemporaryRedirect)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	post := NewPost()
+	post.Details.Error = Error{Message: "redirected"}
+	if err := SubmitToUrl(post, server.URL+"/entries", "key", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 || bodies[1] == "" || bodies[1] != bodies[0] {
+		t.Errorf("expected the body to be sent again after the redirect, got %q", bodies)
+	}
+}
+
+func BenchmarkSubmit(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ioutil.ReadAll(req.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	reporter := NewReporter("key", WithEndpoint(server.URL))
+	err := errors.New("benchmark")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reporter.Report(err)
+	}
+}
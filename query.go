@@ -0,0 +1,30 @@
+package crashreport
+
+import (
+	"fmt"
+)
+
+// WithQuery attaches the sql query that failed to the error, under the "query" key of Data. The arguments are
+// replaced by their types under the "args" key, so that no user data is sent. See WithUnredactedQuery to send them.
+func (e *Error) WithQuery(query string, args ...interface{}) *Error {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = fmt.Sprintf("%T", arg)
+	}
+	e.SetData("query", query)
+	e.SetData("args", types)
+	return e
+}
+
+// WithUnredactedQuery is like WithQuery, but sends the values of the arguments. Only use it when the arguments can't
+// contain personal data.
+//
+//	e := crashreport.FromErr(err)
+//	reporter.Report(e.WithUnredactedQuery("SELECT * FROM orders WHERE id = ?", id))
+func (e *Error) WithUnredactedQuery(query string, args ...interface{}) *Error {
+	values := make([]interface{}, len(args))
+	copy(values, args)
+	e.SetData("query", query)
+	e.SetData("args", values)
+	return e
+}
@@ -0,0 +1,28 @@
+package crashreport
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestErrorWithQuery(t *testing.T) {
+	query := "SELECT * FROM users WHERE email = ? AND age > ?"
+
+	redacted := FromErr(errors.New("query failed"))
+	redacted.WithQuery(query, "john@example.com", 42, nil)
+	data := redacted.Data.(map[string]interface{})
+	if data["query"] != query {
+		t.Errorf("query should be attached, got %v", data["query"])
+	}
+	if !reflect.DeepEqual(data["args"], []string{"string", "int", "<nil>"}) {
+		t.Errorf("args should be redacted to their types, got %v", data["args"])
+	}
+
+	unredacted := FromErr(errors.New("query failed"))
+	unredacted.WithUnredactedQuery(query, "john@example.com", 42)
+	data = unredacted.Data.(map[string]interface{})
+	if !reflect.DeepEqual(data["args"], []interface{}{"john@example.com", 42}) {
+		t.Errorf("args should be sent when unredacted, got %v", data["args"])
+	}
+}
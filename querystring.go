@@ -0,0 +1,41 @@
+package crashreport
+
+import (
+	"strings"
+)
+
+// sensitiveQueryParams are the parameters of the query always redacted by WithStripQueryString, in lowercase
+var sensitiveQueryParams = []string{"token", "access_token", "refresh_token", "password", "secret", "api_key", "apikey",
+	"signature"}
+
+// WithStripQueryString removes the query from the url of the reported requests, since it often carries tokens or ids
+// that are sensitive and split the grouping. The parameters stay in the QueryString of the request, with the values
+// of the sensitive ones, such as token or password, and of the scrub ones replaced by "[REDACTED]". The names are
+// compared case-insensitively.
+func WithStripQueryString(scrub ...string) Option {
+	return func(r *Reporter) {
+		r.stripQuery = map[string]bool{}
+		for _, name := range append(sensitiveQueryParams, scrub...) {
+			r.stripQuery[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// applyStripQuery removes the query from the url of the request of the post, and redacts its sensitive parameters
+func (r *Reporter) applyStripQuery(post *Post) {
+	request := &post.Details.Request
+	if i := strings.IndexByte(request.URL, '?'); i >= 0 {
+		request.URL = request.URL[:i]
+	}
+	if len(request.QueryString) == 0 {
+		return
+	}
+	query := make(map[string]string, len(request.QueryString))
+	for name, value := range request.QueryString {
+		if r.stripQuery[strings.ToLower(name)] {
+			value = redacted
+		}
+		query[name] = value
+	}
+	request.QueryString = query
+}
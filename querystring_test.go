@@ -0,0 +1,37 @@
+package crashreport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStripQueryString(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithStripQueryString("Email"))
+	handler := reporter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest("GET", "/orders?Token=secret&email=a@example.com&page=2", nil))
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	request := sent[0].Details.Request
+	if request.URL != "/orders" {
+		t.Errorf("expected the url without its query, got %s", request.URL)
+	}
+	expected := map[string]string{"Token": redacted, "email": redacted, "page": "2"}
+	if len(request.QueryString) != len(expected) {
+		t.Errorf("expected the scrubbed query %v, got %v", expected, request.QueryString)
+	}
+	for name, value := range expected {
+		if request.QueryString[name] != value {
+			t.Errorf("expected %s=%s, got %v", name, value, request.QueryString)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package crashreport
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRateLimited is returned by the reports over the rate set with WithGlobalRateLimit
+var ErrRateLimited = errors.New("crashreport: rate limited")
+
+// WithGlobalRateLimit caps the number of reports submitted per second, whatever their error, with a token bucket:
+// bursts of perSecond reports go through, then perSecond reports per second. The reports over the rate are dropped
+// with DropRateLimited, fail with ErrRateLimited, and are counted in Stats().GlobalRateLimited. It's the blunt
+// instrument for incidents, when many different errors would flood Raygun and the network.
+// It applies after the sampling and the aggregation, to the reports about to be submitted or queued.
+func WithGlobalRateLimit(perSecond int) Option {
+	return func(r *Reporter) {
+		r.rateLimit = &tokenBucket{rate: float64(perSecond), tokens: float64(perSecond), at: timeNow()}
+	}
+}
+
+// tokenBucket allows rate events per second, in bursts of rate events at most
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	at     time.Time // of the last update of tokens
+}
+
+// take consumes a token, if there is one
+func (b *tokenBucket) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := timeNow()
+	b.tokens += now.Sub(b.at).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.at = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimited tells if the post is over the global rate, and drops it
+func (r *Reporter) rateLimited(post Post) bool {
+	if r.rateLimit == nil || r.rateLimit.take() {
+		return false
+	}
+	atomic.AddInt64(&r.stats.globalRateLimited, 1)
+	r.dropped(post, DropRateLimited)
+	return true
+}
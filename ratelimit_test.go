@@ -0,0 +1,52 @@
+package crashreport
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithGlobalRateLimit(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	clock := time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return clock }
+
+	sink := &countingSink{counts: map[string]int{}}
+	drops := &dropRecorder{reasons: map[DropReason]int{}}
+	reporter := NewReporter("key", WithSink(sink), WithGlobalRateLimit(10), WithDropObserver(drops.observe))
+
+	burst := func(n int) (limited int) {
+		for i := 0; i < n; i++ {
+			if err := reporter.Report(errors.New("incident")); err == ErrRateLimited {
+				limited++
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return limited
+	}
+
+	if limited := burst(50); limited != 40 {
+		t.Errorf("a burst of 10 reports should go through, got %d limited", 50-limited)
+	}
+	for i := 0; i < 5; i++ {
+		clock = clock.Add(300 * time.Millisecond)
+		if limited := burst(10); limited != 7 {
+			t.Errorf("3 reports should be submitted per 300ms, got %d", 10-limited)
+		}
+	}
+	clock = clock.Add(time.Minute)
+	if limited := burst(20); limited != 10 {
+		t.Errorf("the bucket should refill up to the burst, got %d submitted", 20-limited)
+	}
+
+	if sent := sink.counts["incident"]; sent != 10+15+10 {
+		t.Errorf("expected 35 submissions, got %d", sent)
+	}
+	if n := reporter.Stats().GlobalRateLimited; n != 40+35+10 {
+		t.Errorf("expected 85 rate limited reports, got %d", n)
+	}
+	if n := drops.Count(DropRateLimited); n != 85 {
+		t.Errorf("the limited reports should be dropped as rate limited, got %d", n)
+	}
+}
@@ -0,0 +1,126 @@
+package crashreport
+
+import (
+	"net/http"
+)
+
+// WithSwallowPanics makes RecoverRequest stop the panics it reports, instead of panicking again with the same value
+func WithSwallowPanics() Option {
+	return func(r *Reporter) {
+		r.swallowPanics = true
+	}
+}
+
+// FromPanic creates an error struct from a value recovered from a panic, as FromErr does for an error. It must be
+// called in the deferred function that recovered the panic: the stacktrace then starts at the function that
+// panicked, instead of the deferred functions and the runtime. A value that is an error is converted by FromErr, with
+// its inner errors, class and data, and keeps its own stacktrace if it carries one: panicking with an error reports it
+// as returning it does. Values that are not errors get the message "panic: <value>".
+func FromPanic(v interface{}) Error {
+	e := FromErr(panicError(v))
+	e.StackTrace = panicStack(e.StackTrace)
+	return e
+}
+
+// panicStack cuts the frames above the function that panicked, from a stacktrace taken while recovering: the ones of
+// the deferred functions, and the ones of the runtime that raised the panic, such as runtime.sigpanic for a nil
+// pointer dereference. The stacktrace is returned as it is if it has no panic frame.
+func panicStack(stack StackTrace) StackTrace {
+	for i, frame := range stack {
+		if frame.PackageName != "runtime" || (frame.MethodName != "panic" && frame.MethodName != "gopanic") {
+			continue
+		}
+		for i < len(stack) && stack[i].PackageName == "runtime" {
+			i++
+		}
+		if i == len(stack) {
+			return stack
+		}
+		return stack[i:]
+	}
+	return stack
+}
+
+// CapturePanic reports a value recovered from a panic, with the stacktrace starting at the function that panicked, as
+// FromPanic does: the frames of the deferred functions and of the runtime above the panic are found and cut. It must
+// be called in the deferred function that recovered the panic, possibly after other work:
+//
+//	defer func() {
+//		if v := recover(); v != nil {
+//			rollback()
+//			reporter.CapturePanic(v)
+//		}
+//	}()
+//
+// When a deferred function panicked again while the first panic was running, the stacktrace starts at that function,
+// the site of the panic that was recovered. See CapturePanicAt for an explicit control of the frames.
+func (r *Reporter) CapturePanic(v interface{}, opts ...ReportOption) error {
+	post := r.newPost()
+	post.Details.Error = FromPanic(v)
+	post.err = panicError(v)
+	post.panicked = true
+	return r.send(post, opts...)
+}
+
+// CapturePanicAt is CapturePanic with an explicit start of the stacktrace: the function calling CapturePanicAt, skip
+// frames higher. The frames of the runtime, such as runtime.gopanic, are not counted and are cut from the top, so
+// that a skip of 1 in the deferred function that recovered starts at the function that panicked, and each deferred
+// function in between, such as a helper doing the recovery, counts for one more. Errors carrying their own
+// stacktrace keep it.
+func (r *Reporter) CapturePanicAt(v interface{}, skip int, opts ...ReportOption) error {
+	err := panicError(v)
+	post := r.newPost()
+	post.Details.Error = FromErr(err)
+	if !carriesStack(err) {
+		post.Details.Error.StackTrace = skipFrames(callers(0), skip)
+	}
+	post.err = err
+	post.panicked = true
+	return r.send(post, opts...)
+}
+
+// skipFrames cuts the top skip frames outside of the runtime from the stacktrace, and the runtime frames above the
+// next one
+func skipFrames(stack StackTrace, skip int) StackTrace {
+	i := 0
+	for ; i < len(stack) && skip > 0; i++ {
+		if stack[i].PackageName != "runtime" {
+			skip--
+		}
+	}
+	for i < len(stack) && stack[i].PackageName == "runtime" {
+		i++
+	}
+	return stack[i:]
+}
+
+// RecoverRequest recovers a panic and reports it with the request, like the middleware does, for the servers that
+// can't use the middleware. It must be deferred directly:
+//
+//	func (s *server) handle(ctx *framework.Context) {
+//		defer reporter.RecoverRequest(ctx.Request)
+//		...
+//	}
+//
+// Once reported, the panic goes on with the same value, for the recovery of the server, unless WithSwallowPanics is
+// set. The report is submitted before, even for an asynchronous reporter.
+func (r *Reporter) RecoverRequest(req *http.Request, opts ...ReportOption) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	post := r.newPost()
+	post.Details.Error = FromPanic(v)
+	post.err = panicError(v)
+	post.panicked = true
+	if req != nil {
+		post.Details.Request = fromReq(req, r.headers)
+	}
+	r.send(post, opts...)
+
+	if !r.swallowPanics {
+		r.Flush(fatalTimeout)
+		panic(v)
+	}
+}
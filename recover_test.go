@@ -0,0 +1,128 @@
+package crashreport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	pkerr "github.com/pkg/errors"
+)
+
+// legacyHandler is a handler of a framework without middlewares, writing to a nil map
+func legacyHandler(reporter *Reporter, req *http.Request) {
+	defer reporter.RecoverRequest(req)
+	var counts map[string]int
+	counts[req.URL.Path]++
+}
+
+func TestRecoverRequest(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	req := httptest.NewRequest("POST", "/orders?id=42", strings.NewReader("quantity=3"))
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		legacyHandler(NewReporter("key"), req)
+	}()
+	if recovered == nil {
+		t.Error("the panic should go on after the report")
+	}
+
+	func() {
+		defer NewReporter("key", WithSwallowPanics()).RecoverRequest(nil)
+		panic("swallowed")
+	}()
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	e := sent[0].Details.Error
+	if !strings.Contains(e.Message, "assignment to entry in nil map") {
+		t.Errorf("the report should have the panic message, got %q", e.Message)
+	}
+	if frame := e.StackTrace[0]; frame.MethodName != "legacyHandler" {
+		t.Errorf("the stacktrace should start where the panic happened, got %+v", frame)
+	}
+	request := sent[0].Details.Request
+	if request.HTTPMethod != "POST" || request.URL != "/orders?id=42" || request.RawData == nil {
+		t.Errorf("the report should have the request, got %+v", request)
+	}
+
+	if e := sent[1].Details.Error; e.Message != "panic: swallowed" || !strings.HasPrefix(e.StackTrace[0].MethodName, "TestRecoverRequest") {
+		t.Errorf("unexpected report of the swallowed panic %q at %+v", e.Message, e.StackTrace[0])
+	}
+}
+
+// closeLedger panics in a deferred function, while cleaning up
+func closeLedger() {
+	defer func() {
+		var balances map[string]int
+		balances["closed"]++
+	}()
+}
+
+// settle recovers the panic of closeLedger after running another deferred function, with CapturePanic or
+// CapturePanicAt when skip is not negative
+func settle(reporter *Reporter, skip int) {
+	defer func() {
+		if v := recover(); v != nil {
+			if skip < 0 {
+				reporter.CapturePanic(v)
+			} else {
+				reporter.CapturePanicAt(v, skip)
+			}
+		}
+	}()
+	defer func() {}()
+	closeLedger()
+}
+
+func TestCapturePanic(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	settle(reporter, -1)
+	settle(reporter, 1)
+	settle(reporter, 0)
+
+	sent := server.Posts()
+	if len(sent) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(sent))
+	}
+	for i, expected := range []string{"closeLedger.func1", "closeLedger.func1", "settle.func1"} {
+		if top := sent[i].Details.Error.StackTrace[0]; top.MethodName != expected {
+			t.Errorf("report %d: expected the top frame %s, got %+v", i, expected, top)
+		}
+	}
+	if message := sent[0].Details.Error.Message; !strings.Contains(message, "nil map") {
+		t.Errorf("expected the message of the panic, got '%s'", message)
+	}
+}
+
+func TestFromPanicError(t *testing.T) {
+	err := pkerr.Wrap(pkerr.New("connection reset"), "charge card")
+	recovered := func(v interface{}) (e Error) {
+		defer func() { e = FromPanic(recover()) }()
+		panic(v)
+	}
+
+	e, expected := recovered(err), FromErr(err)
+	if e.Message != expected.Message || e.ClassName != expected.ClassName {
+		t.Errorf("expected the error as FromErr converts it, got %q %q", e.ClassName, e.Message)
+	}
+	if !reflect.DeepEqual(e.StackTrace, expected.StackTrace) || len(e.StackTrace) == 0 {
+		t.Errorf("expected the stacktrace of the error, got %+v", e.StackTrace)
+	}
+	if e.InnerError != "connection reset" || e.InnerError != expected.InnerError {
+		t.Errorf("expected the inner error, got %+v", e.InnerError)
+	}
+
+	if e := recovered("boom"); e.Message != "panic: boom" || e.InnerError != "" {
+		t.Errorf("expected the message of a value, got %+v", e)
+	}
+}
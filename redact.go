@@ -0,0 +1,152 @@
+package crashreport
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+)
+
+// maxRedactDepth is the depth of nested values after which the values are left as they are, to stop on cycles
+const maxRedactDepth = 64
+
+// WithRedactPattern replaces the matches of the pattern by replacement in every string of the reports, including the
+// keys and values nested in UserCustomData, Error.Data and the breadcrumbs. The replacement can refer to the groups of
+// the pattern, as in regexp.ReplaceAllString. It's a safety net against personal data, such as emails or card numbers,
+// reaching Raygun through any field:
+//
+//	crashreport.WithRedactPattern(regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`), "[EMAIL]")
+//
+// The patterns run last, after the enrichers. The values of the reports are copied before being redacted, so the data
+// given to the reporter is never modified. Values of other types than maps, slices and structs, such as the ones with
+// a MarshalJSON method, are converted to json data first.
+func WithRedactPattern(re *regexp.Regexp, replacement string) Option {
+	return func(r *Reporter) {
+		r.redactions = append(r.redactions, redaction{re, replacement})
+	}
+}
+
+// redaction is a pattern of WithRedactPattern
+type redaction struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// redact applies the patterns to every string of the post
+func (r *Reporter) redact(post *Post) {
+	redactValue(reflect.ValueOf(&post.Details).Elem(), r.redactions, 0)
+}
+
+// redactString applies the patterns to a string
+func redactString(s string, redactions []redaction) string {
+	for _, red := range redactions {
+		if red.re.MatchString(s) {
+			s = red.re.ReplaceAllString(s, red.replacement)
+		}
+	}
+	return s
+}
+
+// redactValue applies the patterns to the strings of a settable value. Slices, maps and pointers are replaced by
+// redacted copies, since they may be shared with the caller.
+func redactValue(v reflect.Value, redactions []redaction, depth int) {
+	if depth > maxRedactDepth {
+		return
+	}
+	depth++
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(redactString(v.String(), redactions))
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); field.CanSet() {
+				redactValue(field, redactions, depth)
+			}
+		}
+
+	case reflect.Slice:
+		if v.IsNil() || !containsStrings(v.Type().Elem(), 0) {
+			return
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		for i := 0; i < c.Len(); i++ {
+			redactValue(c.Index(i), redactions, depth)
+		}
+		v.Set(c)
+
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := reflect.New(v.Type().Key()).Elem()
+			key.Set(iter.Key())
+			redactValue(key, redactions, depth)
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			redactValue(elem, redactions, depth)
+			c.SetMapIndex(key, elem)
+		}
+		v.Set(c)
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(v.Elem())
+		redactValue(c.Elem(), redactions, depth)
+		v.Set(c)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		inner := v.Elem()
+		if inner.Type().Implements(jsonMarshaler) || inner.Type().Implements(textMarshaler) {
+			data, err := json.Marshal(inner.Interface())
+			var decoded interface{}
+			if err != nil || json.Unmarshal(data, &decoded) != nil {
+				return
+			}
+			inner = reflect.ValueOf(decoded)
+			if !inner.IsValid() {
+				return
+			}
+		}
+		c := reflect.New(inner.Type()).Elem()
+		c.Set(inner)
+		redactValue(c, redactions, depth)
+		v.Set(c)
+	}
+}
+
+// containsStrings tells if values of the type may hold strings, to skip the slices of numbers
+func containsStrings(t reflect.Type, depth int) bool {
+	if depth > 2 {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Interface, reflect.Map, reflect.Ptr:
+		return true
+	case reflect.Slice, reflect.Array:
+		return containsStrings(t.Elem(), depth+1)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if containsStrings(t.Field(i).Type, depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
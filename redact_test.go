@@ -0,0 +1,91 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var emailPattern = regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)
+
+// account has a custom marshaling, that the redaction must see through
+type account struct {
+	email string
+}
+
+func (a account) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"email": a.email})
+}
+
+func TestWithRedactPattern(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	cards := regexp.MustCompile(`\b(\d{4})[ -]?\d{4}[ -]?\d{4}[ -]?(\d{4})\b`)
+	reporter := NewReporter("key", WithRedactPattern(emailPattern, "[EMAIL]"), WithRedactPattern(cards, "$1-xxxx-xxxx-$2"))
+
+	orders := []interface{}{
+		map[string]interface{}{"card": "4111 1111 1111 1234", "amounts": []float64{12.5}},
+		[]string{"bob@example.com"},
+	}
+	custom := map[string]interface{}{
+		"user": map[string]interface{}{
+			"contacts": map[string]string{"alice@example.com": "friend"},
+			"orders":   orders,
+		},
+		"account": account{"carol@example.com"},
+		"since":   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	reporter.Report(errors.New("no user dave@example.com"), WithCustomData("data", custom),
+		WithUser("erin@example.com"), func(post *Post) {
+			post.Details.Breadcrumbs = []Breadcrumb{{Message: "login", CustomData: []interface{}{"frank@example.com"}}}
+		})
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	body, _ := json.Marshal(sent[0])
+	if emails := emailPattern.FindAllString(string(body), -1); len(emails) != 0 {
+		t.Errorf("every email should be redacted, found %v in %s", emails, body)
+	}
+	for _, expected := range []string{
+		`"message":"no user [EMAIL]"`, `"identifier":"[EMAIL]"`, `"[EMAIL]":"friend"`,
+		`"card":"4111-xxxx-xxxx-1234"`, `"amounts":[12.5]`, `"since":"2020-01-01T00:00:00Z"`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("expected %s in %s", expected, body)
+		}
+	}
+
+	if orders[0].(map[string]interface{})["card"] != "4111 1111 1111 1234" || orders[1].([]string)[0] != "bob@example.com" {
+		t.Error("the data of the caller should not be modified")
+	}
+}
+
+func BenchmarkRedact(b *testing.B) {
+	reporter := NewReporter("key", WithRedactPattern(emailPattern, "[EMAIL]"))
+	post := NewPost()
+	post.Details.Error = FromErr(errors.New("no user dave@example.com"))
+	post.Details.Request = Request{
+		URL:     "/users",
+		Headers: map[string]string{"Accept": "application/json", "User-Agent": "test", "X-User": "erin@example.com"},
+	}
+	for i := 0; i < 20; i++ {
+		post.Details.Breadcrumbs = append(post.Details.Breadcrumbs, Breadcrumb{
+			Message:    "GET /api/items",
+			CustomData: map[string]interface{}{"status": 200, "path": "/api/items"},
+		})
+	}
+	post.SetCustomData("user", map[string]interface{}{"name": "Dave", "roles": []interface{}{"admin", "user"}})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := post
+		reporter.redact(&p)
+	}
+}
@@ -0,0 +1,168 @@
+package crashreport
+
+import (
+	"reflect"
+	"strings"
+)
+
+// redactedValue replaces the fields tagged with `crashreport:"redact"` by RedactStruct
+const redactedValue = "[REDACTED]"
+
+// RedactStruct returns the value with the struct fields tagged with `crashreport:"redact"` replaced by "[REDACTED]",
+// to attach application types to the custom data without their secrets:
+//
+//	type Account struct {
+//		Email    string `json:"email"`
+//		Password string `json:"password" crashreport:"redact"`
+//	}
+//
+//	reporter.Report(err, crashreport.WithCustomData("account", crashreport.RedactStruct(account)))
+//
+// Structs are converted to maps keyed by their json names, following the json tags of the fields, including the
+// nested ones, through pointers, slices and maps. Other values, and the types with a MarshalJSON or MarshalText
+// method, are kept as they are. The value itself is not modified.
+func RedactStruct(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return redactStruct(reflect.ValueOf(v), 0)
+}
+
+// redactStruct converts the value for RedactStruct
+func redactStruct(v reflect.Value, depth int) interface{} {
+	if depth > maxRedactDepth || !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	depth++
+
+	if v.Type().Implements(jsonMarshaler) || v.Type().Implements(textMarshaler) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactStruct(v.Elem(), depth)
+
+	case reflect.Struct:
+		fields := map[string]interface{}{}
+		redactFields(v, fields, depth)
+		return fields
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if !containsStructs(v.Type().Elem(), 0) {
+			return v.Interface()
+		}
+		elems := make([]interface{}, v.Len())
+		for i := range elems {
+			elems[i] = redactStruct(v.Index(i), depth)
+		}
+		return elems
+
+	case reflect.Map:
+		if v.IsNil() || !containsStructs(v.Type().Elem(), 0) {
+			return v.Interface()
+		}
+		m := reflect.MakeMapWithSize(reflect.MapOf(v.Type().Key(), reflect.TypeOf((*interface{})(nil)).Elem()), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.ValueOf(redactStruct(iter.Value(), depth))
+			if !elem.IsValid() {
+				elem = reflect.Zero(m.Type().Elem())
+			}
+			m.SetMapIndex(iter.Key(), elem)
+		}
+		return m.Interface()
+	}
+	return v.Interface()
+}
+
+// redactFields adds the exported fields of the struct to the map, under their json names. The fields of the embedded
+// structs without a json name are added as if they were fields of the struct, like encoding/json does.
+func redactFields(v reflect.Value, fields map[string]interface{}, depth int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue // unexported
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		value := v.Field(i)
+
+		if name == "" && field.Anonymous {
+			embedded := value
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				redactFields(embedded, fields, depth)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmptyValue(value) {
+			continue
+		}
+
+		if hasRedactTag(field) {
+			fields[name] = redactedValue
+		} else {
+			fields[name] = redactStruct(value, depth)
+		}
+	}
+}
+
+// hasRedactTag tells if the field is tagged with `crashreport:"redact"`
+func hasRedactTag(field reflect.StructField) bool {
+	for _, opt := range strings.Split(field.Tag.Get("crashreport"), ",") {
+		if opt == "redact" {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyValue tells if the value is omitted by the omitempty option of encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Ptr:
+		return v.IsZero()
+	}
+	return false
+}
+
+// containsStructs tells if values of the type may hold structs, to keep the slices and maps of other values as they
+// are
+func containsStructs(t reflect.Type, depth int) bool {
+	if depth > 2 {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return containsStructs(t.Elem(), depth+1)
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type redactedAddress struct {
+	Street string `json:"street" crashreport:"redact"`
+	City   string `json:"city"`
+}
+
+type redactedCard struct {
+	Number string `crashreport:"redact"`
+	Expiry string
+}
+
+type redactedAudit struct {
+	CreatedBy string `json:"createdBy" crashreport:"redact"`
+}
+
+type redactedAccount struct {
+	redactedAudit
+	ID       int                     `json:"id"`
+	Email    string                  `json:"email"`
+	Password string                  `json:"password" crashreport:"redact"`
+	PIN      int                     `json:"pin,omitempty" crashreport:"redact"`
+	Address  *redactedAddress        `json:"address"`
+	Cards    []redactedCard          `json:"cards"`
+	Previous map[string]redactedCard `json:"previous"`
+	Internal string                  `json:"-"`
+	Created  time.Time               `json:"created"`
+	Extra    map[string]interface{}  `json:"extra"`
+	secret   string
+}
+
+func TestRedactStruct(t *testing.T) {
+	account := &redactedAccount{
+		redactedAudit: redactedAudit{CreatedBy: "admin"},
+		ID:            42,
+		Email:         "alice@example.com",
+		Password:      "hunter2",
+		Address:       &redactedAddress{Street: "1 Main St", City: "Springfield"},
+		Cards:         []redactedCard{{Number: "4111111111111111", Expiry: "12/30"}},
+		Previous:      map[string]redactedCard{"old": {Number: "5500000000000004", Expiry: "01/20"}},
+		Internal:      "internal",
+		Created:       time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Extra:         map[string]interface{}{"card": redactedCard{Number: "378282246310005"}},
+		secret:        "secret",
+	}
+
+	data, err := json.Marshal(RedactStruct(account))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got interface{}
+	json.Unmarshal(data, &got)
+	var expected interface{}
+	json.Unmarshal([]byte(`{
+		"createdBy": "[REDACTED]",
+		"id": 42,
+		"email": "alice@example.com",
+		"password": "[REDACTED]",
+		"address": {"street": "[REDACTED]", "city": "Springfield"},
+		"cards": [{"Number": "[REDACTED]", "Expiry": "12/30"}],
+		"previous": {"old": {"Number": "[REDACTED]", "Expiry": "01/20"}},
+		"created": "2020-01-02T03:04:05Z",
+		"extra": {"card": {"Number": "[REDACTED]", "Expiry": ""}}
+	}`), &expected)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected redacted struct %s", data)
+	}
+
+	if account.Password != "hunter2" || account.Address.Street != "1 Main St" || account.Cards[0].Number != "4111111111111111" {
+		t.Error("the struct should not be modified")
+	}
+	if RedactStruct(nil) != nil || RedactStruct("text") != "text" {
+		t.Error("values other than structs should be kept")
+	}
+}
@@ -0,0 +1,589 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// Reporter sends errors to Raygun with a fixed api key, applying the same options to every report.
+type Reporter struct {
+	endpoint  string
+	key       string
+	keyRouter func(Post) string
+	client    *http.Client
+	proxy     *url.URL
+	transport http.RoundTripper // used by the default clients, nil for http.DefaultTransport
+	err       error             // a configuration error, returned by every report
+	sink      Sink              // nil to submit to Raygun
+	dryRun    bool
+	inspect   func([]byte) // receives the posts in dry run
+
+	*state                   // shared with the reporters created by With
+	defaults  []ReportOption // see With
+	rateLimit *tokenBucket   // nil without a global rate limit
+
+	retryBudget     *retryBudget     // nil without a retry budget
+	adaptiveTimeout *adaptiveTimeout // nil without an adaptive timeout
+
+	ignore       []func(error) bool
+	dropObserver func(Post, DropReason)
+
+	messageTransform  func(string) string
+	maxMessageLength  int
+	breadcrumbSources []func() []Breadcrumb
+	operations        *breadcrumbRing // see TrackOperation
+	enrichers         []func(*Post)   // add data to every report, see prepare
+	redactions        []redaction
+
+	template           *Post // see newPost
+	appModule          string
+	trimPaths          bool // see WithSourceLinking
+	sourceURL          *sourceURL
+	version            string
+	deepestStack       bool
+	innerErrorMode     InnerErrorMode
+	joinedStacks       bool
+	exitCode           int // see Fatal
+	goroutineDumpLimit int
+	swallowPanics      bool // see RecoverRequest
+	crashSignals       bool
+	fingerprintFrames  int // see WithStackOnlyFingerprint
+	headers            headerFilter
+	lastResort         *lastResortFile
+	hostMemory         bool          // see WithContainerAware
+	environmentFile    []byte        // see WithEnvironmentFile
+	jsonBody           *jsonBody     // see WithJSONRequestBody
+	lean               bool          // see sendLean
+	submits            chan struct{} // the slots of WithMaxConcurrentSubmits
+
+	customDataMarshaler func(interface{}) ([]byte, error)
+	codec               Codec
+
+	environmentName   string          // see WithEnvironmentName
+	environmentFilter map[string]bool // see WithEnvironmentFilter
+	environmentDenied bool            // by the filter, every report is dropped
+	stripQuery        map[string]bool // the parameters to redact, see WithStripQueryString
+	endpointResolver  func(Post) string
+	slowThreshold     time.Duration // see Timed
+
+	minBreadcrumbLevel int   // see WithMinBreadcrumbLevel
+	minLevel           Level // see WithMinLevel
+	flatStack          bool  // see WithFlatStackInData
+
+	postMapper func(src interface{}, dst *Post) // see WithPostMapper
+}
+
+// state is the state of a reporter, shared with the reporters created by With
+type state struct {
+	clientsMu sync.Mutex
+	clients   map[string]*http.Client // one per api key, when no client is configured
+
+	ctx       context.Context // cancelled by Close
+	cancel    context.CancelFunc
+	async     async
+	cooldown  cooldown
+	diskQueue diskQueue
+	sampling  sampling
+	debounce  debounce
+	aggregate aggregate
+	rollup    rollup
+	once      once
+	crashLoop crashLoop
+	stats     stats
+	globals   globalContext // see SetContext
+	session   atomic.Value  // string, see StartSession
+}
+
+// Option configures a Reporter
+type Option func(*Reporter)
+
+// NewReporter creates a reporter that submits with the given api key
+func NewReporter(key string, opts ...Option) *Reporter {
+	r := &Reporter{endpoint: Endpoint, key: key, exitCode: 1, state: &state{clients: map[string]*http.Client{}},
+		operations: newBreadcrumbRing(defaultOperationHistory)}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.appModule == "" {
+		r.appModule = mainModule()
+	}
+	if r.environmentFilter != nil {
+		r.environmentDenied = !r.environmentFilter[r.environment()]
+	}
+	r.start()
+	if r.crashSignals {
+		r.watchCrashSignals()
+	}
+	r.lean = r.isLean()
+	return r
+}
+
+// WithEndpoint sets the endpoint of the raygun api, by default the value of Endpoint when the reporter is created.
+// It's useful to send to a regional endpoint, or to a fake server in tests.
+func WithEndpoint(endpoint string) Option {
+	return func(r *Reporter) {
+		r.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithClient sets the http client used to submit the reports. By default Submit uses its own client with a 5s timeout
+func WithClient(client *http.Client) Option {
+	return func(r *Reporter) {
+		r.client = client
+	}
+}
+
+// WithKeyRouter sets a function choosing the api key of each report, for example from its tags, to send reports to
+// different Raygun applications. If the function returns an empty string the report is dropped.
+func WithKeyRouter(router func(Post) string) Option {
+	return func(r *Reporter) {
+		r.keyRouter = router
+	}
+}
+
+// WithMessageTransform sets a function applied to Error.Message before the report is sent. It's useful to remove
+// volatile data from the message, such as ids or paths, that would prevent grouping or leak personal information:
+//
+//	"user 12345 not found" -> "user {id} not found"
+//
+// The transform runs right after the error is converted with FromErr, so that the fingerprint is computed on the
+// transformed message.
+func WithMessageTransform(transform func(string) string) Option {
+	return func(r *Reporter) {
+		r.messageTransform = transform
+	}
+}
+
+// WithDeepestStack makes the reports take the stacktrace of the deepest error carrying one, following Unwrap and
+// Cause. When an error is wrapped with a stack at several places, as pkg/errors.Wrap does, the stacktrace then
+// points at where the error was created instead of where it was last wrapped. By default the outermost stack is used.
+func WithDeepestStack() Option {
+	return func(r *Reporter) {
+		r.deepestStack = true
+	}
+}
+
+// ReportOption customizes a single report
+type ReportOption func(*Post)
+
+// WithTags adds tags to the report
+func WithTags(tags ...string) ReportOption {
+	return func(post *Post) {
+		post.Details.Tags = append(post.Details.Tags, tags...)
+	}
+}
+
+// WithUser sets the user affected by the error
+func WithUser(identifier string) ReportOption {
+	return func(post *Post) {
+		post.Details.User = User{Identifier: identifier}
+	}
+}
+
+// WithCustomData sets a key of the report's custom data
+func WithCustomData(key string, value interface{}) ReportOption {
+	return func(post *Post) {
+		post.SetCustomData(key, value)
+	}
+}
+
+// WithBreadcrumbs appends breadcrumbs to the report
+func WithBreadcrumbs(breadcrumbs ...Breadcrumb) ReportOption {
+	return func(post *Post) {
+		post.Details.Breadcrumbs = append(post.Details.Breadcrumbs, breadcrumbs...)
+	}
+}
+
+// WithHTTPClient submits the report with the client instead of the one of the reporter, for example for a report
+// that must go through another proxy. The report doesn't share the connections of the other reports, unless the
+// client is reused. It doesn't apply to the sinks of WithSink, nor to the report once persisted to the disk queue:
+// DrainQueue submits it with the client of the reporter.
+func WithHTTPClient(client *http.Client) ReportOption {
+	return func(post *Post) {
+		post.client = client
+	}
+}
+
+// WithMaxMessageLength truncates the messages longer than n characters, ending them with an ellipsis. The full
+// message is kept in Error.Data, under the "fullMessage" key. By default messages are not truncated.
+func WithMaxMessageLength(n int) Option {
+	return func(r *Reporter) {
+		r.maxMessageLength = n
+	}
+}
+
+// WithBreadcrumbSource adds a function called at report time, whose breadcrumbs are appended to the report. See
+// NewLogCapture for an example.
+func WithBreadcrumbSource(source func() []Breadcrumb) Option {
+	return func(r *Reporter) {
+		r.breadcrumbSources = append(r.breadcrumbSources, source)
+	}
+}
+
+// With returns a reporter applying the options to its reports before their own options, such as the tags and the
+// user of a request. It shares the configuration, the queue and the connections of r, and creating it is cheap: no
+// worker is started. Closing either reporter closes both.
+func (r *Reporter) With(opts ...ReportOption) *Reporter {
+	child := *r
+	child.defaults = append(append([]ReportOption(nil), r.defaults...), opts...)
+	return &child
+}
+
+// Report builds a post from the error and sends it to Raygun
+func (r *Reporter) Report(err error, opts ...ReportOption) error {
+	post := r.newPost()
+	post.Details.Error = FromErr(err)
+	post.err = err
+	identify(&post, caller(0))
+	return r.send(post, opts...)
+}
+
+// ReportMany reports each error of a batch, such as the failed items of a job, with the same options: the reports
+// share the request, the user and the tags of the options, and each has the message and the stacktrace of its error.
+// The nil errors are skipped. The reports go through the pipeline one after the other, so they are queued together by
+// an asynchronous reporter, and submitted in the same batch by a BatchSink, see WithSink. It returns the errors of the
+// reports, joined.
+func (r *Reporter) ReportMany(errs []error, opts ...ReportOption) error {
+	site := caller(0)
+	var failed []error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		post := r.newPost()
+		post.Details.Error = FromErr(err)
+		post.err = err
+		identify(&post, site)
+		if err := r.send(post, opts...); err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return errors.Join(failed...)
+}
+
+// ReportRequest builds a post from the error and the http request that caused it, and sends it to Raygun. It's useful
+// outside of the middleware, for example in a worker processing a job queued by a request. If req is nil the report
+// has no request info.
+func (r *Reporter) ReportRequest(err error, req *http.Request, opts ...ReportOption) error {
+	return r.reportRequest(err, req, caller(0), opts)
+}
+
+func (r *Reporter) reportRequest(err error, req *http.Request, caller string, opts []ReportOption) error {
+	post := r.newPost()
+	post.Details.Error = FromErr(err)
+	post.err = err
+	if req != nil {
+		post.Details.Request = fromReq(req, r.headers)
+	}
+	identify(&post, caller)
+	return r.send(post, opts...)
+}
+
+// Drain reports the errors received from the channel until it's closed or the context is done, in which case it
+// returns the error of the context. Nil errors are skipped. It's meant to run in its own goroutine, for services
+// pushing their errors to a channel.
+func (r *Reporter) Drain(ctx context.Context, errs <-chan error, opts ...ReportOption) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err, ok := <-errs:
+			if !ok {
+				return nil
+			}
+			if err != nil {
+				r.Report(err, opts...)
+			}
+		}
+	}
+}
+
+// send applies the options to the post, prepares it and dispatches it. The posts of the errors of WithIgnoreErrors are
+// dropped first, for every path to Raygun.
+func (r *Reporter) send(post Post, opts ...ReportOption) error {
+	if post.err != nil && r.ignored(post.err) {
+		post.outcome.record(OutcomeDropped, nil)
+		return nil
+	}
+	if r.err != nil {
+		post.outcome.record(OutcomeFailed, r.err)
+		return r.err
+	}
+	if r.environmentDenied {
+		r.dropped(post, DropFiltered)
+		return nil
+	}
+	r.applySession(&post)
+	if r.lean && len(opts) == 0 && len(r.defaults) == 0 && !r.hasGlobals() {
+		return r.sendLean(post)
+	}
+	return r.sendFull(post, opts)
+}
+
+// sendFull is send through every stage of the pipeline
+func (r *Reporter) sendFull(post Post, opts []ReportOption) error {
+	for _, opt := range r.defaults {
+		applySafely("report option", &post, opt)
+	}
+	for _, opt := range opts {
+		applySafely("report option", &post, opt)
+	}
+	if r.belowMinLevel(post) {
+		return nil
+	}
+	r.prepare(&post)
+
+	key := r.keyFor(post)
+	if key == "" || (r.endpointResolver != nil && !r.resolveEndpoint(&post)) {
+		r.dropped(post, DropVetoed)
+		return nil
+	}
+	if r.once.submitted != nil && r.suppressed(post) {
+		r.dropped(post, DropSuppressed)
+		return nil
+	}
+	if r.crashLoop.window > 0 && post.panicked && r.crashLooping(post) {
+		r.dropped(post, DropSuppressed)
+		return nil
+	}
+	if (r.sampling.fixed || r.sampling.levels != nil || r.sampling.errors != nil) && r.sampled(post) {
+		r.dropped(post, DropSampled)
+		return nil
+	}
+	if post.synchronous {
+		return r.submit(post, key)
+	}
+	if r.rollup.interval > 0 && r.rolledUp(post) {
+		r.rolled(post, key)
+		return nil
+	}
+	if r.aggregate.window > 0 {
+		r.aggregated(post, key)
+		return nil
+	}
+	if r.debounce.interval > 0 {
+		r.debounced(post, key)
+		return nil
+	}
+	return r.dispatch(post, key)
+}
+
+// isLean tells if the reporter has none of the options that transform, drop or hold the posts before their
+// submission, so that the reports without options can take the fast path of sendLean
+func (r *Reporter) isLean() bool {
+	return r.key != "" && r.keyRouter == nil && r.endpointResolver == nil && r.jsonBody == nil &&
+		r.stripQuery == nil && r.minBreadcrumbLevel == 0 && !r.flatStack && r.messageTransform == nil &&
+		r.maxMessageLength == 0 && r.environmentFile == nil && len(r.breadcrumbSources) == 0 && len(r.enrichers) == 0 &&
+		len(r.redactions) == 0 && !r.deepestStack && r.innerErrorMode == InnerErrorImmediate && !r.joinedStacks &&
+		!r.trimPaths && r.sourceURL == nil && r.version == "" && r.fingerprintFrames == 0 &&
+		!r.sampling.fixed && r.sampling.levels == nil && r.sampling.errors == nil && r.aggregate.window == 0 &&
+		r.debounce.interval == 0 && r.rollup.interval == 0 && r.once.submitted == nil && r.rateLimit == nil &&
+		r.crashLoop.window == 0
+}
+
+// sendLean is send for the reports without options of a lean reporter, see isLean: it only runs the stages of
+// prepare that apply to every post, and dispatches the post. The post stays on the stack, while sendFull moves it to
+// the heap for the options. The result is the same as sendFull's, see BenchmarkSend.
+func (r *Reporter) sendLean(post Post) error {
+	if r.belowMinLevel(post) {
+		return nil
+	}
+	applyLevel(&post)
+	applyRoute(&post)
+	if r.appModule != "" {
+		post.Details.Error.StackTrace = markInApp(post.Details.Error.StackTrace, r.appModule)
+	}
+	post.Details.Breadcrumbs = append(post.Details.Breadcrumbs, r.operations.breadcrumbs()...)
+	if r.async.queue != nil && !post.synchronous {
+		return r.enqueue(post, r.key)
+	}
+	return r.submit(post, r.key)
+}
+
+// dispatch queues the post if the reporter is asynchronous, or submits it
+func (r *Reporter) dispatch(post Post, key string) error {
+	if r.rateLimited(post) {
+		return ErrRateLimited
+	}
+	if r.async.queue != nil {
+		return r.enqueue(post, key)
+	}
+	return r.submit(post, key)
+}
+
+// keyFor returns the api key of the post. An empty key means that the post must be dropped.
+func (r *Reporter) keyFor(post Post) string {
+	key := r.key
+	if r.keyRouter != nil {
+		safely("key router", func() { key = r.keyRouter(post) })
+	}
+	return key
+}
+
+// submit sends the post to Raygun. When Raygun answers 429 the reporter enters a cool-down for the Retry-After
+// duration, during which the reports are kept instead of submitted. They are submitted when the cool-down ends.
+// The same happens for an hour when the quota is exceeded.
+// Reports that fail because of the network or of the server are persisted to the disk queue, if configured, and the
+// others are appended to the file of WithLastResortFile.
+func (r *Reporter) submit(post Post, key string) (err error) {
+	if r.hold(post, key) {
+		return nil
+	}
+	defer func() { post.outcome.record(outcomeOf(err), err) }()
+
+	r.marshalCustomData(&post)
+
+	ctx := r.ctx
+	if !post.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, post.deadline)
+		defer cancel()
+	}
+
+	if r.dryRun {
+		return r.dryRunSubmit(post)
+	}
+
+	r.retryBudget.request()
+	endpoint, client := r.endpoint, post.client
+	if post.endpoint != "" {
+		endpoint = post.endpoint
+	}
+	switch {
+	case client != nil:
+	case post.endpointHost != "":
+		client = r.clientFor(post.endpointHost + "/" + key)
+	default:
+		client = r.clientFor(key)
+	}
+	release, err := r.acquireSubmit(ctx)
+	if err == nil {
+		ctx, done := r.adaptiveTimeout.bound(ctx)
+		switch {
+		case r.sink != nil:
+			err = sinkSend(ctx, r.sink, post, key)
+		case r.codec != nil:
+			err = r.redactProxy(submitEncoded(ctx, post, r.codec, endpoint+"/entries", key, client))
+		default:
+			err = r.redactProxy(SubmitContextToUrl(ctx, post, endpoint+"/entries", key, client))
+		}
+		done(err)
+		release()
+	}
+	if e, ok := err.(*ResponseError); ok {
+		switch {
+		case e.StatusCode == http.StatusTooManyRequests:
+			r.startCooldown(e.RetryAfter)
+		case errors.Is(e, ErrQuotaExceeded):
+			r.stopOnQuota(e.RetryAfter)
+		}
+	}
+	if err == nil && r.once.submitted != nil {
+		r.submittedOnce(post)
+	}
+	if err == nil && r.crashLoop.window > 0 && post.panicked {
+		r.reportedPanic(post)
+	}
+	if err != nil {
+		if persisted := r.diskQueue.dir != "" && Retryable(err) && r.persist(post) == nil; !persisted {
+			r.writeLastResort(post)
+		}
+	}
+	return err
+}
+
+// clientFor returns the http client used to submit with the given api key. Without a configured client, each key gets
+// its own client with a 5s timeout, so that applications don't share connections. The key is prefixed by the host of
+// the endpoint for the endpoints of WithEndpointResolver.
+func (r *Reporter) clientFor(key string) *http.Client {
+	if r.client != nil {
+		return r.client
+	}
+
+	r.clientsMu.Lock()
+	defer r.clientsMu.Unlock()
+	client, ok := r.clients[key]
+	if !ok {
+		client = &http.Client{Timeout: 5 * time.Second, Transport: r.transport}
+		r.clients[key] = client
+	}
+	return client
+}
+
+// prepare applies the reporter options to the post
+func (r *Reporter) prepare(post *Post) {
+	if post.err != nil {
+		r.applyStacks(post)
+		if r.innerErrorMode != InnerErrorImmediate {
+			post.Details.Error.InnerError = innerError(post.err, r.innerErrorMode)
+		}
+	}
+	if r.messageTransform != nil {
+		safely("message transform", func() { post.Details.Error.Message = r.messageTransform(post.Details.Error.Message) })
+	}
+	if r.maxMessageLength > 0 {
+		truncateMessage(&post.Details.Error, r.maxMessageLength)
+	}
+	applyLevel(post)
+	applyRoute(post)
+	if r.jsonBody != nil {
+		r.jsonBody.apply(post)
+	}
+	if r.stripQuery != nil {
+		r.applyStripQuery(post)
+	}
+	if r.appModule != "" {
+		post.Details.Error.StackTrace = markInApp(post.Details.Error.StackTrace, r.appModule)
+		if r.sourceURL != nil {
+			post.Details.Error.StackTrace = r.sourceURL.link(post.Details.Error.StackTrace, r.appModule)
+		}
+		if r.trimPaths {
+			post.Details.Error.StackTrace = trimPaths(post.Details.Error.StackTrace, r.appModule)
+		}
+	}
+	if r.flatStack && len(post.Details.Error.StackTrace) > 0 {
+		post.Details.Error.SetData("stackString", post.Details.Error.StackTrace.String())
+	}
+	if r.version != "" {
+		post.Details.Version = r.version
+	}
+	for _, source := range r.breadcrumbSources {
+		safely("breadcrumb source", func() { post.Details.Breadcrumbs = append(post.Details.Breadcrumbs, source()...) })
+	}
+	post.Details.Breadcrumbs = append(post.Details.Breadcrumbs, r.operations.breadcrumbs()...)
+	if r.fingerprintFrames > 0 {
+		post.Details.GroupingKey = StackFingerprint(*post, r.fingerprintFrames)
+	}
+	r.applyGlobals(post)
+	for _, enrich := range r.enrichers {
+		applySafely("enricher", post, enrich)
+	}
+	if r.environmentFile != nil {
+		r.applyEnvironmentFile(post)
+	}
+	if r.minBreadcrumbLevel > 0 {
+		post.Details.Breadcrumbs = filterBreadcrumbs(post.Details.Breadcrumbs, r.minBreadcrumbLevel)
+	}
+	if len(r.redactions) > 0 {
+		r.redact(post)
+	}
+}
+
+// truncateMessage cuts the message of the error to n runes, keeping the full message in Data
+func truncateMessage(e *Error, n int) {
+	if utf8.RuneCountInString(e.Message) <= n {
+		return
+	}
+	full := e.Message
+	runes := []rune(full)
+	e.Message = string(runes[:n-1]) + "…"
+	e.SetData("fullMessage", full)
+}
@@ -0,0 +1,563 @@
+package crashreport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pkerr "github.com/pkg/errors"
+)
+
+// mockServer is a fake Raygun api recording the posts it receives
+type mockServer struct {
+	*httptest.Server
+	oldEndpoint string
+
+	mu       sync.Mutex
+	posts    []Post
+	keys     []string
+	attempts int
+	reject   func(w http.ResponseWriter) bool
+}
+
+// mockRaygun starts a server that accepts every post and records it. It points Endpoint to the server until closed.
+func mockRaygun(t *testing.T) *mockServer {
+	m := &mockServer{oldEndpoint: Endpoint}
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var post Post
+		if err := json.NewDecoder(req.Body).Decode(&post); err != nil {
+			t.Errorf("decode post: %s", err)
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.attempts++
+		if m.reject != nil && m.reject(w) {
+			return
+		}
+		m.posts = append(m.posts, post)
+		m.keys = append(m.keys, req.Header.Get("X-ApiKey"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	Endpoint = m.URL
+	return m
+}
+
+// Posts returns the posts received so far
+func (m *mockServer) Posts() []Post {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Post(nil), m.posts...)
+}
+
+// Keys returns the api keys of the posts received so far
+func (m *mockServer) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.keys...)
+}
+
+// Attempts returns the number of posts received so far, including the rejected ones
+func (m *mockServer) Attempts() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.attempts
+}
+
+// Reject sets a function called for every post. If it writes a response, the post is not recorded.
+func (m *mockServer) Reject(reject func(w http.ResponseWriter) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reject = reject
+}
+
+// Close shuts the server down and restores Endpoint
+func (m *mockServer) Close() {
+	Endpoint = m.oldEndpoint
+	m.Server.Close()
+}
+
+func TestWithMessageTransform(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	digits := regexp.MustCompile(`\d+`)
+	reporter := NewReporter("key", WithMessageTransform(func(msg string) string {
+		return digits.ReplaceAllString(msg, "{id}")
+	}))
+
+	for _, msg := range []string{"user 12345 not found", "user 678 not found"} {
+		if err := reporter.Report(errors.New(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	for _, post := range sent {
+		if post.Details.Error.Message != "user {id} not found" {
+			t.Errorf("message should be templated, got '%s'", post.Details.Error.Message)
+		}
+	}
+	if Fingerprint(sent[0]) != Fingerprint(sent[1]) {
+		t.Error("fingerprints of templated messages should match")
+	}
+
+	raw := sent[0]
+	raw.Details.Error.Message = "user 12345 not found"
+	if Fingerprint(raw) == Fingerprint(sent[0]) {
+		t.Error("fingerprint should depend on the transformed message")
+	}
+}
+
+func TestReportRequest(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+
+	req, _ := http.NewRequest("POST", "http://example.com/jobs?id=42", strings.NewReader("payload"))
+	req.Header.Set("User-Agent", "worker")
+	if err := reporter.ReportRequest(errors.New("job failed"), req, WithTags("worker")); err != nil {
+		t.Fatal(err)
+	}
+	if err := reporter.ReportRequest(errors.New("job failed"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+
+	withReq := sent[0].Details
+	if withReq.Error.Message != "job failed" {
+		t.Errorf("message should be 'job failed', got '%s'", withReq.Error.Message)
+	}
+	if withReq.Request.URL != "http://example.com/jobs?id=42" || withReq.Request.HTTPMethod != "POST" {
+		t.Errorf("request should be attached, got %+v", withReq.Request)
+	}
+	if withReq.Request.QueryString["id"] != "42" || withReq.Request.Headers["User-Agent"] != "worker" {
+		t.Errorf("query and headers should be attached, got %+v", withReq.Request)
+	}
+	if len(withReq.Tags) != 1 || withReq.Tags[0] != "worker" {
+		t.Errorf("report options should be applied, got tags %v", withReq.Tags)
+	}
+
+	if sent[1].Details.Request.URL != "" || sent[1].Details.Request.HTTPMethod != "" {
+		t.Errorf("a nil request should not be attached, got %+v", sent[1].Details.Request)
+	}
+}
+
+func TestWithKeyRouter(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("default", WithKeyRouter(func(post Post) string {
+		for _, tag := range post.Details.Tags {
+			switch tag {
+			case "product:a":
+				return "key-a"
+			case "product:b":
+				return "key-b"
+			}
+		}
+		return ""
+	}))
+
+	reporter.Report(errors.New("a failed"), WithTags("product:a"))
+	reporter.Report(errors.New("b failed"), WithTags("product:b"))
+	reporter.Report(errors.New("unknown failed"), WithTags("product:c"))
+	reporter.Report(errors.New("a failed again"), WithTags("product:a"))
+
+	keys := server.Keys()
+	expected := []string{"key-a", "key-b", "key-a"}
+	if strings.Join(keys, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected keys %v, got %v", expected, keys)
+	}
+	if reporter.clientFor("key-a") != reporter.clientFor("key-a") || reporter.clientFor("key-a") == reporter.clientFor("key-b") {
+		t.Error("clients should be cached per key")
+	}
+}
+
+func TestWithMaxMessageLength(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithMaxMessageLength(5))
+	reporter.Report(errors.New("héllo"))
+	reporter.Report(errors.New("héllo wörld"))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	if msg := sent[0].Details.Error.Message; msg != "héllo" || sent[0].Details.Error.Data != nil {
+		t.Errorf("a message at the limit should not be truncated, got '%s'", msg)
+	}
+	if msg := sent[1].Details.Error.Message; msg != "héll…" {
+		t.Errorf("message should be truncated to 5 runes, got '%s'", msg)
+	}
+	data := sent[1].Details.Error.Data.(map[string]interface{})
+	if data["fullMessage"] != "héllo wörld" {
+		t.Errorf("the full message should be kept, got %v", data["fullMessage"])
+	}
+}
+
+func TestDrain(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+
+	errs := make(chan error)
+	done := make(chan error)
+	go func() {
+		done <- reporter.Drain(context.Background(), errs)
+	}()
+	errs <- errors.New("first")
+	errs <- nil
+	errs <- errors.New("second")
+	close(errs)
+	if err := <-done; err != nil {
+		t.Errorf("drain should return nil when the channel is closed, got %v", err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 2 || sent[0].Details.Error.Message != "first" || sent[1].Details.Error.Message != "second" {
+		t.Errorf("expected the 2 non-nil errors, got %d posts", len(sent))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- reporter.Drain(ctx, make(chan error))
+	}()
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("drain should return the context error, got %v", err)
+	}
+}
+
+func TestWithEndpoint(t *testing.T) {
+	us := mockRaygun(t)
+	defer us.Close()
+	eu := mockRaygun(t)
+	defer eu.Close()
+
+	var wg sync.WaitGroup
+	for _, endpoint := range []string{us.URL, eu.URL + "/"} {
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(endpoint string) {
+				defer wg.Done()
+				if err := NewReporter("key", WithEndpoint(endpoint)).Report(errors.New("regional")); err != nil {
+					t.Errorf("report to %s: %s", endpoint, err)
+				}
+			}(endpoint)
+		}
+	}
+	wg.Wait()
+
+	if n := len(us.Posts()); n != 5 {
+		t.Errorf("expected 5 posts to the first endpoint, got %d", n)
+	}
+	if n := len(eu.Posts()); n != 5 {
+		t.Errorf("expected 5 posts to the second endpoint, got %d", n)
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	var shared, egress []string
+	client := func(used *[]string) *http.Client {
+		return &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			*used = append(*used, req.Header.Get("X-ApiKey"))
+			return http.DefaultTransport.RoundTrip(req)
+		})}
+	}
+	reporter := NewReporter("key", WithClient(client(&shared)))
+	reporter.Report(errors.New("shared"))
+	reporter.Report(errors.New("egress"), WithHTTPClient(client(&egress)))
+	reporter.Report(errors.New("shared again"))
+
+	if len(server.Posts()) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(server.Posts()))
+	}
+	if len(shared) != 2 || len(egress) != 1 || egress[0] != "key" {
+		t.Errorf("expected the override to submit only its report, got %d and %d submissions", len(shared), len(egress))
+	}
+}
+
+// createdError and wrappedError attach pkg/errors stacks at two different places
+func createdError() error {
+	return pkerr.New("not found")
+}
+
+func wrappedError() error {
+	return pkerr.Wrap(createdError(), "load user")
+}
+
+func TestWithDeepestStack(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	NewReporter("key").Report(wrappedError())
+	NewReporter("key", WithDeepestStack()).Report(wrappedError())
+	NewReporter("key", WithDeepestStack()).Report(errors.New("no stack"))
+
+	sent := server.Posts()
+	if len(sent) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(sent))
+	}
+	if method := sent[0].Details.Error.StackTrace[0].MethodName; method != "wrappedError" {
+		t.Errorf("the outermost stack should be used by default, got %s", method)
+	}
+	if method := sent[1].Details.Error.StackTrace[0].MethodName; method != "createdError" {
+		t.Errorf("the deepest stack should point at the creation of the error, got %s", method)
+	}
+	if sent[1].Details.Error.Message != "load user: not found" {
+		t.Errorf("the message should be the outermost one, got %q", sent[1].Details.Error.Message)
+	}
+	if len(sent[2].Details.Error.StackTrace) == 0 {
+		t.Error("errors without a stack should keep the stack of the report")
+	}
+}
+
+func TestWithJoinedStacks(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	joined := fmt.Errorf("checkout: %w", errors.Join(wrappedError(), createdError()))
+	NewReporter("key").Report(joined)
+	NewReporter("key", WithJoinedStacks()).Report(joined)
+	NewReporter("key", WithJoinedStacks(), WithDeepestStack()).Report(joined)
+
+	sent := server.Posts()
+	if len(sent) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(sent))
+	}
+	if data := sent[0].Details.Error.Data; data != nil {
+		t.Errorf("the stacks should not be added by default, got %v", data)
+	}
+
+	for i, first := range []string{"wrappedError", "createdError"} {
+		e := sent[i+1].Details.Error
+		if method := e.StackTrace[0].MethodName; method != first {
+			t.Errorf("the stacktrace should be the one of the first joined error, got %s", method)
+		}
+		stacks, _ := e.Data.(map[string]interface{})["additionalStacks"].([]interface{})
+		if len(stacks) != 1 {
+			t.Fatalf("expected the stack of the second error, got %v", e.Data)
+		}
+		if stack := stacks[0].(string); !strings.HasPrefix(stack, "github.com/chennqqi/crashreport.createdError\n\t") {
+			t.Errorf("the additional stack should be rendered, got %q", stack)
+		}
+	}
+}
+
+func TestRelateTo(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	original := NewPost()
+	original.Details.Error = FromErr(errors.New("connection reset"))
+	wrapper := NewPost()
+	wrapper.Details.Error = FromErr(fmt.Errorf("load cart: %w", errors.New("connection reset")))
+	fingerprint := Fingerprint(wrapper)
+
+	wrapper.RelateTo(Fingerprint(original))
+	wrapper.RelateTo(Fingerprint(original))
+	original.RelateTo(fingerprint)
+	if Fingerprint(wrapper) != fingerprint {
+		t.Error("the relations should not change the fingerprint")
+	}
+
+	reporter := NewReporter("key")
+	for _, post := range []Post{original, wrapper} {
+		if err := reporter.send(post); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	for i, other := range []Post{sent[1], sent[0]} {
+		related := sent[i].Details.UserCustomData.(map[string]interface{})["related"].([]interface{})
+		if len(related) != 1 || related[0] != Fingerprint(other) {
+			t.Errorf("post %d should be related to the other one, got %v", i, related)
+		}
+	}
+}
+
+func TestWith(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	parent := NewReporter("key", WithAsync(1, 10))
+	defer parent.Close(time.Second)
+	child := parent.With(WithTags("request"), WithUser("alice"))
+	grandchild := child.With(WithBreadcrumbs(Breadcrumb{Message: "handler"}))
+
+	parent.CaptureMessage("parent", WithTags("parent"))
+	child.CaptureMessage("child", WithTags("child"))
+	grandchild.CaptureMessage("grandchild")
+	if !child.Flush(time.Second) {
+		t.Fatal("the child should flush the queue of the parent")
+	}
+
+	sent := map[string]Post{}
+	for _, post := range server.Posts() {
+		sent[post.Details.Error.Message] = post
+	}
+	if len(sent) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(sent))
+	}
+	if hasTag(sent["parent"].Details.Tags, "request") || sent["parent"].Details.User.Identifier != "" {
+		t.Errorf("the defaults of the child should not apply to the parent, got %v", sent["parent"].Details)
+	}
+	if tags := sent["child"].Details.Tags; len(tags) < 2 || tags[0] != "request" || tags[1] != "child" {
+		t.Errorf("expected the default tags before the tags of the report, got %v", tags)
+	}
+	if sent["child"].Details.User.Identifier != "alice" {
+		t.Errorf("expected the default user, got %v", sent["child"].Details.User)
+	}
+	if crumbs := sent["grandchild"].Details.Breadcrumbs; !hasTag(sent["grandchild"].Details.Tags, "request") ||
+		len(crumbs) != 1 || crumbs[0].Message != "handler" {
+		t.Errorf("expected the defaults of the child and the grandchild, got %v", sent["grandchild"].Details)
+	}
+}
+
+// timeoutError returns errors with the same stack and different messages
+func timeoutError(after string) error {
+	return pkerr.New("timeout after " + after)
+}
+
+func TestWithStackOnlyFingerprint(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithStackOnlyFingerprint(3))
+	reporter.Report(timeoutError("5s"))
+	reporter.Report(timeoutError("3s"))
+	reporter.Report(pkerr.New("timeout after 5s"))
+
+	sent := server.Posts()
+	if len(sent) != 3 {
+		t.Fatalf("expected 3 posts, got %d", len(sent))
+	}
+	if Fingerprint(sent[0]) == Fingerprint(sent[1]) {
+		t.Error("the messages should change the default fingerprint")
+	}
+	if key := sent[0].Details.GroupingKey; key == "" || key != sent[1].Details.GroupingKey {
+		t.Errorf("expected the same grouping key for the same stack, got %q and %q", key, sent[1].Details.GroupingKey)
+	}
+	if StackFingerprint(sent[0], 3) != sent[0].Details.GroupingKey {
+		t.Error("the grouping key should be the stack fingerprint")
+	}
+	if sent[2].Details.GroupingKey == sent[0].Details.GroupingKey {
+		t.Error("errors raised elsewhere should have another grouping key")
+	}
+
+	var stackless Post
+	stackless.Details.Error.Message = "no stack"
+	if StackFingerprint(stackless, 3) != Fingerprint(stackless) {
+		t.Error("posts without stacktrace should fall back to Fingerprint")
+	}
+}
+
+// discardSink drops the posts, to measure the pipeline alone
+type discardSink struct{}
+
+func (discardSink) Send(ctx context.Context, post Post, key string) error {
+	return nil
+}
+
+func TestSendLean(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	clock := time.Now()
+	timeNow = func() time.Time { return clock } // the breadcrumbs of the operations are timestamped
+
+	var bodies [][]byte
+	inspect := func(body []byte) {
+		bodies = append(bodies, body)
+	}
+	lean := NewReporter("key", WithDryRun(true, inspect))
+	full := NewReporter("key", WithDryRun(true, inspect))
+	full.lean = false
+	if !lean.lean || NewReporter("key", WithVersion("1.0")).lean || NewReporter("key", WithSampleRate(0.5)).lean {
+		t.Error("only the reporters without transforming options should be lean")
+	}
+
+	post := NewPost()
+	post.Details.Error = FromErr(pkerr.New("boom"))
+	post.level = LevelWarning
+	for _, reporter := range []*Reporter{lean, full} {
+		reporter.TrackOperation("checkout", map[string]interface{}{"cart": 3})
+		if err := reporter.send(post); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(bodies) != 2 || string(bodies[0]) != string(bodies[1]) {
+		t.Errorf("expected the same post from both paths, got\n%s\n%s", bodies[0], bodies[1])
+	}
+}
+
+func BenchmarkSend(b *testing.B) {
+	post := NewPost()
+	post.Details.Error = FromErr(errors.New("boom"))
+	post.level = LevelWarning
+	for _, lean := range []bool{true, false} {
+		name := "full"
+		if lean {
+			name = "lean"
+		}
+		b.Run(name, func(b *testing.B) {
+			reporter := NewReporter("key", WithSink(discardSink{}))
+			reporter.lean = lean
+			reporter.TrackOperation("checkout", nil)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				reporter.send(post)
+			}
+		})
+	}
+}
+
+func TestReportMany(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	errs := []error{errors.New("item 1 failed"), nil, pkerr.New("item 3 failed"), nil, errors.New("item 5 failed")}
+	if err := reporter.ReportMany(errs, WithTags("job:42"), WithUser("worker")); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 3 {
+		t.Fatalf("expected a post per error, got %d", len(sent))
+	}
+	for i, message := range []string{"item 1 failed", "item 3 failed", "item 5 failed"} {
+		details := sent[i].Details
+		if details.Error.Message != message || !hasTag(details.Tags, "job:42") || details.User.Identifier != "worker" {
+			t.Errorf("expected %q with the shared tags and user, got %q %v %q", message, details.Error.Message,
+				details.Tags, details.User.Identifier)
+		}
+	}
+	if sent[1].Details.Error.StackTrace[0].MethodName != "TestReportMany" {
+		t.Errorf("expected the stacktrace of the error, got %+v", sent[1].Details.Error.StackTrace[0])
+	}
+}
@@ -0,0 +1,58 @@
+// Package reportgroup wraps golang.org/x/sync/errgroup so that every error returned by the goroutines of the group,
+// and every panic, is reported to Raygun.
+package reportgroup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chennqqi/crashreport"
+	"golang.org/x/sync/errgroup"
+)
+
+// Group is an errgroup.Group reporting the errors of its goroutines. Its semantics are the same: the first error
+// cancels the context returned by WithContext and is returned by Wait.
+type Group struct {
+	group    *errgroup.Group
+	reporter *crashreport.Reporter
+}
+
+// New returns a Group reporting to reporter
+func New(reporter *crashreport.Reporter) *Group {
+	return &Group{group: &errgroup.Group{}, reporter: reporter}
+}
+
+// WithContext returns a Group reporting to reporter, and a context cancelled when a goroutine of the group fails or
+// Wait returns
+func WithContext(ctx context.Context, reporter *crashreport.Reporter) (*Group, context.Context) {
+	group, ctx := errgroup.WithContext(ctx)
+	return &Group{group: group, reporter: reporter}, ctx
+}
+
+// Go calls f in a new goroutine. If f returns an error it's reported, then handled by the group. If f panics the panic
+// is recovered, reported, and handled by the group as an error.
+func (g *Group) Go(f func() error) {
+	g.group.Go(func() (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				if e, ok := v.(error); ok {
+					err = e
+				} else {
+					err = fmt.Errorf("panic: %v", v)
+				}
+				g.reporter.Report(err)
+			}
+		}()
+
+		err = f()
+		if err != nil {
+			g.reporter.Report(err)
+		}
+		return err
+	})
+}
+
+// Wait blocks until all the goroutines of the group have returned, then returns the first error
+func (g *Group) Wait() error {
+	return g.group.Wait()
+}
@@ -0,0 +1,56 @@
+package reportgroup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/chennqqi/crashreport"
+)
+
+func TestGroup(t *testing.T) {
+	var mu sync.Mutex
+	var messages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var post crashreport.Post
+		json.NewDecoder(req.Body).Decode(&post)
+		mu.Lock()
+		messages = append(messages, post.Details.Error.Message)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	oldEndpoint := crashreport.Endpoint
+	crashreport.Endpoint = server.URL
+	defer func() { crashreport.Endpoint = oldEndpoint }()
+
+	group, ctx := WithContext(context.Background(), crashreport.NewReporter("key"))
+
+	failed := errors.New("failed")
+	group.Go(func() error { return failed })
+	group.Go(func() error { panic("boom") })
+	group.Go(func() error { return nil })
+	group.Go(func() error {
+		<-ctx.Done()
+		return nil
+	})
+
+	err := group.Wait()
+	if err != failed && (err == nil || err.Error() != "panic: boom") {
+		t.Errorf("Wait should return the first error, got %v", err)
+	}
+	if ctx.Err() == nil {
+		t.Error("context should be cancelled")
+	}
+
+	sort.Strings(messages)
+	if len(messages) != 2 || messages[0] != "failed" || messages[1] != "panic: boom" {
+		t.Errorf("the error and the panic should be reported, got %v", messages)
+	}
+}
@@ -0,0 +1,83 @@
+package crashreport
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is returned by DrainQueue when the retries are over the budget set with WithRetryBudget
+var ErrRetryBudgetExhausted = errors.New("crashreport: retry budget exhausted")
+
+// Parameters of the retry budget
+const (
+	retryBudgetHalfLife = 10 * time.Second // of the counts of the submissions and of the retries
+	retryReserve        = 10               // retries allowed on top of the ratio, to drain the queue after a start
+)
+
+// WithRetryBudget caps the retries to ratio times the reports submitted recently, such as 0.1 for a retry every ten
+// reports, so that the reports failing during an outage aren't retried all at once against a struggling Raygun.
+// The retries are the submissions of DrainQueue: when the budget is exhausted it stops, leaving the remaining reports
+// in the disk queue, and returns ErrRetryBudgetExhausted. A reserve of 10 retries is always allowed, and the counts
+// halve every 10 seconds. The share of the budget used is in Stats().RetryBudgetUsed.
+func WithRetryBudget(ratio float64) Option {
+	return func(r *Reporter) {
+		r.retryBudget = &retryBudget{ratio: ratio, at: timeNow()}
+	}
+}
+
+// retryBudget counts the submissions and the retries with an exponential decay
+type retryBudget struct {
+	mu       sync.Mutex
+	ratio    float64
+	requests float64
+	retries  float64
+	at       time.Time // of the last update of the counts
+}
+
+// decay updates the counts to now
+func (b *retryBudget) decay() {
+	now := timeNow()
+	factor := math.Exp2(-float64(now.Sub(b.at)) / float64(retryBudgetHalfLife))
+	b.requests *= factor
+	b.retries *= factor
+	b.at = now
+}
+
+// request counts a first submission
+func (b *retryBudget) request() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.decay()
+	b.requests++
+}
+
+// retry counts a retry if the budget allows it
+func (b *retryBudget) retry() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.decay()
+	if b.retries+1 > b.ratio*b.requests+retryReserve {
+		return false
+	}
+	b.retries++
+	return true
+}
+
+// used returns the share of the budget used, between 0 and 1
+func (b *retryBudget) used() float64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.decay()
+	return math.Min(1, b.retries/(b.ratio*b.requests+retryReserve))
+}
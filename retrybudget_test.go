@@ -0,0 +1,59 @@
+package crashreport
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithRetryBudget(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	clock := time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return clock }
+
+	server := mockRaygun(t)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "crashreport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server.Reject(func(w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+	reporter := NewReporter("key", WithDiskQueue(dir), WithRetryBudget(0.1))
+	for i := 0; i < 100; i++ {
+		reporter.Report(errors.New("outage"))
+	}
+
+	server.Reject(nil)
+	if err := reporter.DrainQueue(); err != ErrRetryBudgetExhausted {
+		t.Fatalf("expected the drain to stop on the budget, got %v", err)
+	}
+	if sent := len(server.Posts()); sent != 20 {
+		t.Errorf("expected 10%% of the reports plus the reserve to be retried, got %d", sent)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 80 {
+		t.Errorf("expected the other reports to stay queued, got %d", len(files))
+	}
+	if used := reporter.Stats().RetryBudgetUsed; used != 1 {
+		t.Errorf("expected the budget to be used up, got %f", used)
+	}
+
+	clock = clock.Add(time.Minute)
+	if used := reporter.Stats().RetryBudgetUsed; used > 0.05 {
+		t.Errorf("expected the budget to recover, got %f used", used)
+	}
+	if err := reporter.DrainQueue(); err != ErrRetryBudgetExhausted {
+		t.Fatalf("expected the drain to stop on the reserve, got %v", err)
+	}
+	if sent := len(server.Posts()); sent < 29 || sent > 30 {
+		t.Errorf("expected the reserve to be retried after a minute, got %d", sent-20)
+	}
+}
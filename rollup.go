@@ -0,0 +1,118 @@
+package crashreport
+
+import (
+	"sync"
+	"time"
+)
+
+// rollupTag opts a report in the rollups of WithRollup
+const rollupTag = "rollup"
+
+// WithRollup rolls up the occurrences of the noisy errors of low value into summaries: the first occurrence of an
+// error starts an interval, during which the other occurrences are only counted. When the interval ends, the first
+// occurrence is submitted as a sample, with the number of occurrences and the times of the first and the last one
+// under the "rollup" key of the custom data. Errors are identified by their Fingerprint, or by their StackFingerprint
+// with WithStackOnlyFingerprint. The rollups still open are submitted by Close.
+//
+// Only the reports tagged "rollup", or matched by match if not nil, are rolled up:
+//
+//	crashreport.WithRollup(time.Minute, func(post crashreport.Post) bool {
+//		return post.Level() <= crashreport.LevelWarning
+//	})
+func WithRollup(interval time.Duration, match func(Post) bool) Option {
+	return func(r *Reporter) {
+		r.rollup.interval = interval
+		r.rollup.match = match
+		r.rollup.groups = map[string]*rollupGroup{}
+	}
+}
+
+// rollup counts the occurrences of each error until the end of their interval
+type rollup struct {
+	interval time.Duration
+	match    func(Post) bool
+
+	mu     sync.Mutex
+	groups map[string]*rollupGroup // by fingerprint
+}
+
+type rollupGroup struct {
+	pendingPost
+	timer       *time.Timer
+	count       int
+	first, last time.Time // the occurrence times
+}
+
+// report returns the sample of the error, with the summary of its occurrences
+func (g *rollupGroup) report() Post {
+	post := g.post
+	post.SetCustomData("rollup", map[string]interface{}{
+		"count":           g.count,
+		"firstOccurredOn": g.first.UTC().Format(TimeFormat),
+		"lastOccurredOn":  g.last.UTC().Format(TimeFormat),
+	})
+	return post
+}
+
+// rolledUp tells if the post is rolled up, see WithRollup
+func (r *Reporter) rolledUp(post Post) bool {
+	if hasTag(post.Details.Tags, rollupTag) {
+		return true
+	}
+	matched := false
+	if r.rollup.match != nil {
+		safely("rollup matcher", func() { matched = r.rollup.match(post) })
+	}
+	return matched
+}
+
+// rolled counts the post in the rollup of its error, or opens one if there is none
+func (r *Reporter) rolled(post Post, key string) {
+	fingerprint := r.fingerprint(post)
+	at := occurredAt(post)
+
+	r.rollup.mu.Lock()
+	if g, ok := r.rollup.groups[fingerprint]; ok {
+		g.count++
+		if at.Before(g.first) {
+			g.first = at
+		}
+		if at.After(g.last) {
+			g.last = at
+		}
+		r.rollup.mu.Unlock()
+		r.dropped(post, DropDeduped)
+		return
+	}
+	g := &rollupGroup{pendingPost: pendingPost{post, key}, count: 1, first: at, last: at}
+	post.outcome.record(OutcomeQueued, nil)
+	g.timer = time.AfterFunc(r.rollup.interval, func() {
+		r.rollup.mu.Lock()
+		if r.rollup.groups[fingerprint] == g {
+			delete(r.rollup.groups, fingerprint)
+		}
+		post := g.report()
+		r.rollup.mu.Unlock()
+
+		r.dispatch(post, g.key)
+	})
+	r.rollup.groups[fingerprint] = g
+	r.rollup.mu.Unlock()
+}
+
+// flushRollups submits the open rollups without waiting for the end of their interval
+func (r *Reporter) flushRollups() {
+	r.rollup.mu.Lock()
+	var pending []pendingPost
+	for fingerprint, g := range r.rollup.groups {
+		if g.timer.Stop() {
+			pending = append(pending, pendingPost{g.report(), g.key})
+			delete(r.rollup.groups, fingerprint)
+		}
+	}
+	r.rollup.mu.Unlock()
+
+	for _, p := range pending {
+		r.dispatch(p.post, p.key)
+	}
+}
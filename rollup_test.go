@@ -0,0 +1,47 @@
+package crashreport
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithRollup(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithRollup(100*time.Millisecond, func(post Post) bool {
+		return post.Details.Error.Message == "cache miss"
+	}))
+	for i := 0; i < 50; i++ {
+		reporter.Report(errors.New("cache miss"))
+	}
+	reporter.Report(errors.New("checkout failed"))
+	if n := len(server.Posts()); n != 1 {
+		t.Fatalf("only the error not rolled up should be sent before the end of the interval, got %d posts", n)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(server.Posts()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected the summary after the interval, got %d posts", len(sent))
+	}
+	summary := sent[1].Details
+	if summary.Error.Message != "cache miss" {
+		t.Errorf("expected the sample of the rolled up error, got %q", summary.Error.Message)
+	}
+	data := summary.UserCustomData.(map[string]interface{})["rollup"].(map[string]interface{})
+	if data["count"] != float64(50) || data["firstOccurredOn"] == nil || data["lastOccurredOn"] == nil {
+		t.Errorf("expected the summary of the 50 occurrences, got %v", data)
+	}
+
+	reporter.Report(errors.New("tagged"), WithTags("rollup"))
+	reporter.Close(time.Second)
+	if sent := server.Posts(); len(sent) != 3 ||
+		sent[2].Details.UserCustomData.(map[string]interface{})["rollup"] == nil {
+		t.Errorf("expected the tagged report to be rolled up and flushed by Close, got %d posts", len(sent))
+	}
+}
@@ -0,0 +1,252 @@
+package crashreport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RoundTripperOption configures a ReportingRoundTripper
+type RoundTripperOption func(*ReportingRoundTripper)
+
+// WithReportedStatus reports the responses with one of the status codes, in addition to the transport errors. By
+// default the responses are never reported.
+func WithReportedStatus(codes ...int) RoundTripperOption {
+	return func(t *ReportingRoundTripper) {
+		for _, code := range codes {
+			t.statuses[code] = true
+		}
+	}
+}
+
+// WithRoundTripperOptions sets options applied to the reports of the round trips, such as tags
+func WithRoundTripperOptions(opts ...ReportOption) RoundTripperOption {
+	return func(t *ReportingRoundTripper) {
+		t.opts = append(t.opts, opts...)
+	}
+}
+
+// WithOutboundBreadcrumbs records every request as a breadcrumb of category "http-client", with its method, host,
+// status and duration, so that the reports show the last calls to other services. The url has no user info, query or
+// fragment, and the headers are left out. The breadcrumbs go to the context of the request if it collects them, see
+// ContextWithBreadcrumbs, and to the history of the operations of the reporter otherwise, see TrackOperation: the
+// last ones are added to every report, as many as WithOperationHistory keeps.
+func WithOutboundBreadcrumbs() RoundTripperOption {
+	return func(t *ReportingRoundTripper) {
+		t.breadcrumbs = true
+	}
+}
+
+// WithResponseBody attaches the beginning of the body of the reported responses, maxBytes at most, under the
+// "responseBody" key of the custom data, since the error message of the other service is often the best clue. Only the
+// responses with the status codes of WithReportedStatus are read, and their body is given back whole to the caller.
+// The values of the sensitive fields, such as token or password, and of the scrub ones are replaced by "[REDACTED]",
+// in the json objects and in the form encoded bodies. The names are compared case-insensitively.
+func WithResponseBody(maxBytes int, scrub ...string) RoundTripperOption {
+	return func(t *ReportingRoundTripper) {
+		t.bodyLimit = maxBytes
+		t.bodyScrub = newBodyScrubber(append(sensitiveQueryParams, scrub...))
+	}
+}
+
+// ReportingRoundTripper is an http.RoundTripper reporting the failures of the outgoing requests: the errors of the
+// transport, and the responses with the status codes of WithReportedStatus. Install it on a client:
+//
+//	client := &http.Client{Transport: crashreport.NewReportingRoundTripper(reporter, http.DefaultTransport,
+//		crashreport.WithReportedStatus(http.StatusInternalServerError, http.StatusBadGateway))}
+//
+// The reports are tagged "http-client", and have the method, the url and the headers of the request, filtered as the
+// ones of the incoming requests, see WithHeaderAllowlist. The values of the query and the body are left out. The
+// status and the headers of the response are under the "responseStatus" and "responseHeaders" keys of the custom
+// data, and the beginning of its body under "responseBody" with WithResponseBody. The response and the error are
+// returned unchanged.
+//
+// The reports are submitted before RoundTrip returns, unless the reporter is asynchronous, see WithAsync. The
+// requests of the submissions themselves are never reported, so the client of the reporter can use the round
+// tripper too.
+type ReportingRoundTripper struct {
+	reporter *Reporter
+	base     http.RoundTripper
+	statuses map[int]bool
+	opts     []ReportOption
+
+	breadcrumbs bool // see WithOutboundBreadcrumbs
+	bodyLimit   int  // see WithResponseBody
+	bodyScrub   bodyScrubber
+}
+
+// NewReportingRoundTripper creates a round tripper reporting the failures of base, http.DefaultTransport if nil
+func NewReportingRoundTripper(reporter *Reporter, base http.RoundTripper,
+	opts ...RoundTripperOption) *ReportingRoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &ReportingRoundTripper{reporter: reporter, base: base, statuses: map[int]bool{}}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// RoundTrip executes the request with the base round tripper, and reports its failure
+func (t *ReportingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := timeNow()
+	resp, err := t.base.RoundTrip(req)
+	if isSubmission(req.Context()) {
+		return resp, err
+	}
+	if t.breadcrumbs {
+		t.record(req, resp, err, start)
+	}
+
+	switch {
+	case err != nil:
+		t.report(req, nil, fmt.Errorf("%s %s: %w", req.Method, redactedURL(req.URL), err))
+	case t.statuses[resp.StatusCode]:
+		t.report(req, resp, &statusError{method: req.Method, url: redactedURL(req.URL), status: resp.Status})
+	}
+	return resp, err
+}
+
+// report submits the failure of the round trip
+func (t *ReportingRoundTripper) report(req *http.Request, resp *http.Response, err error) {
+	r := t.reporter
+	post := r.newPost()
+	post.Details.Error = FromErr(err)
+	post.err = err
+	post.Details.Request = Request{
+		HostName:   req.URL.Host,
+		URL:        redactedURL(req.URL),
+		HTTPMethod: req.Method,
+		Headers:    r.headers.apply(req.Header),
+	}
+	post.Details.Tags = append(post.Details.Tags, "http-client")
+	if resp != nil {
+		post.SetCustomData("responseStatus", resp.StatusCode)
+		if headers := r.headers.apply(resp.Header); len(headers) > 0 {
+			post.SetCustomData("responseHeaders", headers)
+		}
+		if t.bodyLimit > 0 {
+			if body := t.peekBody(resp); body != "" {
+				post.SetCustomData("responseBody", body)
+			}
+		}
+	}
+	identify(&post, callerOutside("net/http."))
+	r.send(post, t.opts...)
+}
+
+// record adds the breadcrumb of a round trip that started at start, see WithOutboundBreadcrumbs
+func (t *ReportingRoundTripper) record(req *http.Request, resp *http.Response, err error, start time.Time) {
+	crumb := Breadcrumb{
+		Category:  "http-client",
+		Type:      "request",
+		Timestamp: millis(start),
+		Level:     BreadcrumbInfo,
+	}
+	data := map[string]interface{}{
+		"method":     req.Method,
+		"host":       req.URL.Host,
+		"url":        redactedURL(req.URL),
+		"durationMs": timeNow().Sub(start).Milliseconds(),
+	}
+	switch {
+	case err != nil:
+		crumb.Message = fmt.Sprintf("%s %s: %v", req.Method, req.URL.Host, err)
+		crumb.Level = BreadcrumbError
+		data["error"] = err.Error()
+	default:
+		crumb.Message = fmt.Sprintf("%s %s %d", req.Method, req.URL.Host, resp.StatusCode)
+		data["status"] = resp.StatusCode
+		if resp.StatusCode >= 500 {
+			crumb.Level = BreadcrumbError
+		} else if resp.StatusCode >= 400 {
+			crumb.Level = BreadcrumbWarning
+		}
+	}
+	crumb.CustomData = data
+
+	if !AddBreadcrumb(req.Context(), crumb) {
+		t.reporter.operations.add(crumb)
+	}
+}
+
+// peekBody returns the beginning of the body of the response, scrubbed, and puts it back in front of the rest of the
+// body for the caller
+func (t *ReportingRoundTripper) peekBody(resp *http.Response) string {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return ""
+	}
+	var head bytes.Buffer
+	io.CopyN(&head, resp.Body, int64(t.bodyLimit))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head.Bytes()), resp.Body), resp.Body}
+	return t.bodyScrub.scrub(head.String())
+}
+
+// bodyScrubber redacts the values of the sensitive fields of a body, see WithResponseBody
+type bodyScrubber struct {
+	json *regexp.Regexp // "field": value, the string possibly cut by the limit
+	form *regexp.Regexp // field=value
+}
+
+func newBodyScrubber(fields []string) bodyScrubber {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = regexp.QuoteMeta(field)
+	}
+	alt := strings.Join(names, "|")
+	return bodyScrubber{
+		json: regexp.MustCompile(`(?i)("(?:` + alt + `)"\s*:\s*)(?:"(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`),
+		form: regexp.MustCompile(`(?i)(^|[&\s])((?:` + alt + `)=)[^&\s]*`),
+	}
+}
+
+// scrub returns the body with the values of the sensitive fields redacted
+func (s bodyScrubber) scrub(body string) string {
+	body = s.json.ReplaceAllString(body, `${1}"`+redacted+`"`)
+	return s.form.ReplaceAllString(body, "${1}${2}"+redacted)
+}
+
+// statusError is the error reported for a response with one of the status codes of WithReportedStatus
+type statusError struct {
+	method, url, status string
+}
+
+func (e *statusError) Error() string {
+	return e.method + " " + e.url + ": " + e.status
+}
+
+func (e *statusError) Class() string {
+	return "HTTPStatusError"
+}
+
+// redactedURL returns the url without its user info, query and fragment
+func redactedURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ""
+	redacted.ForceQuery = false
+	redacted.Fragment = ""
+	redacted.RawFragment = ""
+	return redacted.String()
+}
+
+// submission marks the context of the requests submitting the reports, which ReportingRoundTripper doesn't report
+func submission(ctx context.Context) context.Context {
+	return context.WithValue(ctx, submissionKey, true)
+}
+
+// isSubmission tells if the context is the one of a submission, see submission
+func isSubmission(ctx context.Context) bool {
+	submitting, _ := ctx.Value(submissionKey).(bool)
+	return submitting
+}
@@ -0,0 +1,85 @@
+package crashreport
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// SetRoute replaces URL with the route template that matched the request, such as /users/{id} instead of
+// /users/12345, so that Raygun groups the errors of a route together. The raw path is kept in the custom data of the
+// report, under the "rawPath" key.
+func (r *Request) SetRoute(template, rawPath string) {
+	r.URL = template
+	r.rawPath = rawPath
+}
+
+// WithRouteTemplate makes the middleware report the route template of the request instead of its url, see
+// Request.SetRoute. The function is called with the request received by the handler, and returns the template of the
+// route that matched it, or an empty string to keep the url. With the ServeMux of go 1.22:
+//
+//	reporter.Middleware(mux, crashreport.WithRouteTemplate(func(req *http.Request) string {
+//		return req.Pattern
+//	}))
+func WithRouteTemplate(route func(*http.Request) string) MiddlewareOption {
+	return func(m *middleware) {
+		m.route = route
+	}
+}
+
+// WithHandlerIdentifier makes the middleware set the identifier of the report's context to the name of the handler that
+// crashed, see HandlerName, so that the reports tell which handler failed whatever the path. The function is called
+// with the request and returns the handler that matched it, or nil for the handler wrapped by the middleware. With a
+// ServeMux:
+//
+//	reporter.Middleware(mux, crashreport.WithHandlerIdentifier(func(req *http.Request) http.Handler {
+//		h, _ := mux.Handler(req)
+//		return h
+//	}))
+//
+// When the handler has no name, such as a closure, the identifier is the route template of WithRouteTemplate if any.
+func WithHandlerIdentifier(match func(*http.Request) http.Handler) MiddlewareOption {
+	return func(m *middleware) {
+		m.handler = match
+	}
+}
+
+// closureName matches the names the compiler gives to the anonymous functions, such as main.main.func1
+var closureName = regexp.MustCompile(`\.func\d+(\.\d+)*$`)
+
+// HandlerName returns the name of the function of the handler, such as "github.com/acme/shop/api.getUser" for an
+// http.HandlerFunc or "github.com/acme/shop/api.(*Users).ServeHTTP" for a type implementing http.Handler. The method
+// values are named after their method. It returns an empty string for the anonymous functions, whose names are
+// generated.
+func HandlerName(h http.Handler) string {
+	if h == nil {
+		return ""
+	}
+	var pc uintptr
+	if f, ok := h.(http.HandlerFunc); ok {
+		if f == nil {
+			return ""
+		}
+		pc = reflect.ValueOf(f).Pointer()
+	} else if method, ok := reflect.TypeOf(h).MethodByName("ServeHTTP"); ok {
+		pc = method.Func.Pointer()
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+	name := strings.TrimSuffix(fn.Name(), "-fm")
+	if closureName.MatchString(name) {
+		return ""
+	}
+	return name
+}
+
+// applyRoute moves the raw path set by Request.SetRoute to the custom data
+func applyRoute(post *Post) {
+	if post.Details.Request.rawPath != "" {
+		post.SetCustomData("rawPath", post.Details.Request.rawPath)
+	}
+}
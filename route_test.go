@@ -0,0 +1,120 @@
+package crashreport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestMiddlewareRouteTemplate(t *testing.T) {
+	routes := []struct {
+		pattern  *regexp.Regexp
+		template string
+	}{
+		{regexp.MustCompile(`^/users/\d+$`), "/users/{id}"},
+		{regexp.MustCompile(`^/orders/\d+/items/\d+$`), "/orders/{order}/items/{item}"},
+		{regexp.MustCompile(`^/files/.+$`), "/files/{path...}"},
+	}
+	route := func(req *http.Request) string {
+		for _, r := range routes {
+			if r.pattern.MatchString(req.URL.Path) {
+				return r.template
+			}
+		}
+		return ""
+	}
+
+	tests := []struct {
+		path, url, rawPath string
+	}{
+		{"/users/12345", "/users/{id}", "/users/12345"},
+		{"/orders/7/items/3?expand=true", "/orders/{order}/items/{item}", "/orders/7/items/3"},
+		{"/files/docs/report.pdf", "/files/{path...}", "/files/docs/report.pdf"},
+		{"/health", "/health", ""},
+	}
+	for _, test := range tests {
+		server := mockRaygun(t)
+		reporter := NewReporter("key")
+		handler := reporter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			panic("boom")
+		}), WithRouteTemplate(route))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", test.path, nil))
+		server.Close()
+
+		sent := server.Posts()
+		if len(sent) != 1 {
+			t.Fatalf("%s: expected 1 post, got %d", test.path, len(sent))
+		}
+		if url := sent[0].Details.Request.URL; url != test.url {
+			t.Errorf("%s: expected url %s, got %s", test.path, test.url, url)
+		}
+		data, _ := sent[0].Details.UserCustomData.(map[string]interface{})
+		if rawPath, _ := data["rawPath"].(string); rawPath != test.rawPath {
+			t.Errorf("%s: expected raw path %q, got %q", test.path, test.rawPath, rawPath)
+		}
+	}
+}
+
+func getUser(w http.ResponseWriter, req *http.Request) {
+	panic("boom")
+}
+
+type usersHandler struct{}
+
+func (*usersHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	panic("boom")
+}
+
+func (*usersHandler) list(w http.ResponseWriter, req *http.Request) {
+	panic("boom")
+}
+
+func TestHandlerName(t *testing.T) {
+	tests := []struct {
+		handler http.Handler
+		name    string
+	}{
+		{http.HandlerFunc(getUser), "github.com/chennqqi/crashreport.getUser"},
+		{&usersHandler{}, "github.com/chennqqi/crashreport.(*usersHandler).ServeHTTP"},
+		{http.HandlerFunc((&usersHandler{}).list), "github.com/chennqqi/crashreport.(*usersHandler).list"},
+		{http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}), ""},
+		{nil, ""},
+	}
+	for _, test := range tests {
+		if name := HandlerName(test.handler); name != test.name {
+			t.Errorf("expected %q, got %q", test.name, name)
+		}
+	}
+}
+
+func TestMiddlewareHandlerIdentifier(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/", getUser)
+	mux.HandleFunc("/orders/", func(w http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+	reporter := NewReporter("key")
+	handler := reporter.Middleware(mux, WithRouteTemplate(func(req *http.Request) string {
+		return "/orders/{id}"
+	}), WithHandlerIdentifier(func(req *http.Request) http.Handler {
+		h, _ := mux.Handler(req)
+		return h
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/12", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/7", nil))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	if id := sent[0].Details.Context.Identifier; id != "github.com/chennqqi/crashreport.getUser" {
+		t.Errorf("expected the name of the handler, got %q", id)
+	}
+	if id := sent[1].Details.Context.Identifier; id != "/orders/{id}" {
+		t.Errorf("expected the route of the closure, got %q", id)
+	}
+}
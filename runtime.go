@@ -0,0 +1,26 @@
+package crashreport
+
+import (
+	"runtime"
+)
+
+// WithMemoryPressure flags the reports generated while the heap is bigger than threshold bytes, which may be close to
+// an out of memory crash: they get a "memory-pressure" tag, and the HeapAlloc, Sys and NumGC memory stats in custom
+// data. Reading the memory stats briefly stops the world, so it's only done with this option.
+func WithMemoryPressure(threshold uint64) Option {
+	return func(r *Reporter) {
+		r.enrichers = append(r.enrichers, func(post *Post) {
+			var stats runtime.MemStats
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc < threshold {
+				return
+			}
+			post.Details.Tags = append(post.Details.Tags, "memory-pressure")
+			post.SetCustomData("memory", map[string]interface{}{
+				"heapAlloc": stats.HeapAlloc,
+				"sys":       stats.Sys,
+				"numGC":     stats.NumGC,
+			})
+		})
+	}
+}
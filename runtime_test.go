@@ -0,0 +1,72 @@
+package crashreport
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestWithMemoryPressure(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	NewReporter("key", WithMemoryPressure(1)).Report(errors.New("under pressure"))
+	NewReporter("key", WithMemoryPressure(1<<62)).Report(errors.New("relaxed"))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+
+	pressured := sent[0].Details
+	if len(pressured.Tags) != 1 || pressured.Tags[0] != "memory-pressure" {
+		t.Errorf("expected the memory-pressure tag, got %v", pressured.Tags)
+	}
+	memory := pressured.UserCustomData.(map[string]interface{})["memory"].(map[string]interface{})
+	if memory["heapAlloc"].(float64) <= 0 || memory["sys"].(float64) <= 0 {
+		t.Errorf("expected memory stats, got %v", memory)
+	}
+	if _, ok := memory["numGC"]; !ok {
+		t.Error("expected numGC in the memory stats")
+	}
+
+	if len(sent[1].Details.Tags) != 0 || sent[1].Details.UserCustomData != nil {
+		t.Error("reports under the threshold should not be flagged")
+	}
+}
+
+func TestWithRuntimeMetrics(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	runtime.GC()
+	NewReporter("key", WithRuntimeMetrics()).Report(errors.New("default metrics"))
+	NewReporter("key", WithRuntimeMetrics("/gc/heap/allocs:bytes", "/unknown:units")).Report(errors.New("configured"))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+
+	values := sent[0].Details.UserCustomData.(map[string]interface{})["runtimeMetrics"].(map[string]interface{})
+	if n, _ := values[MetricGoroutines].(float64); n < 1 {
+		t.Errorf("expected the number of goroutines, got %v", values[MetricGoroutines])
+	}
+	if _, ok := values[MetricHeapObjects].(float64); !ok {
+		t.Errorf("expected the number of heap objects, got %v", values[MetricHeapObjects])
+	}
+	if pauses, ok := values[MetricGCPauses].(map[string]interface{}); !ok || pauses["p99"] == nil {
+		t.Errorf("expected the summary of the GC pauses, got %v", values[MetricGCPauses])
+	}
+	if tags := sent[0].Details.Tags; !hasTag(tags, "goroutines:<100") || len(tags) != 2 {
+		t.Errorf("expected the goroutines and gc-pause tags, got %v", tags)
+	}
+
+	configured := sent[1].Details.UserCustomData.(map[string]interface{})["runtimeMetrics"].(map[string]interface{})
+	if len(configured) != 1 || configured["/gc/heap/allocs:bytes"] == nil {
+		t.Errorf("expected only the configured metric, got %v", configured)
+	}
+	if len(sent[1].Details.Tags) != 0 {
+		t.Errorf("expected no tag without the default metrics, got %v", sent[1].Details.Tags)
+	}
+}
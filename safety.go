@@ -0,0 +1,52 @@
+package crashreport
+
+import (
+	"context"
+	"log"
+
+	"github.com/pkg/errors"
+)
+
+// safely runs a callback given to the reporter, recovering its panic: a bug in a hook must neither crash the
+// application nor lose the report. The panic is logged and false returned, so that the caller falls back to the post
+// as it was before the stage, or skips it.
+func safely(stage string, fn func()) (ok bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("crashreport: %s panicked, skipped: %v", stage, v)
+			ok = false
+		}
+	}()
+	fn()
+	return true
+}
+
+// sinkSend sends the post with the sink, converting its panic to an error so that the disk queue and the last resort
+// file still apply
+func sinkSend(ctx context.Context, sink Sink, post Post, key string) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = errors.Errorf("sink panicked: %v", v)
+		}
+	}()
+	return sink.Send(ctx, post, key)
+}
+
+// encode encodes the post with the codec, converting its panic to an error
+func encode(codec Codec, post Post) (data []byte, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = errors.Errorf("codec panicked: %v", v)
+		}
+	}()
+	return codec.Encode(post)
+}
+
+// applySafely applies a report option or an enricher to the post. If it panics the post is restored as it was before,
+// except for the changes made in place in its maps.
+func applySafely(stage string, post *Post, fn func(*Post)) {
+	before := *post
+	if !safely(stage, func() { fn(post) }) {
+		*post = before
+	}
+}
@@ -0,0 +1,74 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type panickingSink struct{}
+
+func (panickingSink) Send(ctx context.Context, post Post, key string) error {
+	panic("sink bug")
+}
+
+func TestPanickingCallbacks(t *testing.T) {
+	boom := func() { panic("callback bug") }
+	tests := []struct {
+		name   string
+		opts   []Option
+		report ReportOption
+	}{
+		{"message transform", []Option{WithMessageTransform(func(string) string { boom(); return "" })}, nil},
+		{"key router", []Option{WithKeyRouter(func(Post) string { boom(); return "" })}, nil},
+		{"breadcrumb source", []Option{WithBreadcrumbSource(func() []Breadcrumb { boom(); return nil })}, nil},
+		{"ignore matcher", []Option{WithIgnoreErrors(func(error) bool { boom(); return true })}, nil},
+		{"custom data marshaler", []Option{WithCustomDataMarshaler(func(interface{}) ([]byte, error) { boom(); return nil, nil })}, nil},
+		{"report option", nil, func(post *Post) { post.Details.Tags = append(post.Details.Tags, "x"); boom() }},
+		{"drop observer", []Option{WithKeyRouter(func(post Post) string {
+			if post.Details.Error.Message == "vetoed" {
+				return ""
+			}
+			return "key"
+		}), WithDropObserver(func(Post, DropReason) { boom() })}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := mockRaygun(t)
+			defer server.Close()
+
+			reporter := NewReporter("key", tt.opts...)
+			reporter.Report(errors.New("vetoed"))
+			opts := []ReportOption{WithCustomData("attempt", 1)}
+			if tt.report != nil {
+				opts = append(opts, tt.report)
+			}
+			err := reporter.Report(errors.New("invalid configuration"), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sent := server.Posts()
+			if len(sent) == 0 || sent[len(sent)-1].Details.Error.Message != "invalid configuration" {
+				t.Fatalf("expected the report to be submitted despite the panic, got %+v", sent)
+			}
+			if tags := sent[len(sent)-1].Details.Tags; hasTag(tags, "x") {
+				t.Errorf("expected the panicking option to be rolled back, got tags %v", tags)
+			}
+		})
+	}
+}
+
+func TestPanickingSinkAndCallback(t *testing.T) {
+	var outcome ReportOutcome
+	reporter := NewReporter("key", WithSink(panickingSink{}))
+	err := reporter.Report(errors.New("invalid configuration"), WithDeliveryCallback(func(o ReportOutcome, err error) {
+		outcome = o
+		panic("callback bug")
+	}))
+	if err == nil {
+		t.Fatal("expected the panic of the sink to be returned as an error")
+	}
+	if outcome != OutcomeFailed {
+		t.Errorf("expected the callback to get the failure, got %s", outcome)
+	}
+}
@@ -0,0 +1,145 @@
+package crashreport
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxSampledErrors is the number of errors tracked by the adaptive sampling above which the rare ones are forgotten
+const maxSampledErrors = 1000
+
+// WithAdaptiveSampling samples the frequent errors while keeping the rare ones. The frequency of each error is
+// counted with an exponential decay: an occurrence weighs 1, and half as much after halfLife. Errors whose
+// frequency is under threshold are always reported, the others are reported with a probability of
+// threshold / frequency, so that each error is reported about threshold times per halfLife at most.
+// Errors are identified by their Fingerprint, or by their StackFingerprint with WithStackOnlyFingerprint. The rate of
+// each error and the dropped reports are in Stats().
+// The half-life defaults to a minute.
+func WithAdaptiveSampling(threshold float64, halfLife time.Duration) Option {
+	return func(r *Reporter) {
+		if halfLife <= 0 {
+			halfLife = time.Minute
+		}
+		r.sampling.threshold = threshold
+		r.sampling.halfLife = halfLife
+		r.sampling.errors = map[string]*frequency{}
+	}
+}
+
+// WithSampleRate reports a random fraction of the reports, between 0 and 1, regardless of their error. The dropped
+// reports are counted in Stats().Sampled. It can be combined with WithAdaptiveSampling, which applies to the reports
+// kept.
+func WithSampleRate(rate float64) Option {
+	return func(r *Reporter) {
+		r.sampling.rate = rate
+		r.sampling.fixed = true
+	}
+}
+
+// WithLevelSampleRates sets the sample rate of the reports of each level, between 0 and 1, so that the debug and info
+// reports can be sampled aggressively while the errors are all reported:
+//
+//	crashreport.WithLevelSampleRates(map[crashreport.Level]float64{
+//		crashreport.LevelDebug: 0.01,
+//		crashreport.LevelInfo:  0.1,
+//	})
+//
+// The levels that aren't in rates are reported with the rate of WithSampleRate, or all of them. The reports without a
+// level are errors, see Post.Level. The dropped reports are counted in Stats().Sampled.
+func WithLevelSampleRates(rates map[Level]float64) Option {
+	return func(r *Reporter) {
+		r.sampling.levels = make(map[Level]float64, len(rates))
+		for level, rate := range rates {
+			r.sampling.levels[level] = rate
+		}
+	}
+}
+
+// sampling holds the sample rate and the frequency of each error
+type sampling struct {
+	rate      float64
+	fixed     bool              // rate is set
+	levels    map[Level]float64 // overriding rate
+	threshold float64
+	halfLife  time.Duration
+
+	mu     sync.Mutex
+	errors map[string]*frequency // by fingerprint
+}
+
+type frequency struct {
+	score float64
+	at    time.Time // of the last update of score
+	rate  float64   // the last sampling rate
+}
+
+// decayed returns the score at the given time
+func (f *frequency) decayed(now time.Time, halfLife time.Duration) float64 {
+	return f.score * math.Exp2(-float64(now.Sub(f.at))/float64(halfLife))
+}
+
+// sampled counts the occurrence of the post's error and tells if it must be dropped
+func (r *Reporter) sampled(post Post) bool {
+	rate, fixed := r.sampling.levels[post.Level()]
+	if !fixed {
+		rate, fixed = r.sampling.rate, r.sampling.fixed
+	}
+	if fixed && rand.Float64() >= rate {
+		atomic.AddInt64(&r.stats.sampled, 1)
+		return true
+	}
+	if r.sampling.errors == nil {
+		return false
+	}
+
+	fingerprint := r.fingerprint(post)
+	now := time.Now()
+
+	r.sampling.mu.Lock()
+	f, ok := r.sampling.errors[fingerprint]
+	if !ok {
+		if len(r.sampling.errors) >= maxSampledErrors {
+			r.forgetRareErrors(now)
+		}
+		f = &frequency{}
+		r.sampling.errors[fingerprint] = f
+	}
+	f.score = f.decayed(now, r.sampling.halfLife) + 1
+	f.at = now
+	f.rate = math.Min(1, r.sampling.threshold/f.score)
+	rate = f.rate
+	r.sampling.mu.Unlock()
+
+	if rate < 1 && rand.Float64() >= rate {
+		atomic.AddInt64(&r.stats.sampled, 1)
+		return true
+	}
+	return false
+}
+
+// forgetRareErrors removes the errors that would be reported anyway, to make room for new ones
+func (r *Reporter) forgetRareErrors(now time.Time) {
+	for fingerprint, f := range r.sampling.errors {
+		if f.decayed(now, r.sampling.halfLife) < r.sampling.threshold {
+			delete(r.sampling.errors, fingerprint)
+		}
+	}
+}
+
+// sampleRates returns the last sampling rate of each error
+func (r *Reporter) sampleRates() map[string]float64 {
+	r.sampling.mu.Lock()
+	defer r.sampling.mu.Unlock()
+
+	if r.sampling.errors == nil {
+		return nil
+	}
+	rates := make(map[string]float64, len(r.sampling.errors))
+	for fingerprint, f := range r.sampling.errors {
+		rates[fingerprint] = f.rate
+	}
+	return rates
+}
@@ -0,0 +1,79 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingSink counts the posts by message
+type countingSink struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (s *countingSink) Send(ctx context.Context, post Post, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[post.Details.Error.Message]++
+	return nil
+}
+
+func TestWithAdaptiveSampling(t *testing.T) {
+	sink := &countingSink{counts: map[string]int{}}
+	reporter := NewReporter("key", WithSink(sink), WithAdaptiveSampling(10, time.Hour))
+
+	for i := 0; i < 1000; i++ {
+		reporter.Report(errors.New("common"))
+		if i%500 == 0 {
+			reporter.Report(errors.New("rare"))
+		}
+	}
+
+	if sink.counts["rare"] != 2 {
+		t.Errorf("rare errors should never be dropped, got %d out of 2", sink.counts["rare"])
+	}
+	if common := sink.counts["common"]; common < 10 || common > 200 {
+		t.Errorf("the common error should be throttled, got %d out of 1000", common)
+	}
+
+	stats := reporter.Stats()
+	if stats.Sampled != int64(1000-sink.counts["common"]) {
+		t.Errorf("expected the dropped reports to be counted, got %d", stats.Sampled)
+	}
+	if len(stats.SampleRates) != 2 {
+		t.Fatalf("expected the rates of 2 errors, got %v", stats.SampleRates)
+	}
+	for fingerprint, rate := range stats.SampleRates {
+		if rate != 1 && (rate < 0.005 || rate > 0.02) {
+			t.Errorf("unexpected rate %f for %s", rate, fingerprint)
+		}
+	}
+}
+
+func TestWithLevelSampleRates(t *testing.T) {
+	sink := &countingSink{counts: map[string]int{}}
+	reporter := NewReporter("key", WithSink(sink), WithLevelSampleRates(map[Level]float64{LevelInfo: 0.1, LevelDebug: 0}))
+
+	for i := 0; i < 1000; i++ {
+		reporter.Report(errors.New("error"))
+		reporter.Report(errors.New("warning"), WithLevel(LevelWarning))
+		reporter.CaptureMessage("info")
+		reporter.CaptureMessage("debug", WithLevel(LevelDebug))
+	}
+
+	if sink.counts["error"] != 1000 || sink.counts["warning"] != 1000 {
+		t.Errorf("the levels without a rate should never be sampled, got %v", sink.counts)
+	}
+	if info := sink.counts["info"]; info < 50 || info > 150 {
+		t.Errorf("expected about 100 info reports out of 1000, got %d", info)
+	}
+	if sink.counts["debug"] != 0 {
+		t.Errorf("expected the debug reports to be dropped, got %d", sink.counts["debug"])
+	}
+	if sampled := reporter.Stats().Sampled; sampled != int64(2000-sink.counts["info"]) {
+		t.Errorf("expected the dropped reports to be counted, got %d", sampled)
+	}
+}
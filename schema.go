@@ -0,0 +1,132 @@
+package crashreport
+
+import (
+	"reflect"
+	"strings"
+)
+
+// raygunFields are the fields of a post documented at https://raygun.com/raygun-providers/rest-json-api
+// Slices are marked with [], the free-form fields such as userCustomData are not descended into.
+var raygunFields = map[string]bool{
+	"occurredOn":                                  true,
+	"details":                                     true,
+	"details.machineName":                         true,
+	"details.groupingKey":                         true,
+	"details.version":                             true,
+	"details.client":                              true,
+	"details.client.name":                         true,
+	"details.client.version":                      true,
+	"details.client.clientUrl":                    true,
+	"details.error":                               true,
+	"details.error.innerError":                    true,
+	"details.error.data":                          true,
+	"details.error.className":                     true,
+	"details.error.message":                       true,
+	"details.error.stackTrace":                    true,
+	"details.error.stackTrace[].lineNumber":       true,
+	"details.error.stackTrace[].className":        true,
+	"details.error.stackTrace[].columnNumber":     true,
+	"details.error.stackTrace[].fileName":         true,
+	"details.error.stackTrace[].methodName":       true,
+	"details.error.stackTrace[].raw":              true,
+	"details.error.stackTrace[].inApp":            true, // not documented, see WithAppModule
+	"details.error.stackTrace[].sourceUrl":        true, // not documented, see WithSourceURL
+	"details.breadcrumbs":                         true,
+	"details.breadcrumbs[].message":               true,
+	"details.breadcrumbs[].category":              true,
+	"details.breadcrumbs[].customData":            true,
+	"details.breadcrumbs[].timestamp":             true,
+	"details.breadcrumbs[].level":                 true,
+	"details.breadcrumbs[].type":                  true,
+	"details.breadcrumbs[].className":             true,
+	"details.breadcrumbs[].methodName":            true,
+	"details.breadcrumbs[].lineNumber":            true,
+	"details.environment":                         true,
+	"details.environment.processorCount":          true,
+	"details.environment.osVersion":               true,
+	"details.environment.windowBoundsWidth":       true,
+	"details.environment.windowBoundsHeight":      true,
+	"details.environment.resolutionScale":         true,
+	"details.environment.currentOrientation":      true,
+	"details.environment.cpu":                     true,
+	"details.environment.packageVersion":          true,
+	"details.environment.architecture":            true,
+	"details.environment.totalPhysicalMemory":     true,
+	"details.environment.availablePhysicalMemory": true,
+	"details.environment.totalVirtualMemory":      true,
+	"details.environment.availableVirtualMemory":  true,
+	"details.environment.diskSpaceFree":           true,
+	"details.environment.deviceName":              true,
+	"details.environment.locale":                  true,
+	"details.environment.utcOffset":               true,
+	"details.tags":                                true,
+	"details.userCustomData":                      true,
+	"details.request":                             true,
+	"details.request.hostName":                    true,
+	"details.request.url":                         true,
+	"details.request.httpMethod":                  true,
+	"details.request.ipAddress":                   true,
+	"details.request.queryString":                 true,
+	"details.request.form":                        true,
+	"details.request.headers":                     true,
+	"details.request.rawData":                     true,
+	"details.response":                            true,
+	"details.response.statusCode":                 true,
+	"details.user":                                true,
+	"details.user.identifier":                     true,
+	"details.user.isAnonymous":                    true,
+	"details.user.email":                          true,
+	"details.user.fullName":                       true,
+	"details.user.firstName":                      true,
+	"details.user.uuid":                           true,
+	"details.context":                             true,
+	"details.context.identifier":                  true,
+}
+
+// ValidateSchema compares the json fields of the post with the fields documented by Raygun, and returns the ones
+// that Raygun doesn't know about. It's meant for tests, to catch json tags drifting from the api.
+func ValidateSchema(post Post) []string {
+	return validateSchema(reflect.TypeOf(post), "", raygunFields)
+}
+
+// validateSchema walks the json fields of a struct type and returns the paths missing from known
+func validateSchema(t reflect.Type, prefix string, known map[string]bool) []string {
+	var unknown []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		if !known[path] {
+			unknown = append(unknown, path)
+			continue
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Slice {
+			path += "[]"
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			unknown = append(unknown, validateSchema(ft, path, known)...)
+		}
+	}
+
+	return unknown
+}
@@ -0,0 +1,60 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	post := NewPost()
+	post.Details.Error = Error{
+		Message:    "failure",
+		StackTrace: StackTrace{{LineNumber: 1, PackageName: "main", FileName: "main.go", MethodName: "main"}},
+	}
+	post.Details.Breadcrumbs = []Breadcrumb{{Message: "step"}}
+
+	if unknown := ValidateSchema(post); len(unknown) != 0 {
+		t.Errorf("the post should match the documented fields, got unknown %v", unknown)
+	}
+}
+
+func TestValidateSchemaUnknownFields(t *testing.T) {
+	type frame struct {
+		Line    int    `json:"lineNumber"`
+		Package string `json:"packageName"`
+	}
+	type drifted struct {
+		Frames []frame `json:"stackTrace"`
+		Skip   string  `json:"-"`
+		Raw    string
+	}
+
+	known := map[string]bool{"stackTrace": true, "stackTrace[].lineNumber": true}
+	unknown := validateSchema(reflect.TypeOf(drifted{}), "", known)
+
+	expected := []string{"stackTrace[].packageName", "Raw"}
+	if !reflect.DeepEqual(unknown, expected) {
+		t.Errorf("expected unknown fields %v, got %v", expected, unknown)
+	}
+}
+
+func TestStackTraceElementJSON(t *testing.T) {
+	frame := StackTraceElement{
+		LineNumber:  3086,
+		PackageName: "net/http",
+		FileName:    "/usr/local/go/src/net/http/server.go",
+		MethodName:  "(*conn).serve",
+		InApp:       true,
+	}
+	golden := `{"lineNumber":3086,"className":"net/http","fileName":"/usr/local/go/src/net/http/server.go",` +
+		`"methodName":"(*conn).serve","inApp":true}`
+
+	data, err := json.Marshal(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != golden {
+		t.Errorf("the frame should serialize to\n%s\ngot\n%s", golden, data)
+	}
+}
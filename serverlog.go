@@ -0,0 +1,78 @@
+package crashreport
+
+import (
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// NewServerErrorLog returns a logger for http.Server.ErrorLog, reporting the errors logged by the server outside of
+// the handlers, which the middleware can't see: the panics of the handlers not wrapped by the middleware, the TLS
+// handshake errors, the accept errors and the misuses of the ResponseWriter. The entries are parsed into clean
+// messages, without the address of the client, so that Raygun groups them: the address goes to the "remoteAddr" key
+// of the custom data. A panic is reported with its stacktrace.
+//
+// The entries are still written to os.Stderr, see WithStdLoggerOutput. The failures of the connections, which the
+// server doesn't log, can be recorded with ConnState as operations, so that they show in the next reports:
+//
+//	server := &http.Server{
+//		Handler:  reporter.Middleware(mux),
+//		ErrorLog: crashreport.NewServerErrorLog(reporter),
+//		ConnState: func(conn net.Conn, state http.ConnState) {
+//			if state == http.StateHijacked || state == http.StateClosed {
+//				reporter.TrackOperation("conn "+state.String(), map[string]interface{}{"remoteAddr": conn.RemoteAddr().String()})
+//			}
+//		},
+//	}
+func NewServerErrorLog(reporter *Reporter, opts ...StdLoggerOption) *log.Logger {
+	w := &stdLogWriter{reporter: reporter, out: os.Stderr, server: true}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return log.New(w, "", log.LstdFlags)
+}
+
+// serverLogEntries are the formats of the entries logged by net/http, with the level and the clean message of their
+// reports. The first group of the patterns is the address of the client, if any, and the second one is the error.
+var serverLogEntries = []struct {
+	pattern *regexp.Regexp
+	level   Level
+	message string
+}{
+	{regexp.MustCompile(`^http: panic serving (\S+): (.*)`), LevelError, "panic: "},
+	{regexp.MustCompile(`^http: TLS handshake error from (\S+): (.*)`), LevelWarning, "TLS handshake error: "},
+	{regexp.MustCompile(`^http: Accept error: ()(.*); retrying in .*`), LevelError, "accept error: "},
+	{regexp.MustCompile(`^http: ()((?:superfluous|response\.WriteHeader on hijacked).*)`), LevelWarning, ""},
+}
+
+// reportServerEntry reports an entry of the error log of an http.Server, see NewServerErrorLog
+func (s *stdLogWriter) reportServerEntry(entry string) {
+	first, rest := entry, ""
+	if i := strings.IndexByte(entry, '\n'); i >= 0 {
+		first, rest = entry[:i], entry[i+1:]
+	}
+
+	for _, e := range serverLogEntries {
+		m := e.pattern.FindStringSubmatch(first)
+		if m == nil {
+			continue
+		}
+		opts := []ReportOption{WithLevel(e.level), WithTags("http-server")}
+		if m[1] != "" {
+			opts = append(opts, WithCustomData("remoteAddr", m[1]))
+		}
+		if !strings.HasPrefix(rest, "goroutine ") {
+			s.reporter.CaptureMessage(e.message+m[2], opts...)
+			return
+		}
+
+		post := s.reporter.newPost()
+		post.Details.Error = Error{Message: e.message + m[2], StackTrace: panicStack(ParseStack([]byte(rest)))}
+		post.level = e.level
+		s.reporter.send(post, opts...)
+		return
+	}
+
+	s.reporter.CaptureMessage(strings.TrimPrefix(entry, "http: "), WithLevel(LevelError), WithTags("http-server"))
+}
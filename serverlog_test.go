@@ -0,0 +1,61 @@
+package crashreport
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewServerErrorLog(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	var out bytes.Buffer
+	logger := NewServerErrorLog(NewReporter("key"), WithStdLoggerOutput(&out))
+	logger.Printf("http: panic serving 10.0.0.7:51234: assignment to entry in nil map\n" +
+		"goroutine 42 [running]:\n" +
+		"net/http.(*conn).serve.func1()\n\t/usr/local/go/src/net/http/server.go:1854 +0xbf\n" +
+		"panic({0x6c5a60?, 0x7a4e10?})\n\t/usr/local/go/src/runtime/panic.go:890 +0x263\n" +
+		"github.com/acme/shop/cart.Add(...)\n\t/src/cart/cart.go:12\n" +
+		"net/http.HandlerFunc.ServeHTTP(0x0?, {0x7a8b40?, 0xc0001a0000?}, 0x0?)\n\t/usr/local/go/src/net/http/server.go:2122 +0x2f\n")
+	logger.Printf("http: TLS handshake error from 192.168.1.20:40212: EOF")
+	logger.Printf("http: Accept error: accept tcp [::]:8080: accept4: too many open files; retrying in 5ms")
+	logger.Printf("http: superfluous response.WriteHeader call from main.handler (main.go:12)")
+	logger.Printf("http2: server: error reading preface from client 10.0.0.8:3000: bogus greeting")
+
+	sent := server.Posts()
+	if len(sent) != 5 {
+		t.Fatalf("expected 5 posts, got %d", len(sent))
+	}
+	expected := []struct {
+		message    string
+		remoteAddr interface{}
+		severity   string
+	}{
+		{"panic: assignment to entry in nil map", "10.0.0.7:51234", "severity:error"},
+		{"TLS handshake error: EOF", "192.168.1.20:40212", "severity:warning"},
+		{"accept error: accept tcp [::]:8080: accept4: too many open files", nil, "severity:error"},
+		{"superfluous response.WriteHeader call from main.handler (main.go:12)", nil, "severity:warning"},
+		{"http2: server: error reading preface from client 10.0.0.8:3000: bogus greeting", nil, "severity:error"},
+	}
+	for i, e := range expected {
+		post := sent[i]
+		if post.Details.Error.Message != e.message {
+			t.Errorf("entry %d: expected the message %q, got %q", i, e.message, post.Details.Error.Message)
+		}
+		data, _ := post.Details.UserCustomData.(map[string]interface{})
+		if data["remoteAddr"] != e.remoteAddr {
+			t.Errorf("entry %d: expected the remote address %v, got %v", i, e.remoteAddr, data["remoteAddr"])
+		}
+		if !hasTag(post.Details.Tags, e.severity) || !hasTag(post.Details.Tags, "http-server") {
+			t.Errorf("entry %d: expected the tags %s and http-server, got %v", i, e.severity, post.Details.Tags)
+		}
+	}
+
+	stack := sent[0].Details.Error.StackTrace
+	if len(stack) != 2 || stack[0].PackageName != "github.com/acme/shop/cart" || stack[0].MethodName != "Add" {
+		t.Errorf("expected the stacktrace from the function that panicked, got %+v", stack)
+	}
+	if bytes.Count(out.Bytes(), []byte("http")) < 5 {
+		t.Errorf("the entries should still be written to the output, got %q", out.String())
+	}
+}
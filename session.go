@@ -0,0 +1,67 @@
+package crashreport
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// sessionTag prefixes the id of the session in the tags of the reports
+const sessionTag = "session:"
+
+// StartSession starts a session of the reporter and returns its id: every report carries it until EndSession, as a
+// "session:<id>" tag and as the identifier of its context, so that the errors of a session are grouped in Raygun.
+// WithIdentifier still overrides the identifier. The reporters created by With share the session.
+//
+// A reporter has a single session at a time: use ContextWithSession for the sessions of concurrent requests.
+func (r *Reporter) StartSession() string {
+	id := newSessionID()
+	r.session.Store(id)
+	return id
+}
+
+// EndSession ends the session of StartSession
+func (r *Reporter) EndSession() {
+	r.session.Store("")
+}
+
+// ContextWithSession returns a context carrying a new session, and its id, for ReportCtx. The reports of the context
+// carry the session as with StartSession, instead of the session of the reporter.
+func ContextWithSession(ctx context.Context) (context.Context, string) {
+	id := newSessionID()
+	return context.WithValue(ctx, sessionKey, id), id
+}
+
+// SessionFromContext returns the id of the session stored by ContextWithSession, or ""
+func SessionFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionKey).(string)
+	return id
+}
+
+// newSessionID returns a random id of 32 hex characters
+func newSessionID() string {
+	b := make([]byte, 16)
+	crand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// setSession marks the post as a report of the session
+func setSession(post *Post, id string) {
+	post.Details.Tags = append(post.Details.Tags, sessionTag+id)
+	post.Details.Context.Identifier = id
+}
+
+// applySession marks the post with the session of the reporter, unless it has the session of a context
+func (r *Reporter) applySession(post *Post) {
+	id, _ := r.session.Load().(string)
+	if id == "" {
+		return
+	}
+	for _, tag := range post.Details.Tags {
+		if strings.HasPrefix(tag, sessionTag) {
+			return
+		}
+	}
+	setSession(post, id)
+}
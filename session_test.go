@@ -0,0 +1,46 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	reporter := NewReporter("key")
+
+	reporter.Report(errors.New("before"))
+	id := reporter.StartSession()
+	reporter.Report(errors.New("first"))
+	reporter.With(WithTags("worker")).Report(errors.New("second"))
+	ctx, requestID := ContextWithSession(context.Background())
+	reporter.ReportCtx(ctx, errors.New("request"))
+	reporter.EndSession()
+	reporter.Report(errors.New("after"))
+
+	sent := server.Posts()
+	if len(sent) != 5 {
+		t.Fatalf("expected 5 posts, got %d", len(sent))
+	}
+	if id == "" || id == requestID {
+		t.Fatalf("expected distinct session ids, got %q and %q", id, requestID)
+	}
+	for i, expected := range []string{"", id, id, requestID, ""} {
+		details := sent[i].Details
+		var sessions []string
+		for _, tag := range details.Tags {
+			if len(tag) > len(sessionTag) && tag[:len(sessionTag)] == sessionTag {
+				sessions = append(sessions, tag[len(sessionTag):])
+			}
+		}
+		switch {
+		case expected == "" && (len(sessions) != 0 || len(details.Context.Identifier) == 32):
+			t.Errorf("%s: expected no session, got %v %q", details.Error.Message, sessions, details.Context.Identifier)
+		case expected != "" && (len(sessions) != 1 || sessions[0] != expected || details.Context.Identifier != expected):
+			t.Errorf("%s: expected session %s, got %v %q", details.Error.Message, expected, sessions,
+				details.Context.Identifier)
+		}
+	}
+}
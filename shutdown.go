@@ -0,0 +1,37 @@
+package crashreport
+
+import (
+	"time"
+)
+
+// shutdownTimeout bounds the submission of the report and the flush of the queue by ReportShutdown
+const shutdownTimeout = 2 * time.Second
+
+// ReportShutdown reports a clean shutdown of the process, to tell it apart from the crashes in the dashboard: a
+// message "shutdown: <reason>" with the info level, the "shutdown" tag and the reason under the "reason" key of the
+// custom data. The report is submitted synchronously, even by an asynchronous reporter, then the queued reports are
+// flushed, both within 2 seconds. As for Fatal, the report isn't held or rate limited, but it goes through the options
+// and the filters like the others: it can be dropped, for example by WithMinLevel or WithEnvironmentFilter. The
+// reporter keeps working afterwards. For example on SIGTERM:
+//
+//	signals := make(chan os.Signal, 1)
+//	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+//	sig := <-signals
+//	reporter.ReportShutdown("received " + sig.String())
+//
+// It returns the error of the submission.
+func (r *Reporter) ReportShutdown(reason string, opts ...ReportOption) error {
+	deadline := time.Now().Add(shutdownTimeout)
+
+	post := r.newPost()
+	post.Details.Error = Error{ClassName: "Shutdown", Message: "shutdown: " + reason}
+	post.level = LevelInfo
+	post.deadline = deadline
+	post.synchronous = true
+	post.Details.Tags = append(post.Details.Tags, "shutdown")
+	post.SetCustomData("reason", reason)
+	identify(&post, caller(0))
+	err := r.send(post, opts...)
+	r.Flush(time.Until(deadline))
+	return err
+}
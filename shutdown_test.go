@@ -0,0 +1,65 @@
+package crashreport
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReportShutdown(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithAsync(1, 10))
+	defer reporter.Close(0)
+	reporter.Report(errors.New("queued"))
+	if err := reporter.ReportShutdown("received terminated"); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected the shutdown and the queued report to be sent, got %d posts", len(sent))
+	}
+	var shutdown Post
+	for _, post := range sent {
+		if post.Details.Error.ClassName == "Shutdown" {
+			shutdown = post
+		}
+	}
+	if shutdown.Details.Error.Message != "shutdown: received terminated" {
+		t.Fatalf("expected the shutdown report, got %+v", sent)
+	}
+	if tags := shutdown.Details.Tags; !hasTag(tags, "shutdown") || !hasTag(tags, "severity:info") {
+		t.Errorf("expected the shutdown tag and the info level, got %v", tags)
+	}
+	if reason := shutdown.Details.UserCustomData.(map[string]interface{})["reason"]; reason != "received terminated" {
+		t.Errorf("expected the reason in the custom data, got %v", reason)
+	}
+}
+
+func TestReportShutdownPipeline(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithAsync(1, 10))
+	defer reporter.Close(0)
+	session := reporter.StartSession()
+	panicking := func(*Post) { panic("bug in an option") }
+	if err := reporter.With(WithTags("worker")).ReportShutdown("done", panicking); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected the shutdown report despite the panicking option, got %d posts", len(sent))
+	}
+	if tags := sent[0].Details.Tags; !hasTag(tags, "worker") || !hasTag(tags, "session:"+session) {
+		t.Errorf("expected the tags of the defaults and of the session, got %v", tags)
+	}
+
+	quiet := NewReporter("key", WithMinLevel(LevelError))
+	if err := quiet.ReportShutdown("done"); err != nil || len(server.Posts()) != 1 {
+		t.Errorf("expected the shutdown report to be dropped below the min level, got %v and %d posts", err,
+			len(server.Posts()))
+	}
+}
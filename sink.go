@@ -0,0 +1,48 @@
+package crashreport
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Sink receives the posts of a reporter, instead of Raygun. The context is cancelled when the reporter is closed, or
+// at the deadline of the report. Sinks must be safe for concurrent use.
+type Sink interface {
+	Send(ctx context.Context, post Post, key string) error
+}
+
+// WithSink makes the reporter send its posts to the sink instead of Raygun. The cool-down and the disk queue still
+// apply, if the sink returns a ResponseError.
+func WithSink(sink Sink) Option {
+	return func(r *Reporter) {
+		r.sink = sink
+	}
+}
+
+// WriterSink writes each post as a line of json, to inspect what would be sent to Raygun
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink creates a sink writing newline-delimited json to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Send writes the post as a single line. The key is not written.
+func (s *WriterSink) Send(ctx context.Context, post Post, key string) error {
+	line, err := marshalPost(post)
+	if err != nil {
+		return errors.Wrap(err, "marshal post")
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(line)
+	return errors.Wrap(err, "write post")
+}
@@ -0,0 +1,56 @@
+package crashreport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestWriterSink(t *testing.T) {
+	var out bytes.Buffer
+	reporter := NewReporter("key", WithSink(NewWriterSink(&out)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := reporter.Report(fmt.Errorf("error %d", i), WithTags("ndjson")); err != nil {
+				t.Errorf("report: %s", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(&out)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var post Post
+		if err := json.Unmarshal(scanner.Bytes(), &post); err != nil {
+			t.Fatalf("each line should be a post: %s in %q", err, scanner.Text())
+		}
+		if len(post.Details.Tags) != 1 || post.Details.Tags[0] != "ndjson" {
+			t.Errorf("unexpected tags %v", post.Details.Tags)
+		}
+		seen[post.Details.Error.Message] = true
+	}
+	if len(seen) != 20 {
+		t.Errorf("expected 20 distinct posts, got %d", len(seen))
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestWriterSinkError(t *testing.T) {
+	err := NewReporter("key", WithSink(NewWriterSink(failingWriter{}))).Report(errors.New("lost"))
+	if err == nil || err.Error() != "write post: disk full" {
+		t.Errorf("expected the write error, got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// SlogHandler is a slog.Handler that passes the records to another handler, and reports the ones at error level or
+// above. The error is taken from the first attribute holding an error, the ones of WithAttrs first and then the ones
+// of the record in their order. The other attributes are sent as custom data with dotted keys for the groups.
+type SlogHandler struct {
+	next     slog.Handler
+	reporter *Reporter
+	attrs    map[string]interface{} // from WithAttrs, already flattened
+	group    string                 // prefix of the attributes, from WithGroup
+	cause    error                  // the first error of the attributes of WithAttrs
+}
+
+// NewSlogHandler returns a handler passing the records to next, and reporting the errors to reporter
+func NewSlogHandler(next slog.Handler, reporter *Reporter) *SlogHandler {
+	return &SlogHandler{next: next, reporter: reporter, attrs: map[string]interface{}{}}
+}
+
+// Enabled implements slog.Handler. Errors are always enabled, to be reported.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelError || h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelError {
+		h.report(record)
+	}
+	if !h.next.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := h.clone()
+	clone.next = h.next.WithAttrs(attrs)
+	flattenAttrs(clone.attrs, h.group, attrs)
+	if clone.cause == nil {
+		clone.cause = firstError(attrs)
+	}
+	return clone
+}
+
+// WithGroup implements slog.Handler
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	clone := h.clone()
+	clone.next = h.next.WithGroup(name)
+	if name != "" {
+		clone.group = joinKey(h.group, name)
+	}
+	return clone
+}
+
+func (h *SlogHandler) clone() *SlogHandler {
+	attrs := make(map[string]interface{}, len(h.attrs))
+	for k, v := range h.attrs {
+		attrs[k] = v
+	}
+	return &SlogHandler{next: h.next, reporter: h.reporter, attrs: attrs, group: h.group, cause: h.cause}
+}
+
+// report sends the record to the reporter
+func (h *SlogHandler) report(record slog.Record) {
+	data := recordAttrs(h.attrs, h.group, record)
+
+	cause := h.cause
+	if cause == nil {
+		record.Attrs(func(attr slog.Attr) bool {
+			cause = firstError([]slog.Attr{attr})
+			return cause == nil
+		})
+	}
+
+	post := h.reporter.newPost()
+	if cause != nil {
+		post.Details.Error = FromErr(cause)
+		post.err = cause
+		post.Details.Error.Message = record.Message + ": " + cause.Error()
+	} else {
+		post.Details.Error = FromErr(errors.New(record.Message))
+	}
+	for k, v := range data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		post.SetCustomData(k, v)
+	}
+	post.level = LevelError
+
+	h.reporter.send(post)
+}
+
+// recordAttrs returns the attributes of the record, in the group, added to a copy of attrs
+func recordAttrs(attrs map[string]interface{}, group string, record slog.Record) map[string]interface{} {
+	data := make(map[string]interface{}, len(attrs)+record.NumAttrs())
+	for k, v := range attrs {
+		data[k] = v
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		flattenAttrs(data, group, []slog.Attr{attr})
+		return true
+	})
+	return data
+}
+
+// flattenAttrs adds the attributes to data, with the keys of the groups joined by dots
+func flattenAttrs(data map[string]interface{}, prefix string, attrs []slog.Attr) {
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		if value.Kind() == slog.KindGroup {
+			flattenAttrs(data, joinKey(prefix, attr.Key), value.Group())
+			continue
+		}
+		if attr.Key == "" {
+			continue
+		}
+		data[joinKey(prefix, attr.Key)] = value.Any()
+	}
+}
+
+// firstError returns the first error of the attributes, in their order and depth first in the groups
+func firstError(attrs []slog.Attr) error {
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		if value.Kind() == slog.KindGroup {
+			if err := firstError(value.Group()); err != nil {
+				return err
+			}
+			continue
+		}
+		if err, ok := value.Any().(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	if key == "" {
+		return prefix
+	}
+	return prefix + "." + key
+}
+
+var installed struct {
+	sync.Mutex
+	previous *slog.Logger
+
+	// the output of the log package, redirected by slog.SetDefault when the previous handler is the built-in one
+	logOutput io.Writer
+	logFlags  int
+}
+
+// InstallSlog wraps the handler of the default slog logger in a SlogHandler, so that every slog.Error is reported.
+// Calling it again only changes the reporter. UninstallSlog restores the previous default logger.
+//
+// The built-in handler of slog, used until slog.SetDefault is called, writes through the log package, which
+// slog.SetDefault redirects to the new default logger: wrapping it would loop. It's replaced by a slog.TextHandler
+// writing to os.Stderr, and UninstallSlog restores the output of the log package.
+func InstallSlog(reporter *Reporter) {
+	installed.Lock()
+	defer installed.Unlock()
+
+	current := slog.Default()
+	if h, ok := current.Handler().(*SlogHandler); ok {
+		clone := h.clone()
+		clone.reporter = reporter
+		slog.SetDefault(slog.New(clone))
+		return
+	}
+
+	installed.previous = current
+	next := current.Handler()
+	if isBuiltinSlogHandler(next) {
+		next = slog.NewTextHandler(os.Stderr, nil)
+		installed.logOutput, installed.logFlags = log.Writer(), log.Flags()
+	}
+	slog.SetDefault(slog.New(NewSlogHandler(next, reporter)))
+}
+
+// isBuiltinSlogHandler tells if the handler is the one of the default slog logger before slog.SetDefault is called
+func isBuiltinSlogHandler(h slog.Handler) bool {
+	return fmt.Sprintf("%T", h) == "*slog.defaultHandler"
+}
+
+// UninstallSlog restores the default slog logger replaced by InstallSlog
+func UninstallSlog() {
+	installed.Lock()
+	defer installed.Unlock()
+
+	if installed.previous == nil {
+		return
+	}
+	slog.SetDefault(installed.previous)
+	installed.previous = nil
+	if installed.logOutput != nil {
+		log.SetOutput(installed.logOutput)
+		log.SetFlags(installed.logFlags)
+		installed.logOutput = nil
+	}
+}
@@ -0,0 +1,124 @@
+package crashreport
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstallSlog(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	var out bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&out, nil)))
+	defer slog.SetDefault(previous)
+	original := slog.Default()
+
+	reporter := NewReporter("key")
+	InstallSlog(reporter)
+	InstallSlog(reporter)
+
+	if _, ok := slog.Default().Handler().(*SlogHandler); !ok {
+		t.Fatal("the default handler should be wrapped")
+	}
+	if _, ok := slog.Default().Handler().(*SlogHandler).next.(*SlogHandler); ok {
+		t.Fatal("installing twice should not wrap twice")
+	}
+
+	slog.Info("starting")
+	slog.With("request", "abc").WithGroup("payment").Error("payment failed", "err", errors.New("card declined"), "amount", 42)
+
+	if !strings.Contains(out.String(), "payment failed") || !strings.Contains(out.String(), "starting") {
+		t.Errorf("records should be passed to the original handler, got %s", out.String())
+	}
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	details := sent[0].Details
+	if details.Error.Message != "payment failed: card declined" {
+		t.Errorf("unexpected message '%s'", details.Error.Message)
+	}
+	data := details.UserCustomData.(map[string]interface{})
+	if data["request"] != "abc" || data["payment.amount"] != float64(42) || data["payment.err"] != "card declined" {
+		t.Errorf("attributes should be in the custom data, got %v", data)
+	}
+
+	UninstallSlog()
+	if slog.Default() != original {
+		t.Error("uninstall should restore the previous logger")
+	}
+	slog.Error("not reported")
+	if len(server.Posts()) != 1 {
+		t.Error("errors should not be reported after uninstall")
+	}
+}
+
+func TestInstallSlogBuiltinHandler(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	previous := slog.Default()
+	if !isBuiltinSlogHandler(previous.Handler()) {
+		t.Skipf("the default handler is already replaced: %T", previous.Handler())
+	}
+	output := log.Writer()
+
+	InstallSlog(NewReporter("key"))
+	if h, ok := slog.Default().Handler().(*SlogHandler); !ok || isBuiltinSlogHandler(h.next) {
+		t.Fatalf("expected the built-in handler to be replaced, got %T", slog.Default().Handler())
+	}
+
+	done := make(chan struct{})
+	go func() {
+		slog.Error("payment failed", "err", errors.New("card declined"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging with the built-in handler wrapped should not deadlock")
+	}
+	if sent := server.Posts(); len(sent) != 1 {
+		t.Errorf("expected 1 post, got %d", len(sent))
+	}
+
+	UninstallSlog()
+	if slog.Default() != previous || log.Writer() != output {
+		t.Error("uninstall should restore the previous logger and the output of the log package")
+	}
+}
+
+func TestSlogHandlerFirstError(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	var out bytes.Buffer
+	handler := NewSlogHandler(slog.NewTextHandler(&out, nil), NewReporter("key"))
+	logger := slog.New(handler)
+	for i := 0; i < 10; i++ {
+		logger.Error("sync failed", "a", errors.New("first"), slog.Group("g", "b", errors.New("second")),
+			"c", errors.New("third"))
+	}
+	logger.With("conn", errors.New("connection reset")).Error("sync failed", "err", errors.New("timeout"))
+
+	sent := server.Posts()
+	if len(sent) != 11 {
+		t.Fatalf("expected 11 posts, got %d", len(sent))
+	}
+	for _, post := range sent[:10] {
+		if post.Details.Error.Message != "sync failed: first" {
+			t.Fatalf("expected the first error of the record to be the cause, got '%s'", post.Details.Error.Message)
+		}
+	}
+	if message := sent[10].Details.Error.Message; message != "sync failed: connection reset" {
+		t.Errorf("expected the error of WithAttrs to come first, got '%s'", message)
+	}
+}
@@ -0,0 +1,96 @@
+package crashreport
+
+import (
+	"context"
+	"log/slog"
+)
+
+// BreadcrumbFromSlog converts a log record to a breadcrumb of category "log", keeping its time. The attributes are
+// the custom data of the breadcrumb, with dotted keys for the groups.
+func BreadcrumbFromSlog(record slog.Record) Breadcrumb {
+	return slogBreadcrumb(record, recordAttrs(nil, "", record))
+}
+
+// slogBreadcrumb converts a log record with the given attributes to a breadcrumb
+func slogBreadcrumb(record slog.Record, attrs map[string]interface{}) Breadcrumb {
+	crumb := Breadcrumb{
+		Message:  record.Message,
+		Category: "log",
+		Level:    slogBreadcrumbLevel(record.Level),
+	}
+	if !record.Time.IsZero() {
+		crumb.Timestamp = millis(record.Time)
+	}
+	if len(attrs) > 0 {
+		for k, v := range attrs {
+			if err, ok := v.(error); ok {
+				attrs[k] = err.Error()
+			}
+		}
+		crumb.CustomData = attrs
+	}
+	return crumb
+}
+
+// slogBreadcrumbLevel converts a slog level to a breadcrumb level
+func slogBreadcrumbLevel(level slog.Level) int {
+	switch {
+	case level < slog.LevelInfo:
+		return BreadcrumbDebug
+	case level < slog.LevelWarn:
+		return BreadcrumbInfo
+	case level < slog.LevelError:
+		return BreadcrumbWarning
+	default:
+		return BreadcrumbError
+	}
+}
+
+// NewSlogCapture is the slog counterpart of NewLogCapture: it returns a handler passing the records to next and
+// keeping the last n as breadcrumbs, and a function returning them, oldest first:
+//
+//	handler, breadcrumbs := crashreport.NewSlogCapture(slog.Default().Handler(), 20)
+//	reporter := crashreport.NewReporter(key, crashreport.WithBreadcrumbSource(breadcrumbs))
+//	slog.SetDefault(slog.New(crashreport.NewSlogHandler(handler, reporter)))
+func NewSlogCapture(next slog.Handler, n int) (slog.Handler, func() []Breadcrumb) {
+	ring := newBreadcrumbRing(n)
+	return &slogCapture{next: next, ring: ring}, ring.breadcrumbs
+}
+
+// slogCapture is a slog.Handler keeping the records in a ring buffer
+type slogCapture struct {
+	next  slog.Handler
+	ring  *breadcrumbRing
+	attrs map[string]interface{} // from WithAttrs, already flattened
+	group string                 // prefix of the attributes, from WithGroup
+}
+
+// Enabled implements slog.Handler
+func (c *slogCapture) Enabled(ctx context.Context, level slog.Level) bool {
+	return c.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler
+func (c *slogCapture) Handle(ctx context.Context, record slog.Record) error {
+	c.ring.add(slogBreadcrumb(record, recordAttrs(c.attrs, c.group, record)))
+	return c.next.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (c *slogCapture) WithAttrs(attrs []slog.Attr) slog.Handler {
+	flattened := make(map[string]interface{}, len(c.attrs)+len(attrs))
+	for k, v := range c.attrs {
+		flattened[k] = v
+	}
+	flattenAttrs(flattened, c.group, attrs)
+	return &slogCapture{next: c.next.WithAttrs(attrs), ring: c.ring, attrs: flattened, group: c.group}
+}
+
+// WithGroup implements slog.Handler
+func (c *slogCapture) WithGroup(name string) slog.Handler {
+	clone := &slogCapture{next: c.next.WithGroup(name), ring: c.ring, attrs: c.attrs, group: c.group}
+	if name != "" {
+		clone.group = joinKey(c.group, name)
+	}
+	return clone
+}
@@ -0,0 +1,66 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBreadcrumbFromSlog(t *testing.T) {
+	at := time.Date(2023, 8, 1, 12, 0, 0, 250e6, time.UTC)
+	record := slog.NewRecord(at, slog.LevelWarn, "slow query", 0)
+	record.AddAttrs(
+		slog.String("table", "orders"),
+		slog.Group("db", slog.Int("ms", 1200), slog.Group("pool", slog.Int("idle", 0))),
+		slog.Any("err", errors.New("timeout")),
+	)
+
+	crumb := BreadcrumbFromSlog(record)
+	if crumb.Message != "slow query" || crumb.Category != "log" || crumb.Level != BreadcrumbWarning {
+		t.Errorf("unexpected breadcrumb %+v", crumb)
+	}
+	if crumb.Timestamp != at.UnixNano()/1e6 {
+		t.Errorf("the time of the record should be kept, got %d", crumb.Timestamp)
+	}
+	expected := map[string]interface{}{
+		"table":        "orders",
+		"db.ms":        int64(1200),
+		"db.pool.idle": int64(0),
+		"err":          "timeout",
+	}
+	if !reflect.DeepEqual(crumb.CustomData, expected) {
+		t.Errorf("expected the flattened attributes %v, got %v", expected, crumb.CustomData)
+	}
+
+	if level := BreadcrumbFromSlog(slog.NewRecord(at, slog.LevelDebug-4, "trace", 0)).Level; level != BreadcrumbDebug {
+		t.Errorf("levels below debug should be debug, got %d", level)
+	}
+}
+
+func TestNewSlogCapture(t *testing.T) {
+	handler, breadcrumbs := NewSlogCapture(slog.NewTextHandler(ioutil.Discard, nil), 2)
+	logger := slog.New(handler)
+
+	logger.Info("first")
+	logger.With("user", "ann").WithGroup("cart").Info("second", "items", 3)
+	logger.Error("third")
+	logger.Debug("disabled")
+
+	crumbs := breadcrumbs()
+	if len(crumbs) != 2 || crumbs[0].Message != "second" || crumbs[1].Message != "third" {
+		t.Fatalf("expected the last 2 records, got %+v", crumbs)
+	}
+	if data := crumbs[0].CustomData.(map[string]interface{}); data["user"] != "ann" || data["cart.items"] != int64(3) {
+		t.Errorf("expected the attributes of the logger, got %v", data)
+	}
+	if crumbs[1].Level != BreadcrumbError {
+		t.Errorf("expected an error breadcrumb, got %d", crumbs[1].Level)
+	}
+	if !handler.Enabled(context.Background(), slog.LevelInfo) || handler.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("the capture should follow the levels of the next handler")
+	}
+}
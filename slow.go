@@ -0,0 +1,44 @@
+package crashreport
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultSlowThreshold is the duration over which Timed reports an operation, see WithSlowThreshold
+const defaultSlowThreshold = time.Second
+
+// WithSlowThreshold sets the duration over which the operations measured by Timed are reported, 1s by default
+func WithSlowThreshold(threshold time.Duration) Option {
+	return func(r *Reporter) {
+		r.slowThreshold = threshold
+	}
+}
+
+// Timed starts measuring an operation, and returns the function stopping the measure. If the operation took longer
+// than the threshold of WithSlowThreshold, a warning "slow operation: <name>" is reported with CaptureMessage, tagged
+// "slow", with the duration and the threshold in milliseconds under the "durationMs" and "thresholdMs" keys of the
+// custom data, and the options. Its identifier is the function that called Timed:
+//
+//	defer reporter.Timed("checkout")()
+//
+// The timers are independent, so they can be nested. Only the first call of the stop function counts.
+func (r *Reporter) Timed(name string, opts ...ReportOption) func() {
+	start, site := timeNow(), caller(0)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			threshold := r.slowThreshold
+			if threshold == 0 {
+				threshold = defaultSlowThreshold
+			}
+			elapsed := timeNow().Sub(start)
+			if elapsed <= threshold {
+				return
+			}
+			r.CaptureMessage("slow operation: "+name, append([]ReportOption{WithLevel(LevelWarning),
+				WithIdentifier(site), WithTags("slow"), WithCustomData("durationMs", elapsed.Milliseconds()),
+				WithCustomData("thresholdMs", threshold.Milliseconds())}, opts...)...)
+		})
+	}
+}
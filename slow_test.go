@@ -0,0 +1,40 @@
+package crashreport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimed(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	reporter := NewReporter("key", WithSlowThreshold(100*time.Millisecond))
+	stopCheckout := reporter.Timed("checkout")
+	stopPayment := reporter.Timed("payment", WithTags("payments"))
+	now = now.Add(50 * time.Millisecond)
+	stopPayment()
+	now = now.Add(200 * time.Millisecond)
+	stopCheckout()
+	stopCheckout()
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected only the slow operation to be reported, got %d posts", len(sent))
+	}
+	details := sent[0].Details
+	if details.Error.Message != "slow operation: checkout" || !hasTag(details.Tags, "slow") ||
+		!hasTag(details.Tags, "severity:warning") {
+		t.Errorf("expected the slow operation warning, got %q %v", details.Error.Message, details.Tags)
+	}
+	data := details.UserCustomData.(map[string]interface{})
+	if data["durationMs"] != float64(250) || data["thresholdMs"] != float64(100) {
+		t.Errorf("expected the duration and the threshold, got %v", data)
+	}
+	if details.Context.Identifier != "github.com/chennqqi/crashreport.TestTimed" {
+		t.Errorf("expected the function timing the operation, got %s", details.Context.Identifier)
+	}
+}
@@ -0,0 +1,109 @@
+package crashreport
+
+import (
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// WithVersion sets the version of the application, Details.Version, on every report. Raygun uses it to filter the
+// errors by release, and to link the frames to the source of the right version.
+func WithVersion(version string) Option {
+	return func(r *Reporter) {
+		r.version = version
+	}
+}
+
+// WithSourceLinking sets up the reports for the source view of Raygun, which links the frames to the files of the
+// repository at the given version: it sets the module of the application as WithAppModule, the version as
+// WithVersion, and makes the file names of the frames of the module relative to its root, as in billing/charge.go
+// instead of /home/ci/src/shop/billing/charge.go. The module must be at the root of the repository.
+// An empty module is the main module of the binary.
+func WithSourceLinking(module, version string) Option {
+	return func(r *Reporter) {
+		WithAppModule(module)(r)
+		WithVersion(version)(r)
+		r.trimPaths = true
+	}
+}
+
+// WithSourceURL links the frames of the application to their line in the repository at the commit, in the SourceURL
+// of the frames, for the engineers to jump to the code from a report. The base url is the one of the repository, such
+// as https://github.com/acme/shop, and the links follow the format of its host: GitHub, GitLab, Bitbucket, or
+// GitHub's for the other hosts, such as GitHub Enterprise. As for WithSourceLinking, the frames of the application are
+// the ones of the module set with WithAppModule, which must be at the root of the repository.
+func WithSourceURL(baseURL, commit string) Option {
+	return func(r *Reporter) {
+		r.sourceURL = newSourceURL(baseURL, commit)
+	}
+}
+
+// sourceURL builds the links of WithSourceURL, as prefix + path + anchor + line
+type sourceURL struct {
+	prefix string
+	anchor string
+}
+
+func newSourceURL(baseURL, commit string) *sourceURL {
+	base := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), ".git")
+	host := ""
+	if u, err := url.Parse(base); err == nil {
+		host = strings.ToLower(u.Hostname())
+	}
+
+	switch {
+	case strings.Contains(host, "gitlab"):
+		return &sourceURL{prefix: base + "/-/blob/" + commit + "/", anchor: "#L"}
+	case strings.Contains(host, "bitbucket"):
+		return &sourceURL{prefix: base + "/src/" + commit + "/", anchor: "#lines-"}
+	default:
+		return &sourceURL{prefix: base + "/blob/" + commit + "/", anchor: "#L"}
+	}
+}
+
+// link returns a copy of the stacktrace where the frames of the module have their SourceURL
+func (s *sourceURL) link(stack StackTrace, module string) StackTrace {
+	linked := make(StackTrace, len(stack))
+	copy(linked, stack)
+
+	for i, frame := range trimPaths(stack, module) {
+		if !frame.InApp && frame.PackageName != "main" {
+			continue
+		}
+		if path.IsAbs(frame.FileName) || strings.Contains(frame.FileName, ":") {
+			continue // not under the root of the module
+		}
+		linked[i].SourceURL = s.prefix + frame.FileName + s.anchor + strconv.Itoa(frame.LineNumber)
+	}
+	return linked
+}
+
+// trimPaths returns a copy of the stacktrace where the file names of the frames of the module are relative to its
+// root. The path of a file inside the module is found from its package, which gives the root of the module on the
+// build machine, so that the frames of the main package, whose package isn't the module path, are trimmed too.
+func trimPaths(stack StackTrace, module string) StackTrace {
+	trimmed := make(StackTrace, len(stack))
+	copy(trimmed, stack)
+
+	root := ""
+	for i, frame := range trimmed {
+		if frame.PackageName != module && !strings.HasPrefix(frame.PackageName, module+"/") {
+			continue
+		}
+		rel := path.Join(strings.TrimPrefix(strings.TrimPrefix(frame.PackageName, module), "/"), path.Base(frame.FileName))
+		if root == "" && strings.HasSuffix(frame.FileName, "/"+rel) {
+			root = strings.TrimSuffix(frame.FileName, rel)
+		}
+		trimmed[i].FileName = rel
+	}
+
+	if root != "" {
+		for i, frame := range trimmed {
+			if frame.PackageName == "main" && strings.HasPrefix(frame.FileName, root) {
+				trimmed[i].FileName = strings.TrimPrefix(frame.FileName, root)
+			}
+		}
+	}
+	return trimmed
+}
@@ -0,0 +1,98 @@
+package crashreport
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithSourceLinking(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	stack := StackTrace{}
+	stack.AddEntry(10, "github.com/acme/shop/billing", "/home/ci/src/shop/billing/charge.go", "Charge")
+	stack.AddEntry(20, "github.com/acme/shop", "/home/ci/src/shop/shop.go", "Checkout")
+	stack.AddEntry(30, "main", "/home/ci/src/shop/cmd/server/main.go", "main")
+	stack.AddEntry(40, "github.com/acme/shop/cart", "github.com/acme/shop/cart/cart.go", "Add")
+	stack.AddEntry(50, "net/http", "/usr/local/go/src/net/http/server.go", "ServeHTTP")
+	err := Error{Message: "declined", StackTrace: stack}
+
+	NewReporter("key", WithSourceLinking("github.com/acme/shop", "1.4.2")).Report(err)
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	if version := sent[0].Details.Version; version != "1.4.2" {
+		t.Errorf("expected the version 1.4.2, got %q", version)
+	}
+
+	var files []string
+	for _, frame := range sent[0].Details.Error.StackTrace {
+		files = append(files, frame.FileName)
+	}
+	expected := []string{
+		"billing/charge.go", "shop.go", "cmd/server/main.go", "cart/cart.go", "/usr/local/go/src/net/http/server.go",
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected the files %v, got %v", expected, files)
+	}
+	if frame := sent[0].Details.Error.StackTrace[0]; !frame.InApp {
+		t.Error("the frames of the module should be in app")
+	}
+	if stack[0].FileName != "/home/ci/src/shop/billing/charge.go" {
+		t.Error("the stacktrace of the error should not be modified")
+	}
+}
+
+func TestWithSourceURL(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	stack := StackTrace{}
+	stack.AddEntry(10, "github.com/acme/shop/billing", "/home/ci/src/shop/billing/charge.go", "Charge")
+	stack.AddEntry(30, "main", "/home/ci/src/shop/cmd/server/main.go", "main")
+	stack.AddEntry(50, "net/http", "/usr/local/go/src/net/http/server.go", "ServeHTTP")
+	err := Error{Message: "declined", StackTrace: stack}
+
+	tests := []struct {
+		base     string
+		expected []string
+	}{
+		{"https://github.com/acme/shop", []string{
+			"https://github.com/acme/shop/blob/abc123/billing/charge.go#L10",
+			"https://github.com/acme/shop/blob/abc123/cmd/server/main.go#L30",
+			"",
+		}},
+		{"https://gitlab.com/acme/shop.git", []string{
+			"https://gitlab.com/acme/shop/-/blob/abc123/billing/charge.go#L10",
+			"https://gitlab.com/acme/shop/-/blob/abc123/cmd/server/main.go#L30",
+			"",
+		}},
+		{"https://bitbucket.org/acme/shop/", []string{
+			"https://bitbucket.org/acme/shop/src/abc123/billing/charge.go#lines-10",
+			"https://bitbucket.org/acme/shop/src/abc123/cmd/server/main.go#lines-30",
+			"",
+		}},
+	}
+	for _, test := range tests {
+		NewReporter("key", WithAppModule("github.com/acme/shop"), WithSourceURL(test.base, "abc123")).Report(err)
+	}
+
+	sent := server.Posts()
+	if len(sent) != len(tests) {
+		t.Fatalf("expected %d posts, got %d", len(tests), len(sent))
+	}
+	for i, test := range tests {
+		var urls []string
+		for _, frame := range sent[i].Details.Error.StackTrace {
+			urls = append(urls, frame.SourceURL)
+		}
+		if !reflect.DeepEqual(urls, test.expected) {
+			t.Errorf("%s: expected the urls %v, got %v", test.base, test.expected, urls)
+		}
+		if file := sent[i].Details.Error.StackTrace[0].FileName; file != "/home/ci/src/shop/billing/charge.go" {
+			t.Errorf("expected the file names to be kept without WithSourceLinking, got %s", file)
+		}
+	}
+}
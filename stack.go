@@ -0,0 +1,103 @@
+package crashreport
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseStack parses the stack of the first goroutine of a dump, as written by runtime.Stack, debug.Stack or an
+// unrecovered panic, into a StackTrace. It's the parser used by FromErr when the error carries no stacktrace.
+//
+// It understands the formats of the different go versions: function arguments are stripped, elided frames are
+// skipped, and the "created by" line becomes the last frame.
+// Lines that are not part of the dump, such as the panic message before it, are ignored.
+func ParseStack(raw []byte) StackTrace {
+	stack := StackTrace{}
+
+	lines := strings.Split(strings.Replace(string(raw), "\r\n", "\n", -1), "\n")
+
+	// Find the first goroutine
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "goroutine ") && strings.HasSuffix(strings.TrimSpace(line), ":") {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return stack
+	}
+
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "goroutine ") {
+			break
+		}
+		if strings.HasPrefix(line, "...") {
+			continue
+		}
+		if i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "\t") {
+			continue
+		}
+
+		pack, method := parseFunction(line)
+		file, n := parseLocation(strings.TrimSpace(lines[i+1]))
+		stack.AddEntry(n, pack, file, method)
+		i++
+	}
+
+	return stack
+}
+
+// parseFunction splits a function line of a dump into the package and the method, stripping the arguments:
+//
+//	github.com/pkg/errors.(*fundamental).Error(0xc000010018)
+//	created by net/http.(*Server).Serve in goroutine 1
+func parseFunction(line string) (pack, method string) {
+	if strings.HasPrefix(line, "created by ") {
+		line = strings.TrimPrefix(line, "created by ")
+		if i := strings.Index(line, " in goroutine "); i >= 0 {
+			line = line[:i]
+		}
+	} else if strings.HasSuffix(line, ")") {
+		if i := strings.LastIndex(line, "("); i > 0 {
+			line = line[:i]
+		}
+	}
+
+	slash := strings.LastIndex(line, "/")
+	dot := strings.Index(line[slash+1:], ".")
+	if dot < 0 {
+		// Builtins such as panic() are implemented by the runtime
+		return "runtime", line
+	}
+	dot += slash + 1
+	return line[:dot], line[dot+1:]
+}
+
+// parseLocation splits a location line of a dump into the file and the line number:
+//
+//	/usr/local/go/src/net/http/server.go:3086 +0x5cb
+func parseLocation(line string) (file string, n int) {
+	if i := strings.LastIndex(line, " +0x"); i >= 0 {
+		line = line[:i]
+	}
+	i := strings.LastIndex(line, ":")
+	if i < 0 {
+		return line, -1
+	}
+	n, err := strconv.Atoi(line[i+1:])
+	if err != nil {
+		n = -1
+	}
+	return line[:i], n
+}
+
+// WithFlatStackInData adds the stacktrace of the reports as a string, in the format of a go panic, under the
+// "stackString" key of the data of the error, for the backends and the readers wanting it flat. The structured
+// stacktrace is still sent. See StackTrace.String.
+func WithFlatStackInData() Option {
+	return func(r *Reporter) {
+		r.flatStack = true
+	}
+}
@@ -0,0 +1,119 @@
+package crashreport
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	pkerr "github.com/pkg/errors"
+)
+
+type frame struct {
+	pack, method, file string
+	line               int
+}
+
+func assertFrames(t *testing.T, name string, stack StackTrace, expected []frame) {
+	t.Helper()
+	if len(stack) != len(expected) {
+		t.Fatalf("%s: expected %d frames, got %d: %+v", name, len(expected), len(stack), stack)
+	}
+	for i, exp := range expected {
+		got := frame{stack[i].PackageName, stack[i].MethodName, stack[i].FileName, stack[i].LineNumber}
+		if got != exp {
+			t.Errorf("%s: frame %d should be %+v, got %+v", name, i, exp, got)
+		}
+	}
+}
+
+func TestParseStack(t *testing.T) {
+	// go 1.11, arguments as words, no offsets on the creator
+	go111 := `panic: boom
+
+goroutine 5 [running]:
+main.(*worker).run(0xc42000e1e0, 0x1)
+	/home/user/go/src/example.com/app/worker.go:42 +0x39
+created by main.main
+	/home/user/go/src/example.com/app/main.go:12 +0x5a
+exit status 2
+`
+	assertFrames(t, "go1.11", ParseStack([]byte(go111)), []frame{
+		{"main", "(*worker).run", "/home/user/go/src/example.com/app/worker.go", 42},
+		{"main", "main", "/home/user/go/src/example.com/app/main.go", 12},
+	})
+
+	// go 1.17, panic frame, elided arguments and frames
+	go117 := `goroutine 1 [running]:
+panic({0x4a5b20, 0xc000012345})
+	/usr/local/go/src/runtime/panic.go:1038 +0x215
+github.com/pkg/errors.(*fundamental).Error(...)
+	/go/pkg/mod/github.com/pkg/errors@v0.9.1/errors.go:123
+example.com/app/handlers.Get.func1({0x4c2d50, 0xc0000a0000}, 0xc0000b2000)
+	/app/handlers/get.go:17 +0x1d
+...additional frames elided...
+`
+	assertFrames(t, "go1.17", ParseStack([]byte(go117)), []frame{
+		{"runtime", "panic", "/usr/local/go/src/runtime/panic.go", 1038},
+		{"github.com/pkg/errors", "(*fundamental).Error", "/go/pkg/mod/github.com/pkg/errors@v0.9.1/errors.go", 123},
+		{"example.com/app/handlers", "Get.func1", "/app/handlers/get.go", 17},
+	})
+
+	// go 1.21, creator goroutine, several goroutines in the dump
+	go121 := "goroutine 7 [running]:\r\n" +
+		"net/http.(*conn).serve(0xc000120000, {0x7a1e08, 0xc0001a2000})\r\n" +
+		"\t/usr/local/go/src/net/http/server.go:2009 +0x645\r\n" +
+		"created by net/http.(*Server).Serve in goroutine 1\r\n" +
+		"\t/usr/local/go/src/net/http/server.go:3086 +0x5cb\r\n" +
+		"\r\n" +
+		"goroutine 1 [IO wait]:\r\n" +
+		"internal/poll.runtime_pollWait(0x7f, 0x72)\r\n" +
+		"\t/usr/local/go/src/runtime/netpoll.go:343 +0x85\r\n"
+	assertFrames(t, "go1.21", ParseStack([]byte(go121)), []frame{
+		{"net/http", "(*conn).serve", "/usr/local/go/src/net/http/server.go", 2009},
+		{"net/http", "(*Server).Serve", "/usr/local/go/src/net/http/server.go", 3086},
+	})
+
+	if stack := ParseStack([]byte("not a stack")); len(stack) != 0 {
+		t.Errorf("garbage should give an empty stack, got %+v", stack)
+	}
+}
+
+func TestParseStackRuntime(t *testing.T) {
+	raw := make([]byte, 1<<16)
+	raw = raw[:runtime.Stack(raw, false)]
+
+	stack := ParseStack(raw)
+	if len(stack) < 2 {
+		t.Fatalf("expected at least 2 frames, got %+v", stack)
+	}
+	if stack[0].PackageName != "github.com/chennqqi/crashreport" || stack[0].MethodName != "TestParseStackRuntime" {
+		t.Errorf("first frame should be the test, got %+v", stack[0])
+	}
+	if stack[0].LineNumber <= 0 {
+		t.Errorf("line number should be parsed, got %d", stack[0].LineNumber)
+	}
+}
+
+func TestWithFlatStackInData(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key", WithFlatStackInData())
+	reporter.Report(pkerr.New("failure"))
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 post, got %d", len(sent))
+	}
+	e := sent[0].Details.Error
+	flat, _ := e.Data.(map[string]interface{})["stackString"].(string)
+	if len(e.StackTrace) == 0 || flat != e.StackTrace.String() {
+		t.Errorf("expected the flat stacktrace of the frames, got %q", flat)
+	}
+	top := e.StackTrace[0]
+	expected := fmt.Sprintf("%s.%s\n\t%s:%d\n", top.PackageName, top.MethodName, top.FileName, top.LineNumber)
+	if top.MethodName != "TestWithFlatStackInData" || !strings.HasPrefix(flat, expected) {
+		t.Errorf("expected the flat stacktrace to start with %q, got %q", expected, flat)
+	}
+}
@@ -0,0 +1,54 @@
+package crashreport
+
+import (
+	"runtime"
+)
+
+// WithCaptureStackAtReport sets the stacktrace of a report whose error carries none to the stack of the function
+// creating the option, skip frames higher, instead of the stack where the error is converted, which is the one of the
+// reporter. It's meant for the central helpers reporting the errors of the application, which pass their own frame
+// so that the top frame is the function that called them:
+//
+//	func handleError(err error) {
+//		log.Print(err)
+//		reporter.Report(err, crashreport.WithCaptureStackAtReport(1))
+//	}
+//
+// A skip of 0 starts the stack at the helper itself. The stacktraces of the errors, such as the ones of pkg/errors or
+// of an Error, are kept: they tell where the error was created.
+func WithCaptureStackAtReport(skip int) ReportOption {
+	stack := callers(skip)
+	return func(post *Post) {
+		if post.err != nil && !carriesStack(post.err) {
+			post.Details.Error.StackTrace = stack
+		}
+	}
+}
+
+// callers returns the stack of the goroutine, starting at the caller of the function calling callers, skip frames
+// higher
+func callers(skip int) StackTrace {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(skip+3, pcs)])
+
+	stack := StackTrace{}
+	for {
+		frame, more := frames.Next()
+		pack, method := parseFunction(frame.Function)
+		stack.AddEntry(frame.Line, pack, frame.File, method)
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// carriesStack tells if FromErr found the stacktrace of the error in the error itself, rather than capturing the
+// stack of the goroutine
+func carriesStack(err error) bool {
+	switch err.(type) {
+	case Error, *Error:
+		return true
+	}
+	return len(stackers(err)) > 0
+}
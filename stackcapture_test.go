@@ -0,0 +1,40 @@
+package crashreport
+
+import (
+	"errors"
+	"testing"
+
+	pkerr "github.com/pkg/errors"
+)
+
+func reportThroughHelper(reporter *Reporter, err error) {
+	reporter.Report(err, WithCaptureStackAtReport(1))
+}
+
+func callHelper(reporter *Reporter, err error) {
+	reportThroughHelper(reporter, err)
+}
+
+func TestWithCaptureStackAtReport(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	callHelper(reporter, errors.New("no stack"))
+	callHelper(reporter, pkerr.New("with stack"))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	top := sent[0].Details.Error.StackTrace[0]
+	if top.MethodName != "callHelper" || top.PackageName != "github.com/chennqqi/crashreport" {
+		t.Errorf("expected the caller of the helper as top frame, got %+v", top)
+	}
+	if next := sent[0].Details.Error.StackTrace[1]; next.MethodName != "TestWithCaptureStackAtReport" {
+		t.Errorf("expected the stack of the caller, got %+v", next)
+	}
+	if top := sent[1].Details.Error.StackTrace[0]; top.MethodName != "TestWithCaptureStackAtReport" {
+		t.Errorf("expected the stacktrace of the error to be kept, got %+v", top)
+	}
+}
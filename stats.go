@@ -0,0 +1,50 @@
+package crashreport
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats are counters of the reports processed by a reporter
+type Stats struct {
+	Ignored int64 // errors dropped by WithIgnoreErrors
+	Sampled int64 // reports dropped by WithSampleRate or WithAdaptiveSampling
+	DryRun  int64 // reports not submitted because of WithDryRun
+
+	GlobalRateLimited int64 // reports dropped by WithGlobalRateLimit
+	Suppressed        int64 // reports dropped by WithReportOncePerFingerprint or WithCrashLoopThrottle
+	BelowMinLevel     int64 // reports dropped by WithMinLevel
+
+	RetryBudgetUsed float64       // share of the budget of WithRetryBudget used by the recent retries, between 0 and 1
+	SubmitTimeout   time.Duration // the current timeout of WithAdaptiveTimeout, zero without
+
+	SampleRates map[string]float64 // the current sampling rate of each error, by Fingerprint
+}
+
+// stats holds the counters, updated atomically
+type stats struct {
+	ignored int64
+	sampled int64
+	dryRun  int64
+
+	globalRateLimited int64
+	suppressed        int64
+	belowMinLevel     int64
+}
+
+// Stats returns a snapshot of the counters of the reporter
+func (r *Reporter) Stats() Stats {
+	return Stats{
+		Ignored:     atomic.LoadInt64(&r.stats.ignored),
+		Sampled:     atomic.LoadInt64(&r.stats.sampled),
+		DryRun:      atomic.LoadInt64(&r.stats.dryRun),
+		SampleRates: r.sampleRates(),
+
+		GlobalRateLimited: atomic.LoadInt64(&r.stats.globalRateLimited),
+		Suppressed:        atomic.LoadInt64(&r.stats.suppressed),
+		BelowMinLevel:     atomic.LoadInt64(&r.stats.belowMinLevel),
+
+		RetryBudgetUsed: r.retryBudget.used(),
+		SubmitTimeout:   r.adaptiveTimeout.timeout(),
+	}
+}
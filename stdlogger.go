@@ -0,0 +1,77 @@
+package crashreport
+
+import (
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// StdLoggerOption configures the logger returned by NewStdLogger
+type StdLoggerOption func(*stdLogWriter)
+
+// WithStdLoggerOutput sets where the logger writes, besides reporting. The default is os.Stderr.
+func WithStdLoggerOutput(w io.Writer) StdLoggerOption {
+	return func(s *stdLogWriter) {
+		s.out = w
+	}
+}
+
+// WithStdLoggerFilter only reports the entries starting with prefix, such as "ERROR". The date and time written by the
+// logger are ignored. By default every entry is reported.
+func WithStdLoggerFilter(prefix string) StdLoggerOption {
+	return func(s *stdLogWriter) {
+		s.filter = prefix
+	}
+}
+
+// NewStdLogger returns a standard library logger whose entries are written to os.Stderr and reported with
+// CaptureMessage. It's the simplest integration for code that only knows about *log.Logger. The level of the reports is
+// guessed from the content of the entries, and an entry spanning several lines is a single report.
+func NewStdLogger(reporter *Reporter, opts ...StdLoggerOption) *log.Logger {
+	w := &stdLogWriter{reporter: reporter, out: os.Stderr}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return log.New(w, "", log.LstdFlags)
+}
+
+// logTimestamp matches the date and time written by the standard logger
+var logTimestamp = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} )?(\d{2}:\d{2}:\d{2}(\.\d+)? )?`)
+
+// levels of the reports, from the levels guessed for the breadcrumbs
+var breadcrumbLevels = map[int]Level{
+	BreadcrumbDebug:   LevelDebug,
+	BreadcrumbInfo:    LevelInfo,
+	BreadcrumbWarning: LevelWarning,
+	BreadcrumbError:   LevelError,
+}
+
+// stdLogWriter reports every entry written by a logger. A logger writes each entry with a single call to Write.
+type stdLogWriter struct {
+	mu       sync.Mutex
+	reporter *Reporter
+	out      io.Writer
+	filter   string
+	server   bool // parses the entries of an http.Server, see NewServerErrorLog
+}
+
+func (s *stdLogWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	n, err := s.out.Write(p)
+	s.mu.Unlock()
+
+	message := strings.TrimRight(logTimestamp.ReplaceAllString(string(p), ""), "\n")
+	if message != "" && strings.HasPrefix(message, s.filter) {
+		if s.server {
+			s.reportServerEntry(message)
+		} else {
+			level := breadcrumbLevels[logLevel(message)]
+			s.reporter.CaptureMessage(message, WithLevel(level), WithIdentifier(callerOutside("log.")))
+		}
+	}
+
+	return n, err
+}
@@ -0,0 +1,42 @@
+package crashreport
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewStdLogger(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	var out bytes.Buffer
+	logger := NewStdLogger(NewReporter("key"), WithStdLoggerOutput(&out), WithStdLoggerFilter("ERROR"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.Print("INFO all good")
+		}()
+	}
+	wg.Wait()
+	logger.Print("ERROR cannot open file\ndetails on the next line")
+
+	if strings.Count(out.String(), "\n") != 5 {
+		t.Errorf("every entry should be written to the output, got %q", out.String())
+	}
+
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("only the filtered entry should be reported, got %d", len(sent))
+	}
+	if msg := sent[0].Details.Error.Message; msg != "ERROR cannot open file\ndetails on the next line" {
+		t.Errorf("the multi-line entry should be a single message without timestamp, got %q", msg)
+	}
+	if tags := sent[0].Details.Tags; len(tags) != 1 || tags[0] != "severity:error" {
+		t.Errorf("the level should be guessed from the entry, got %v", tags)
+	}
+}
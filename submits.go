@@ -0,0 +1,32 @@
+package crashreport
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// WithMaxConcurrentSubmits bounds the number of submissions in flight at the same time to n, whether they come from
+// synchronous reports, the workers of WithAsync or DrainQueue, so that a burst of errors doesn't open hundreds of
+// connections to Raygun. The submissions over the limit wait for a slot until the deadline of their report, see
+// ReportCtx, or until the reporter is closed, and fail with the error of the context then.
+func WithMaxConcurrentSubmits(n int) Option {
+	return func(r *Reporter) {
+		if n > 0 {
+			r.submits = make(chan struct{}, n)
+		}
+	}
+}
+
+// acquireSubmit waits for a submission slot, see WithMaxConcurrentSubmits. The returned function releases it.
+func (r *Reporter) acquireSubmit(ctx context.Context) (release func(), err error) {
+	if r.submits == nil {
+		return func() {}, nil
+	}
+	select {
+	case r.submits <- struct{}{}:
+		return func() { <-r.submits }, nil
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "wait for a submission slot")
+	}
+}
@@ -0,0 +1,66 @@
+package crashreport
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// inFlightSink records the highest number of posts sent at the same time
+type inFlightSink struct {
+	inFlight int64
+	max      int64
+	sent     int64
+}
+
+func (s *inFlightSink) Send(ctx context.Context, post Post, key string) error {
+	n := atomic.AddInt64(&s.inFlight, 1)
+	defer atomic.AddInt64(&s.inFlight, -1)
+	for {
+		max := atomic.LoadInt64(&s.max)
+		if n <= max || atomic.CompareAndSwapInt64(&s.max, max, n) {
+			break
+		}
+	}
+	time.Sleep(2 * time.Millisecond)
+	atomic.AddInt64(&s.sent, 1)
+	return nil
+}
+
+func TestWithMaxConcurrentSubmits(t *testing.T) {
+	sink := &inFlightSink{}
+	reporter := NewReporter("key", WithSink(sink), WithMaxConcurrentSubmits(3))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := reporter.Report(errors.New("burst")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if sink.sent != 50 {
+		t.Errorf("expected 50 posts, got %d", sink.sent)
+	}
+	if sink.max > 3 || sink.max < 2 {
+		t.Errorf("expected up to 3 submissions in flight, got %d", sink.max)
+	}
+
+	block := make(blockingSink)
+	blocked := NewReporter("key", WithSink(block), WithMaxConcurrentSubmits(1))
+	go blocked.Report(errors.New("slow"))
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := blocked.ReportCtx(ctx, errors.New("waiting")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to bound the wait, got %v", err)
+	}
+	close(block)
+}
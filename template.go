@@ -0,0 +1,96 @@
+package crashreport
+
+import (
+	"time"
+)
+
+// WithTemplate sets the post that every report starts from, instead of NewPost(). Only OccuredOn is updated, so build
+// the template from NewPost() to keep the machine and environment info:
+//
+//	template := crashreport.NewPost()
+//	template.Details.Version = version
+//	template.Details.Tags = []string{"billing"}
+//	reporter := crashreport.NewReporter(key, crashreport.WithTemplate(template))
+//
+// The reporter keeps its own copy of the template.
+func WithTemplate(template Post) Option {
+	return func(r *Reporter) {
+		template = template.Clone()
+		r.template = &template
+	}
+}
+
+// Template returns a copy of the post the reports start from, NewPost() if no template is configured
+func (r *Reporter) Template() Post {
+	return r.newPost()
+}
+
+// newPost returns the post a report starts from: a copy of the template, or NewPost()
+func (r *Reporter) newPost() Post {
+	if r.template == nil {
+		return NewPost()
+	}
+	post := r.template.Clone()
+	post.OccuredOn = time.Now().UTC().Format(TimeFormat)
+	return post
+}
+
+// Clone returns a deep copy of the post: changing the copy's tags, breadcrumbs, custom data or request doesn't change
+// the original. Values stored in the custom data are copied if they are maps or slices of interface{}.
+func (p Post) Clone() Post {
+	d := &p.Details
+	d.Error = d.Error.clone()
+	d.Breadcrumbs = append([]Breadcrumb(nil), d.Breadcrumbs...)
+	for i := range d.Breadcrumbs {
+		d.Breadcrumbs[i].CustomData = cloneValue(d.Breadcrumbs[i].CustomData)
+	}
+	d.Environment.DiskSpaceFree = append([]int64(nil), d.Environment.DiskSpaceFree...)
+	d.Tags = append([]string(nil), d.Tags...)
+	d.UserCustomData = cloneValue(d.UserCustomData)
+	d.Request.QueryString = cloneStrings(d.Request.QueryString)
+	d.Request.Form = cloneStrings(d.Request.Form)
+	d.Request.Headers = cloneStrings(d.Request.Headers)
+	d.Request.RawData = cloneValue(d.Request.RawData)
+	return p
+}
+
+// clone returns a deep copy of the error
+func (e Error) clone() Error {
+	e.Data = cloneValue(e.Data)
+	e.StackTrace = append(StackTrace(nil), e.StackTrace...)
+	return e
+}
+
+// cloneValue copies the maps and slices of interface{}, recursively. Other values are returned as is.
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			c[k] = cloneValue(value)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, value := range v {
+			c[i] = cloneValue(value)
+		}
+		return c
+	case map[string]string:
+		return cloneStrings(v)
+	default:
+		return v
+	}
+}
+
+// cloneStrings copies a map of strings, keeping nil maps nil
+func cloneStrings(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,46 @@
+package crashreport
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithTemplate(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	template := NewPost()
+	template.Details.Version = "1.2.3"
+	template.Details.Tags = make([]string, 1, 10) // spare capacity, shared by naive copies
+	template.Details.Tags[0] = "billing"
+	template.SetCustomData("region", map[string]interface{}{"name": "eu"})
+	reporter := NewReporter("key", WithTemplate(template))
+
+	template.Details.Tags[0] = "changed after"
+	reporter.Report(errors.New("first"), WithTags("first"), func(post *Post) {
+		post.Details.UserCustomData.(map[string]interface{})["region"].(map[string]interface{})["name"] = "us"
+	})
+	reporter.Report(errors.New("second"))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	if sent[0].Details.Version != "1.2.3" || !reflect.DeepEqual(sent[0].Details.Tags, []string{"billing", "first"}) {
+		t.Errorf("the report should start from the template, got version %q and tags %v", sent[0].Details.Version, sent[0].Details.Tags)
+	}
+	if !reflect.DeepEqual(sent[1].Details.Tags, []string{"billing"}) {
+		t.Errorf("the tags of a report leaked into the template: %v", sent[1].Details.Tags)
+	}
+	region := sent[1].Details.UserCustomData.(map[string]interface{})["region"].(map[string]interface{})
+	if region["name"] != "eu" {
+		t.Errorf("the custom data of a report leaked into the template: %v", region)
+	}
+
+	snapshot := reporter.Template()
+	snapshot.Details.Tags[0] = "mutated"
+	if tags := reporter.Template().Details.Tags; tags[0] != "billing" {
+		t.Errorf("Template should return a copy, got tags %v", tags)
+	}
+}
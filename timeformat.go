@@ -0,0 +1,75 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WithTimeFormat makes the reporter write the time of the errors and of the breadcrumbs in UTC with the layout, instead
+// of TimeFormat and Unix milliseconds, for the Raygun-compatible backends expecting another format. It's a json codec:
+// it replaces the codec of WithCodec, and the sinks of WithSink and the disk queue still get the posts as they are.
+func WithTimeFormat(layout string) Option {
+	return func(r *Reporter) {
+		r.codec = timestampCodec{func(t time.Time) interface{} { return t.UTC().Format(layout) }}
+	}
+}
+
+// WithUnixTimestamps makes the reporter write the time of the errors as a number of Unix milliseconds, like the
+// breadcrumbs, instead of TimeFormat. See WithTimeFormat.
+func WithUnixTimestamps() Option {
+	return func(r *Reporter) {
+		r.codec = timestampCodec{func(t time.Time) interface{} { return millis(t) }}
+	}
+}
+
+// millis returns the time in Unix milliseconds, the time of the breadcrumbs
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// timestampCodec encodes the posts in json, with the timestamps written by format
+type timestampCodec struct {
+	format func(time.Time) interface{}
+}
+
+func (timestampCodec) ContentType() string {
+	return "application/json"
+}
+
+// Encode shadows the timestamp fields of the post, so that they are encoded as returned by format
+func (c timestampCodec) Encode(post Post) ([]byte, error) {
+	type breadcrumb struct {
+		Breadcrumb
+		Timestamp interface{} `json:"timestamp,omitempty"`
+	}
+	type details struct {
+		Details
+		Breadcrumbs []breadcrumb `json:"breadcrumbs,omitempty"`
+	}
+	type wire struct {
+		Post
+		OccuredOn interface{} `json:"occurredOn,omitempty"`
+		Details   details     `json:"details,omitempty"`
+	}
+
+	w := wire{Post: post, Details: details{Details: post.Details}}
+	if t, err := post.OccurredTime(); err == nil {
+		w.OccuredOn = c.format(t)
+	} else if post.OccuredOn != "" {
+		w.OccuredOn = post.OccuredOn
+	}
+	for _, crumb := range post.Details.Breadcrumbs {
+		b := breadcrumb{Breadcrumb: crumb}
+		if crumb.Timestamp != 0 {
+			b.Timestamp = c.format(time.Unix(0, crumb.Timestamp*int64(time.Millisecond)))
+		}
+		w.Details.Breadcrumbs = append(w.Details.Breadcrumbs, b)
+	}
+
+	data, err := json.Marshal(w)
+	if err == nil || post.Details.UserCustomData == nil {
+		return data, err
+	}
+	w.Details.UserCustomData = safeCustomData(post.Details.UserCustomData)
+	return json.Marshal(w)
+}
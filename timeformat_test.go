@@ -0,0 +1,57 @@
+package crashreport
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeFormat(t *testing.T) {
+	occurred := time.Date(2024, 3, 1, 12, 30, 15, 250e6, time.UTC)
+	crumb := time.Date(2024, 3, 1, 12, 30, 14, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		opts       []Option
+		occurredOn interface{}
+		timestamp  interface{}
+	}{
+		{"default", nil, "2024-03-01T12:30:15.250Z", float64(crumb.UnixNano() / 1e6)},
+		{"layout", []Option{WithTimeFormat(time.RFC1123)}, "Fri, 01 Mar 2024 12:30:15 UTC", "Fri, 01 Mar 2024 12:30:14 UTC"},
+		{"unix", []Option{WithUnixTimestamps()}, float64(occurred.UnixNano() / 1e6), float64(crumb.UnixNano() / 1e6)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body []byte
+			opts := append(test.opts, WithDryRun(true, func(b []byte) { body = b }))
+			reporter := NewReporter("key", opts...)
+			defer reporter.Close(time.Second)
+
+			err := reporter.CaptureMessage("message", WithOccurredOn(occurred),
+				WithBreadcrumbs(Breadcrumb{Message: "step", Timestamp: crumb.UnixNano() / 1e6}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var sent struct {
+				OccurredOn interface{} `json:"occurredOn"`
+				Details    struct {
+					Breadcrumbs []map[string]interface{} `json:"breadcrumbs"`
+					Error       Error                    `json:"error"`
+				} `json:"details"`
+			}
+			if err := json.Unmarshal(body, &sent); err != nil {
+				t.Fatal(err)
+			}
+			if sent.OccurredOn != test.occurredOn {
+				t.Errorf("expected occurredOn %v, got %v", test.occurredOn, sent.OccurredOn)
+			}
+			if len(sent.Details.Breadcrumbs) != 1 || sent.Details.Breadcrumbs[0]["timestamp"] != test.timestamp {
+				t.Errorf("expected the breadcrumb timestamp %v, got %v", test.timestamp, sent.Details.Breadcrumbs)
+			}
+			if sent.Details.Error.Message != "message" {
+				t.Errorf("expected the rest of the post, got %+v", sent.Details.Error)
+			}
+		})
+	}
+}
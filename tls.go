@@ -0,0 +1,37 @@
+package crashreport
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// WithRequestTLS adds the state of the TLS connection of the request to the custom data, under the "tls" key: the
+// version, the cipher suite, the negotiated protocol, the server name and whether the client presented a certificate.
+// The certificates themselves are not sent. Requests that didn't come over TLS are skipped.
+func WithRequestTLS(req *http.Request) ReportOption {
+	return func(post *Post) {
+		if req == nil || req.TLS == nil {
+			return
+		}
+		post.SetCustomData("tls", tlsInfo(req.TLS))
+	}
+}
+
+// WithTLSInfo makes the middleware add the state of the TLS connection to the report, see WithRequestTLS
+func WithTLSInfo() MiddlewareOption {
+	return func(m *middleware) {
+		m.tlsInfo = true
+	}
+}
+
+// tlsInfo returns the parts of the connection state useful to debug TLS errors
+func tlsInfo(state *tls.ConnectionState) map[string]interface{} {
+	return map[string]interface{}{
+		"version":            tls.VersionName(state.Version),
+		"cipherSuite":        tls.CipherSuiteName(state.CipherSuite),
+		"negotiatedProtocol": state.NegotiatedProtocol,
+		"serverName":         state.ServerName,
+		"resumed":            state.DidResume,
+		"clientCertificate":  len(state.PeerCertificates) > 0,
+	}
+}
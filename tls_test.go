@@ -0,0 +1,51 @@
+package crashreport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithTLSInfo(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	handler := NewReporter("key").Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic("handshake")
+	}), WithTLSInfo())
+
+	req := httptest.NewRequest("GET", "https://api.example.com/", nil)
+	req.TLS = &tls.ConnectionState{
+		Version:            tls.VersionTLS13,
+		CipherSuite:        tls.TLS_AES_128_GCM_SHA256,
+		NegotiatedProtocol: "h2",
+		ServerName:         "api.example.com",
+		PeerCertificates:   []*x509.Certificate{{Raw: []byte("certificate")}},
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://api.example.com/", nil))
+
+	sent := server.Posts()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(sent))
+	}
+	info := sent[0].Details.UserCustomData.(map[string]interface{})["tls"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"version":            "TLS 1.3",
+		"cipherSuite":        "TLS_AES_128_GCM_SHA256",
+		"negotiatedProtocol": "h2",
+		"serverName":         "api.example.com",
+		"resumed":            false,
+		"clientCertificate":  true,
+	}
+	for k, v := range expected {
+		if info[k] != v {
+			t.Errorf("expected %s to be %v, got %v", k, v, info[k])
+		}
+	}
+	if data := sent[1].Details.UserCustomData.(map[string]interface{}); data["tls"] != nil {
+		t.Errorf("requests without TLS should be skipped, got %v", data)
+	}
+}
@@ -0,0 +1,44 @@
+package crashreport
+
+import (
+	"time"
+)
+
+// timeNow returns the current time, a variable for the tests
+var timeNow = time.Now
+
+// uptimeBuckets are the upper bounds of the uptime tags
+var uptimeBuckets = []struct {
+	max  time.Duration
+	name string
+}{
+	{time.Minute, "<1m"},
+	{10 * time.Minute, "<10m"},
+	{time.Hour, "<1h"},
+	{24 * time.Hour, "<1d"},
+}
+
+// WithUptime adds the time the reporter was created, as the start time of the process, and the uptime in seconds to
+// the custom data of the reports, under the "startTime" and "uptimeSeconds" keys. The reports are also tagged with a
+// bucket of the uptime, from "uptime:<1m" to "uptime:>=1d", to spot the crashes right after a deploy or a restart.
+func WithUptime() Option {
+	return func(r *Reporter) {
+		start := timeNow()
+		r.enrichers = append(r.enrichers, func(post *Post) {
+			uptime := timeNow().Sub(start)
+			post.SetCustomData("startTime", start.UTC().Format(TimeFormat))
+			post.SetCustomData("uptimeSeconds", int64(uptime/time.Second))
+			post.Details.Tags = append(post.Details.Tags, "uptime:"+uptimeBucket(uptime))
+		})
+	}
+}
+
+// uptimeBucket returns the name of the bucket of the uptime
+func uptimeBucket(uptime time.Duration) string {
+	for _, bucket := range uptimeBuckets {
+		if uptime < bucket.max {
+			return bucket.name
+		}
+	}
+	return ">=1d"
+}
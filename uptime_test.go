@@ -0,0 +1,50 @@
+package crashreport
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithUptime(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+	defer func() { timeNow = time.Now }()
+
+	start := time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC)
+	clock := start
+	timeNow = func() time.Time { return clock }
+	reporter := NewReporter("key", WithUptime())
+
+	tests := []struct {
+		uptime time.Duration
+		bucket string
+	}{
+		{30 * time.Second, "uptime:<1m"},
+		{5 * time.Minute, "uptime:<10m"},
+		{45 * time.Minute, "uptime:<1h"},
+		{3 * time.Hour, "uptime:<1d"},
+		{50 * time.Hour, "uptime:>=1d"},
+	}
+	for _, test := range tests {
+		clock = start.Add(test.uptime)
+		reporter.Report(errors.New("crash"))
+	}
+
+	sent := server.Posts()
+	if len(sent) != len(tests) {
+		t.Fatalf("expected %d posts, got %d", len(tests), len(sent))
+	}
+	for i, test := range tests {
+		data := sent[i].Details.UserCustomData.(map[string]interface{})
+		if data["startTime"] != "2020-05-17T10:00:00.000Z" {
+			t.Errorf("expected the start time of the reporter, got %v", data["startTime"])
+		}
+		if uptime := data["uptimeSeconds"]; uptime != test.uptime.Seconds() {
+			t.Errorf("expected an uptime of %v seconds, got %v", test.uptime.Seconds(), uptime)
+		}
+		if tags := sent[i].Details.Tags; len(tags) != 1 || tags[0] != test.bucket {
+			t.Errorf("expected the tag %s, got %v", test.bucket, tags)
+		}
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/kaeuferportal/stack2struct"
 	pkgerr "github.com/pkg/errors"
 )
 
@@ -82,6 +81,27 @@ func data(err error) interface{} {
 	return nil
 }
 
+// fieldErrors returns the errors of each field of a validation error, if possible.
+// An error value has field errors if it implements the following
+// interface:
+//
+//     type fieldErrorer interface {
+//            FieldErrors() map[string]string
+//     }
+//
+// If the error does not implement FieldErrors, it returns nil
+func fieldErrors(err error) map[string]string {
+	type fieldErrorer interface {
+		FieldErrors() map[string]string
+	}
+
+	if e, ok := err.(fieldErrorer); ok {
+		return e.FieldErrors()
+	}
+
+	return nil
+}
+
 // data returns the stacktrace of the error.
 // It can rely on the errors internal interfaces, or create a new one from the current stacktrace
 // It accepts different interfaces:
@@ -153,11 +173,60 @@ func stacktrace(err error) StackTrace {
 
 	rawStackTrace := make([]byte, 1<<16)
 	rawStackTrace = rawStackTrace[:runtime.Stack(rawStackTrace, false)]
-	stack2struct.Parse(rawStackTrace, &stack)
+	stack = ParseStack(rawStackTrace)
 
 	return stack[2:]
 }
 
+// stackers returns the errors of the chain that have a stacktrace, outermost first, following Unwrap and Cause.
+// See stacktrace for the interfaces of stacktraces.
+func stackers(err error) []error {
+	type stackTracer1 interface {
+		StackTrace() pkgerr.StackTrace
+	}
+
+	type stackTracer2 interface {
+		StackTrace() []string
+	}
+
+	type causer interface {
+		Cause() error
+	}
+
+	var errs []error
+	for err != nil {
+		switch err.(type) {
+		case stackTracer1, stackTracer2:
+			errs = append(errs, err)
+		}
+
+		if e, ok := err.(interface{ Unwrap() error }); ok {
+			err = e.Unwrap()
+		} else if e, ok := err.(causer); ok {
+			err = e.Cause()
+		} else {
+			err = nil
+		}
+	}
+	return errs
+}
+
+// joinedErrors returns the errors joined by the first error of the chain wrapping several errors, such as the ones
+// of errors.Join, or nil if there is none
+func joinedErrors(err error) []error {
+	for err != nil {
+		if e, ok := err.(interface{ Unwrap() []error }); ok {
+			return e.Unwrap()
+		}
+		e, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return nil
+		}
+		err = e.Unwrap()
+	}
+	return nil
+}
+
 // arrayMapToStringMap converts a map[string][]string to a map[string]string
 // by joining all values of the containing array and wrapping them in brackets
 func arrayMapToStringMap(arrayMap map[string][]string) map[string]string {
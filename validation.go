@@ -0,0 +1,39 @@
+package crashreport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FromValidation creates an error struct from the errors of a form or api validation, keyed by field. The message
+// lists the invalid fields and the errors are kept in Data, under the "fields" key:
+//
+//	crashreport.FromValidation(map[string]string{"email": "invalid address", "age": "must be positive"})
+//	// validation failed on 2 fields: age, email
+//
+// FromErr does the same for the errors that implement `FieldErrors() map[string]string`.
+func FromValidation(errs map[string]string) Error {
+	e := Error{
+		ClassName:  "ValidationError",
+		Message:    validationMessage(errs),
+		StackTrace: stacktrace(nil),
+	}
+	e.SetData("fields", errs)
+	return e
+}
+
+// validationMessage lists the invalid fields, sorted so that the reports group together
+func validationMessage(errs map[string]string) string {
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	noun := "fields"
+	if len(fields) == 1 {
+		noun = "field"
+	}
+	return fmt.Sprintf("validation failed on %d %s: %s", len(fields), noun, strings.Join(fields, ", "))
+}
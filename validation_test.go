@@ -0,0 +1,45 @@
+package crashreport
+
+import (
+	"reflect"
+	"testing"
+)
+
+type formError map[string]string
+
+func (e formError) Error() string                  { return "invalid form" }
+func (e formError) FieldErrors() map[string]string { return e }
+
+func TestFromValidation(t *testing.T) {
+	fields := map[string]string{
+		"email":    "invalid address",
+		"age":      "must be positive",
+		"password": "too short",
+	}
+
+	e := FromValidation(fields)
+	if e.Message != "validation failed on 3 fields: age, email, password" {
+		t.Errorf("unexpected message %q", e.Message)
+	}
+	if e.ClassName != "ValidationError" {
+		t.Errorf("unexpected class %q", e.ClassName)
+	}
+	if got := e.Data.(map[string]interface{})["fields"]; !reflect.DeepEqual(got, fields) {
+		t.Errorf("expected the field errors in data, got %v", got)
+	}
+	if len(e.StackTrace) == 0 || e.StackTrace[0].MethodName != "TestFromValidation" {
+		t.Errorf("the stacktrace should start at the caller, got %v", e.StackTrace)
+	}
+
+	if e := FromValidation(map[string]string{"name": "required"}); e.Message != "validation failed on 1 field: name" {
+		t.Errorf("unexpected message %q", e.Message)
+	}
+
+	e = FromErr(formError(fields))
+	if e.Message != "invalid form" {
+		t.Errorf("unexpected message %q", e.Message)
+	}
+	if got := e.Data.(map[string]interface{})["fields"]; !reflect.DeepEqual(got, fields) {
+		t.Errorf("FromErr should use FieldErrors, got %v", got)
+	}
+}
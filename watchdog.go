@@ -0,0 +1,70 @@
+package crashreport
+
+import (
+	"sync"
+	"time"
+)
+
+// Watchdog reports a worker that stopped calling Heartbeat, which is often a deadlock. See NewWatchdog.
+type Watchdog struct {
+	reporter *Reporter
+	interval time.Duration
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	last    time.Time // of the last heartbeat
+	stopped bool
+}
+
+// NewWatchdog starts a watchdog expecting a heartbeat at least every interval. When a heartbeat is missing, a warning
+// is reported with CaptureMessage, with the stacks of all the goroutines as in CapturePanicFull. It's reported once
+// per stall: the next report needs a heartbeat first. Call Stop before shutting the worker down.
+func NewWatchdog(reporter *Reporter, interval time.Duration) *Watchdog {
+	w := &Watchdog{reporter: reporter, interval: interval, last: time.Now()}
+	w.timer = time.AfterFunc(interval, w.check)
+	return w
+}
+
+// Heartbeat tells the watchdog that the worker is alive
+func (w *Watchdog) Heartbeat() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return
+	}
+	w.last = time.Now()
+	w.timer.Reset(w.interval)
+}
+
+// Stop stops the watchdog, so that the end of the heartbeats isn't reported
+func (w *Watchdog) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stopped = true
+	w.timer.Stop()
+}
+
+// check reports a stall if no heartbeat happened during the interval
+func (w *Watchdog) check() {
+	w.mu.Lock()
+	if w.stopped || w.reporter.ctx.Err() != nil {
+		w.mu.Unlock()
+		return
+	}
+	silence := time.Since(w.last)
+	if silence < w.interval {
+		// A heartbeat raced with the timer
+		w.timer.Reset(w.interval - silence)
+		w.mu.Unlock()
+		return
+	}
+	w.mu.Unlock()
+
+	dump := goroutineDump()
+	w.reporter.CaptureMessage("possible deadlock: no heartbeat for "+silence.Round(time.Millisecond).String(),
+		WithLevel(LevelWarning), WithIdentifier("crashreport.Watchdog"), func(post *Post) {
+			setGoroutineDump(post, dump, w.reporter.goroutineDumpLimit)
+		})
+}
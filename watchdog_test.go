@@ -0,0 +1,45 @@
+package crashreport
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	server := mockRaygun(t)
+	defer server.Close()
+
+	reporter := NewReporter("key")
+	watchdog := NewWatchdog(reporter, 20*time.Millisecond)
+	for i := 0; i < 5; i++ {
+		time.Sleep(5 * time.Millisecond)
+		watchdog.Heartbeat()
+	}
+	if n := len(server.Posts()); n != 0 {
+		t.Fatalf("no stall should be reported while heartbeats arrive, got %d posts", n)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	sent := server.Posts()
+	if len(sent) != 1 {
+		t.Fatalf("expected the stall to be reported once, got %d posts", len(sent))
+	}
+	if !strings.HasPrefix(sent[0].Details.Error.Message, "possible deadlock: no heartbeat for ") {
+		t.Errorf("unexpected message %q", sent[0].Details.Error.Message)
+	}
+	if tags := sent[0].Details.Tags; len(tags) != 1 || tags[0] != "severity:warning" {
+		t.Errorf("expected a warning, got tags %v", tags)
+	}
+	data := sent[0].Details.UserCustomData.(map[string]interface{})
+	if dump, _ := data["goroutines"].(string); !strings.Contains(dump, "goroutine ") {
+		t.Errorf("expected the goroutine dump, got %v", data["goroutines"])
+	}
+
+	watchdog.Heartbeat()
+	watchdog.Stop()
+	time.Sleep(50 * time.Millisecond)
+	if n := len(server.Posts()); n != 1 {
+		t.Errorf("a stopped watchdog should not report, got %d posts", n)
+	}
+}
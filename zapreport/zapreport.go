@@ -0,0 +1,141 @@
+// Package zapreport reports the errors logged with go.uber.org/zap to Raygun with the crashreport package, through a
+// zapcore.Core.
+package zapreport
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chennqqi/crashreport"
+	"go.uber.org/zap/zapcore"
+)
+
+// syncTimeout bounds the submission of the queued reports by Sync
+const syncTimeout = 2 * time.Second
+
+// Core is a zapcore.Core that passes the entries to another core, and reports the ones at error level or above. The
+// error is taken from the first zap.Error field, the ones of With first, and the fields are sent as custom data, with
+// dotted keys for the namespaces and the objects:
+//
+//	logger := zap.New(zapreport.NewCore(core, reporter))
+//	logger.Error("payment failed", zap.Error(err), zap.String("order", id))
+//
+// It composes with the other cores, for example zapcore.NewTee(zapreport.NewCore(zapcore.NewNopCore(), reporter),
+// core) reports without wrapping the core. The entries at DPanicLevel and above have the fatal level.
+type Core struct {
+	next     zapcore.Core
+	reporter *crashreport.Reporter
+	fields   []zapcore.Field // from With
+}
+
+// NewCore returns a core passing the entries to next, and reporting the errors to reporter
+func NewCore(next zapcore.Core, reporter *crashreport.Reporter) *Core {
+	return &Core{next: next, reporter: reporter}
+}
+
+// Enabled implements zapcore.LevelEnabler. Errors are always enabled, to be reported.
+func (c *Core) Enabled(level zapcore.Level) bool {
+	return level >= zapcore.ErrorLevel || c.next.Enabled(level)
+}
+
+// With implements zapcore.Core
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(append(all, c.fields...), fields...)
+	return &Core{next: c.next.With(fields), reporter: c.reporter, fields: all}
+}
+
+// Check implements zapcore.Core: the entry is passed to the check of the next core, and added for the report if it's
+// an error
+func (c *Core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	checked = c.next.Check(entry, checked)
+	if entry.Level >= zapcore.ErrorLevel {
+		checked = checked.AddCore(entry, reportingCore{c})
+	}
+	return checked
+}
+
+// Write implements zapcore.Core, for the callers writing without Check: the entry is written by the next core, and
+// reported if it's an error
+func (c *Core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Level >= zapcore.ErrorLevel {
+		c.report(entry, fields)
+	}
+	return c.next.Write(entry, fields)
+}
+
+// Sync implements zapcore.Core. It syncs the next core and submits the queued reports, within 2 seconds.
+func (c *Core) Sync() error {
+	c.reporter.Flush(syncTimeout)
+	return c.next.Sync()
+}
+
+// report sends the entry to the reporter
+func (c *Core) report(entry zapcore.Entry, fields []zapcore.Field) {
+	enc := zapcore.NewMapObjectEncoder()
+	var cause error
+	for _, fields := range [][]zapcore.Field{c.fields, fields} {
+		for _, field := range fields {
+			if err, ok := field.Interface.(error); ok && field.Type == zapcore.ErrorType && cause == nil {
+				cause = err
+			}
+			field.AddTo(enc)
+		}
+	}
+
+	level := crashreport.LevelError
+	if entry.Level >= zapcore.DPanicLevel {
+		level = crashreport.LevelFatal
+	}
+	opts := []crashreport.ReportOption{crashreport.WithLevel(level)}
+	if entry.Caller.Defined && entry.Caller.Function != "" {
+		opts = append(opts, crashreport.WithIdentifier(entry.Caller.Function))
+	}
+	if entry.LoggerName != "" {
+		opts = append(opts, crashreport.WithCustomData("logger", entry.LoggerName))
+	}
+	for key, value := range flatten(enc.Fields, "") {
+		opts = append(opts, crashreport.WithCustomData(key, value))
+	}
+
+	if cause == nil {
+		c.reporter.Report(errors.New(entry.Message), opts...)
+		return
+	}
+	message := entry.Message + ": " + cause.Error()
+	opts = append(opts, func(post *crashreport.Post) { post.Details.Error.Message = message })
+	c.reporter.Report(cause, opts...)
+}
+
+// flatten returns the fields encoded by a zapcore.MapObjectEncoder, with the keys of the namespaces joined by dots
+func flatten(fields map[string]interface{}, prefix string) map[string]interface{} {
+	flat := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			for k, v := range flatten(nested, key) {
+				flat[k] = v
+			}
+			continue
+		}
+		flat[key] = value
+	}
+	return flat
+}
+
+// reportingCore is the core added to the checked entries of the errors, reporting them without writing them
+type reportingCore struct {
+	*Core
+}
+
+func (r reportingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	r.report(entry, fields)
+	return nil
+}
+
+func (r reportingCore) Sync() error {
+	r.reporter.Flush(syncTimeout)
+	return nil
+}
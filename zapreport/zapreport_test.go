@@ -0,0 +1,98 @@
+package zapreport
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chennqqi/crashreport"
+	"github.com/chennqqi/crashreport/crashreporttest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCore(t *testing.T) {
+	sink := crashreporttest.NewMemorySink()
+	reporter := crashreport.NewReporter("key", crashreport.WithSink(sink))
+	observed, logs := observer.New(zapcore.InfoLevel)
+
+	logger := zap.New(NewCore(observed, reporter)).Named("billing").With(zap.String("service", "payments"))
+	logger.Debug("not enabled")
+	logger.Info("charging", zap.Int("amount", 42))
+	logger.Error("payment failed", zap.Int("amount", 42), zap.Error(errors.New("card declined")),
+		zap.Namespace("order"), zap.String("id", "o-1"))
+
+	if n := logs.Len(); n != 2 {
+		t.Errorf("expected the enabled entries to be passed to the core, got %d", n)
+	}
+	posts := sink.Posts()
+	if len(posts) != 1 {
+		t.Fatalf("expected the error to be reported, got %d posts", len(posts))
+	}
+	post := posts[0]
+	if post.Details.Error.Message != "payment failed: card declined" {
+		t.Errorf("expected the message and the error, got %q", post.Details.Error.Message)
+	}
+	data, _ := post.Details.UserCustomData.(map[string]interface{})
+	expected := map[string]interface{}{"service": "payments", "amount": int64(42), "error": "card declined",
+		"order.id": "o-1", "logger": "billing", "level": "error"}
+	for key, value := range expected {
+		if data[key] != value {
+			t.Errorf("expected %v for %s in the custom data, got %v", value, key, data[key])
+		}
+	}
+}
+
+func TestCoreLevelsAndNamespaces(t *testing.T) {
+	sink := crashreporttest.NewMemorySink()
+	reporter := crashreport.NewReporter("key", crashreport.WithSink(sink))
+	logger := zap.New(NewCore(zapcore.NewNopCore(), reporter))
+
+	order := zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddString("id", "o-1")
+		return nil
+	})
+	logger.DPanic("inconsistent ledger", zap.Namespace("billing"), zap.Namespace("ledger"), zap.Int("entries", 3),
+		zap.Object("order", order), zap.Error(errors.New("negative balance")))
+
+	posts := sink.Posts()
+	if len(posts) != 1 {
+		t.Fatalf("expected the entry to be reported, got %d posts", len(posts))
+	}
+	post := posts[0]
+	if post.Level() != crashreport.LevelFatal {
+		t.Errorf("expected DPanic to be reported as fatal, got %v", post.Level())
+	}
+	if post.Details.Error.Message != "inconsistent ledger: negative balance" {
+		t.Errorf("expected the message and the error, got %q", post.Details.Error.Message)
+	}
+	data, _ := post.Details.UserCustomData.(map[string]interface{})
+	expected := map[string]interface{}{"billing.ledger.entries": int64(3), "billing.ledger.order.id": "o-1",
+		"billing.ledger.error": "negative balance"}
+	for key, value := range expected {
+		if data[key] != value {
+			t.Errorf("expected %v for %s in the custom data, got %v", value, key, data[key])
+		}
+	}
+}
+
+func TestCoreTee(t *testing.T) {
+	sink := crashreporttest.NewMemorySink()
+	reporter := crashreport.NewReporter("key", crashreport.WithSink(sink))
+	observed, logs := observer.New(zapcore.InfoLevel)
+
+	logger := zap.New(zapcore.NewTee(NewCore(zapcore.NewNopCore(), reporter), observed))
+	logger.Warn("slow query")
+	logger.With(zap.Error(errors.New("connection reset"))).Error("sync failed", zap.Error(errors.New("timeout")))
+
+	if n := logs.Len(); n != 2 {
+		t.Errorf("expected both entries to be written once by the other core, got %d", n)
+	}
+	posts := sink.Posts()
+	if len(posts) != 1 {
+		t.Fatalf("expected the error to be reported once, got %d posts", len(posts))
+	}
+	if message := posts[0].Details.Error.Message; message != "sync failed: connection reset" {
+		t.Errorf("expected the error of With to come first, got %q", message)
+	}
+}